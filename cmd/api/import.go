@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+	"IRIS-backend/internal/asset_mgmt/imports"
+	"IRIS-backend/internal/asset_mgmt/numbering"
+	"IRIS-backend/internal/platform/db"
+)
+
+// 新しい研究室の立ち上げなど、マスタ・在庫行をまとめて登録する（POST /assets/import と同じ処理）
+func runImport(ctx context.Context, conn *sql.DB, cfg *db.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "検証と採番だけ行ってロールバック")
+	format := fs.String("format", "", "csv|xlsx（省略時は拡張子・中身から判定）")
+	encoding := fs.String("encoding", imports.EncodingAuto, "CSV の文字コード（auto|utf-8|cp932）")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import: ファイルを1つ指定してください")
+	}
+	path := fs.Arg(0)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if *format != imports.FormatCSV && *format != imports.FormatXLSX {
+			*format = ""
+		}
+	}
+	scheme, err := numbering.New(cfg.Numbering)
+	if err != nil {
+		return err
+	}
+
+	svc := imports.NewService(conn, assets.NewService(conn, scheme))
+	rep, err := svc.Import(ctx, data, imports.Options{Format: *format, Encoding: *encoding, DryRun: *dryRun})
+	for _, e := range rep.Errors {
+		if e.Column != "" {
+			fmt.Fprintf(os.Stderr, "row %d [%s]: %s\n", e.Row, e.Column, e.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "row %d: %s\n", e.Row, e.Message)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(rep.Errors) > 0 {
+		return fmt.Errorf("import: %d 件の誤りがあります（dry-run）", len(rep.Errors))
+	}
+	for _, r := range rep.Rows {
+		line := fmt.Sprintf("row %d  %s", r.Row, r.ManagementNumber)
+		if r.AssetID != nil {
+			line += fmt.Sprintf("  asset_id=%d", *r.AssetID)
+		}
+		fmt.Println(line)
+	}
+	verb := "imported"
+	if *dryRun {
+		verb = "dry-run (rolled back)"
+	}
+	fmt.Printf("%s: rows=%d masters=%d assets=%d\n", verb, rep.TotalRows, rep.MastersCreated, rep.AssetsCreated)
+	return nil
+}
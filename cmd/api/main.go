@@ -0,0 +1,73 @@
+// 管理用CLI（サーバ本体はリポジトリ直下の main.go）
+//
+//	go run ./cmd/api [-config config/config.yaml] migrate up
+//	go run ./cmd/api migrate down [-steps N]
+//	go run ./cmd/api migrate status
+//	go run ./cmd/api user add -login admin -name 管理者
+//	go run ./cmd/api import -dry-run assets.xlsx
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+const usage = `usage: api [-config path] <command> [args]
+
+commands:
+  migrate up               未適用のマイグレーションをすべて適用
+  migrate down [-steps N]  適用済みを新しい順に N 件戻す（既定 1）
+  migrate status           マイグレーションの適用状況を表示
+  user add -login ID -name NAME [-role ROLE] [-password PW]
+                           ユーザを登録（パスワード省略時は標準入力）
+  import [-dry-run] [-format csv|xlsx] [-encoding auto|utf-8|cp932] FILE
+                           資産マスタ・在庫行を一括登録（全行1トランザクション）
+`
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "設定ファイルのパス")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := db.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn, err := db.Connect(cfg.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	switch args[0] {
+	case "migrate":
+		err = runMigrate(ctx, conn, args[1:])
+	case "user":
+		err = runUser(ctx, conn, cfg, args[1:])
+	case "import":
+		err = runImport(ctx, conn, cfg, args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
+}
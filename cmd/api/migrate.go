@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"IRIS-backend/internal/platform/migrate"
+)
+
+func runMigrate(ctx context.Context, conn *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: サブコマンド（up|down|status）を指定してください")
+	}
+	m, err := migrate.New(conn)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mg := range done {
+			fmt.Printf("applied  %04d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return nil
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "戻す件数")
+		_ = fs.Parse(args[1:])
+		done, err := m.Down(ctx, *steps)
+		for _, mg := range done {
+			fmt.Printf("reverted %04d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("nothing to revert")
+		}
+		return nil
+
+	case "status":
+		sts, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range sts {
+			at := "pending"
+			if st.AppliedAt != nil {
+				at = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, at)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("migrate: 不明なサブコマンド %q", args[0])
+	}
+}
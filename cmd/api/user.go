@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/users"
+)
+
+// 初回の管理者作成など、API を叩けない状況でのユーザ登録用
+func runUser(ctx context.Context, conn *sql.DB, cfg *db.Config, args []string) error {
+	if len(args) == 0 || args[0] != "add" {
+		return fmt.Errorf("user: サブコマンド（add）を指定してください")
+	}
+	fs := flag.NewFlagSet("user add", flag.ExitOnError)
+	loginID := fs.String("login", "", "ログインID")
+	name := fs.String("name", "", "表示名")
+	role := fs.String("role", "admin", "ロール（admin|staff|member|kiosk）")
+	password := fs.String("password", "", "パスワード（省略時は標準入力から1行読む）")
+	_ = fs.Parse(args[1:])
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	svc := users.NewService(conn, cfg.Auth)
+	u, err := svc.CreateUser(ctx, users.CreateUserRequest{
+		LoginID:     *loginID,
+		DisplayName: *name,
+		Password:    *password,
+		Role:        role,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user_id=%d login_id=%s role=%s\n", u.UserID, u.LoginID, u.Role)
+	return nil
+}
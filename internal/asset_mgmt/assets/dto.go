@@ -1,6 +1,10 @@
 package assets
 
-import "time"
+import (
+	"time"
+
+	"IRIS-backend/internal/platform/pagination"
+)
 
 // ===== Requests =====
 
@@ -10,6 +14,8 @@ type CreateAssetMasterRequest struct {
 	GenreID              uint    `json:"genre_id" binding:"required"`
 	Manufacturer         string  `json:"manufacturer" binding:"required"`
 	Model                *string `json:"model,omitempty"`
+	// ジャンルで定義した追加属性（attr_key → 値）。定義は GET /genres/:genre_id
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type UpdateAssetMasterRequest struct {
@@ -18,70 +24,111 @@ type UpdateAssetMasterRequest struct {
 	GenreID              *uint   `json:"genre_id,omitempty"`
 	Manufacturer         *string `json:"manufacturer,omitempty"`
 	Model                *string `json:"model,omitempty"`
+	// 指定したキーだけ変える（null で削除）。ジャンルを変えると新しいジャンルにない属性は落とす
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type CreateAssetRequest struct {
-	AssetMasterID   *uint64    `json:"asset_master_id,omitempty"`
-	Serial          *string    `json:"serial,omitempty"`
-	Quantity        uint       `json:"quantity"` // >=0, default 1 はDB側デフォルトでも可
-	PurchasedAt     time.Time  `json:"purchased_at" binding:"required"`
-	StatusID        uint       `json:"status_id" binding:"required"`
-	Owner           string     `json:"owner" binding:"required"`
-	DefaultLocation string     `json:"default_location" binding:"required"`
-	Location        *string    `json:"location,omitempty"`
-	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
-	LastCheckedBy   *string    `json:"last_checked_by,omitempty"`
-	Notes           *string    `json:"notes,omitempty"`
+	AssetMasterID     *uint64    `json:"asset_master_id,omitempty"`
+	Serial            *string    `json:"serial,omitempty"`
+	Quantity          uint       `json:"quantity"` // >=0, default 1 はDB側デフォルトでも可
+	PurchasedAt       time.Time  `json:"purchased_at" binding:"required"`
+	StatusID          uint       `json:"status_id" binding:"required"`
+	Owner             string     `json:"owner" binding:"required"`
+	DefaultLocationID uint       `json:"default_location_id" binding:"required"`
+	LocationID        *uint      `json:"location_id,omitempty"` // 既定の場所以外に置いているとき
+	LastCheckedAt     *time.Time `json:"last_checked_at,omitempty"`
+	LastCheckedBy     *string    `json:"last_checked_by,omitempty"`
+	Notes             *string    `json:"notes,omitempty"`
 }
 
 type UpdateAssetRequest struct {
-	Serial          *string    `json:"serial,omitempty"`
-	Quantity        *uint      `json:"quantity,omitempty"` // >=0
-	PurchasedAt     *time.Time `json:"purchased_at,omitempty"`
-	StatusID        *uint      `json:"status_id,omitempty"`
-	Owner           *string    `json:"owner,omitempty"`
-	DefaultLocation *string    `json:"default_location,omitempty"`
-	Location        *string    `json:"location,omitempty"`
-	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
-	LastCheckedBy   *string    `json:"last_checked_by,omitempty"`
-	Notes           *string    `json:"notes,omitempty"`
+	Serial            *string    `json:"serial,omitempty"`
+	Quantity          *uint      `json:"quantity,omitempty"` // >=0
+	PurchasedAt       *time.Time `json:"purchased_at,omitempty"`
+	StatusID          *uint      `json:"status_id,omitempty"`
+	Owner             *string    `json:"owner,omitempty"`
+	DefaultLocationID *uint      `json:"default_location_id,omitempty"`
+	LocationID        *uint      `json:"location_id,omitempty"` // 0 で既定の場所に戻す
+	LastCheckedAt     *time.Time `json:"last_checked_at,omitempty"`
+	LastCheckedBy     *string    `json:"last_checked_by,omitempty"`
+	Notes             *string    `json:"notes,omitempty"`
 }
 
 // ===== Responses =====
 
 type AssetMasterResponse struct {
-	AssetMasterID        uint64    `json:"asset_master_id"`
-	ManagementNumber     string    `json:"management_number"`
-	Name                 string    `json:"name"`
-	ManagementCategoryID uint      `json:"management_category_id"`
-	GenreID              uint      `json:"genre_id"`
-	Manufacturer         string    `json:"manufacturer"`
-	Model                *string   `json:"model,omitempty"`
-	CreatedAt            time.Time `json:"created_at"`
+	AssetMasterID        uint64         `json:"asset_master_id"`
+	ManagementNumber     string         `json:"management_number"`
+	Name                 string         `json:"name"`
+	ManagementCategoryID uint           `json:"management_category_id"`
+	GenreID              uint           `json:"genre_id"`
+	Manufacturer         string         `json:"manufacturer"`
+	Model                *string        `json:"model,omitempty"`
+	Attributes           map[string]any `json:"attributes,omitempty"`
+	CreatedAt            time.Time      `json:"created_at"`
+	Version              uint64         `json:"version"`         // ETag と同じ値
+	Score                *float64       `json:"score,omitempty"` // キーワード検索時の関連度
+}
+
+// GET /assets/masters/next-number（予約はしない）
+type NextNumberResponse struct {
+	ManagementNumber string `json:"management_number"`
+	Template         string `json:"template"`
 }
 
 type AssetResponse struct {
-	AssetID          uint64     `json:"asset_id"`
-	AssetMasterID    uint64     `json:"asset_master_id"`
-	ManagementNumber string     `json:"management_number"`
-	Serial           *string    `json:"serial,omitempty"`
-	Quantity         uint       `json:"quantity"`
-	PurchasedAt      time.Time  `json:"purchased_at"`
-	StatusID         uint       `json:"status_id"`
-	Owner            string     `json:"owner"`
-	DefaultLocation  string     `json:"default_location"`
-	Location         *string    `json:"location,omitempty"`
-	LastCheckedAt    *time.Time `json:"last_checked_at,omitempty"`
-	LastCheckedBy    *string    `json:"last_checked_by,omitempty"`
-	Notes            *string    `json:"notes,omitempty"`
+	AssetID             uint64     `json:"asset_id"`
+	AssetMasterID       uint64     `json:"asset_master_id"`
+	ManagementNumber    string     `json:"management_number"`
+	Serial              *string    `json:"serial,omitempty"`
+	Quantity            uint       `json:"quantity"`
+	PurchasedAt         time.Time  `json:"purchased_at"`
+	StatusID            uint       `json:"status_id"`
+	Owner               string     `json:"owner"`
+	DefaultLocationID   uint       `json:"default_location_id"`
+	DefaultLocationCode string     `json:"default_location_code"`
+	LocationID          *uint      `json:"location_id,omitempty"`
+	LocationCode        *string    `json:"location_code,omitempty"`
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty"`
+	LastCheckedBy       *string    `json:"last_checked_by,omitempty"`
+	Notes               *string    `json:"notes,omitempty"`
+	Version             uint64     `json:"version"` // ETag と同じ値
 }
 
 // ===== Listing helpers =====
 
 type Page struct {
-	Limit  int
-	Offset int
-	Order  string // "asc" or "desc"
+	pagination.Request
+	Order string // "asc" or "desc"
+}
+
+// GET /assets/masters の検索条件
+type MasterSearchQuery struct {
+	Keyword              *string // q: name / manufacturer / model / management_number を横断
+	GenreID              *uint
+	ManagementCategoryID *uint
+	CreatedFrom          *time.Time        // 以上
+	CreatedTo            *time.Time        // 未満
+	Attributes           []AttributeFilter // すべて満たすもの
+	Sort                 []SortKey         // 空なら q ありは relevance、なしは created_at（Page.Order）
+}
+
+// AttributeFilter: attr.<key>=v（一致）、attr.<key>.min=v / attr.<key>.max=v（以上・以下。number / date のみ）
+type AttributeFilter struct {
+	Key   string
+	Op    string // "eq" | "min" | "max"
+	Value string
+
+	// Service が属性の型から決める比較列と値
+	column string
+	arg    any
+}
+
+// SortKey: sort=-relevance,name のように複数指定（- で降順）
+type SortKey struct {
+	Field string
+	Desc  bool
 }
 
 type AssetSearchQuery struct {
@@ -89,8 +136,31 @@ type AssetSearchQuery struct {
 	AssetMasterID    *uint64
 	StatusID         *uint
 	Owner            *string
-	Location         *string
+	LocationID       *uint   // 配下の場所も含む
+	Location         *string // location_code で指定（配下の場所も含む）
 	PurchasedFrom    *time.Time
 	PurchasedTo      *time.Time
 	GenreID          *uint
 }
+
+// GET /assets/export の1行（在庫行 + マスタ・ジャンル・貸出中の数）
+type ExportRow struct {
+	AssetID          uint64     `json:"asset_id"`
+	ManagementNumber string     `json:"management_number"`
+	Name             string     `json:"name"`
+	Manufacturer     string     `json:"manufacturer"`
+	Model            *string    `json:"model,omitempty"`
+	GenreCode        string     `json:"genre_code"`
+	GenreName        string     `json:"genre_name"`
+	Serial           *string    `json:"serial,omitempty"`
+	Quantity         uint       `json:"quantity"`
+	LentQuantity     uint       `json:"lent_quantity"` // 貸出中（未返却）の数。quantity には含まれない
+	PurchasedAt      time.Time  `json:"purchased_at"`
+	StatusID         uint       `json:"status_id"`
+	StatusName       string     `json:"status_name"`
+	Owner            string     `json:"owner"`
+	DefaultLocation  string     `json:"default_location"`   // location_code
+	Location         *string    `json:"location,omitempty"` // location_code
+	LastCheckedAt    *time.Time `json:"last_checked_at,omitempty"`
+	Notes            *string    `json:"notes,omitempty"`
+}
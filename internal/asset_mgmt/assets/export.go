@@ -0,0 +1,294 @@
+package assets
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+const (
+	ExportCSV   = "csv"
+	ExportXLSX  = "xlsx"
+	ExportJSONL = "jsonl"
+)
+
+const contentTypeCSVShiftJIS = "text/csv; charset=Shift_JIS"
+
+var exportContentTypes = map[string]string{
+	ExportCSV:   "text/csv; charset=utf-8",
+	ExportXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportJSONL: "application/x-ndjson",
+}
+
+// 見出し（CSV / XLSX）。列の並びは exportCells と揃える
+var exportHeader = []string{
+	"asset_id", "management_number", "name", "manufacturer", "model", "genre_code", "genre_name",
+	"serial", "quantity", "lent_quantity", "purchased_at", "status_id", "status_name",
+	"owner", "default_location", "location", "last_checked_at", "notes",
+}
+
+// exportCell: XLSX で数値セルにするかどうかだけ区別する
+type exportCell struct {
+	v   string
+	num bool
+}
+
+func exportCells(r ExportRow) []exportCell {
+	s := func(v string) exportCell { return exportCell{v: v} }
+	p := func(v *string) exportCell {
+		if v == nil {
+			return exportCell{}
+		}
+		return exportCell{v: *v}
+	}
+	n := func(v uint64) exportCell { return exportCell{v: strconv.FormatUint(v, 10), num: true} }
+	lct := exportCell{}
+	if r.LastCheckedAt != nil {
+		lct.v = r.LastCheckedAt.Format(time.RFC3339)
+	}
+	return []exportCell{
+		n(r.AssetID), s(r.ManagementNumber), s(r.Name), s(r.Manufacturer), p(r.Model), s(r.GenreCode), s(r.GenreName),
+		p(r.Serial), n(uint64(r.Quantity)), n(uint64(r.LentQuantity)), s(r.PurchasedAt.Format("2006-01-02")),
+		n(uint64(r.StatusID)), s(r.StatusName),
+		s(r.Owner), s(r.DefaultLocation), p(r.Location), lct, p(r.Notes),
+	}
+}
+
+// exportWriter: 1行ずつ書き、Close で末尾（XLSX の閉じタグ等）を書いてフラッシュする
+type exportWriter interface {
+	Write(ExportRow) error
+	Close() error
+}
+
+// exportWriterFor: format / charset（CSV のみ。utf-8 は Excel 向けに BOM 付き）を検証して
+// writer の生成関数と Content-Type を返す
+func exportWriterFor(format, charset string) (func(io.Writer) exportWriter, string, error) {
+	switch format {
+	case ExportCSV:
+		switch charset {
+		case "utf-8", "utf8":
+			return func(w io.Writer) exportWriter { return newCSVExporter(w, false) }, exportContentTypes[format], nil
+		case "cp932", "shift_jis", "sjis":
+			return func(w io.Writer) exportWriter { return newCSVExporter(w, true) }, contentTypeCSVShiftJIS, nil
+		}
+		return nil, "", apperr.Invalid("unsupported encoding").WithField("encoding", "must be utf-8 or cp932")
+	case ExportXLSX:
+		return func(w io.Writer) exportWriter { return newXLSXExporter(w) }, exportContentTypes[format], nil
+	case ExportJSONL:
+		return func(w io.Writer) exportWriter { return newJSONLExporter(w) }, exportContentTypes[format], nil
+	}
+	return nil, "", apperr.Invalid("unsupported format").WithField("format", "must be csv, xlsx or jsonl")
+}
+
+// ===== CSV =====
+
+type csvExporter struct {
+	w      *csv.Writer
+	closer io.Closer
+	err    error
+	header bool
+}
+
+func newCSVExporter(w io.Writer, cp932 bool) *csvExporter {
+	e := &csvExporter{}
+	if cp932 {
+		// Shift_JIS にない文字（絵文字・一部の異体字など）で書き出しを止めず、代替文字（0x1A）に置き換える
+		tw := transform.NewWriter(w, encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder()))
+		w, e.closer = tw, tw
+	} else {
+		_, e.err = w.Write([]byte("\xEF\xBB\xBF"))
+	}
+	e.w = csv.NewWriter(w)
+	return e
+}
+
+func (e *csvExporter) writeHeader() error {
+	if !e.header {
+		e.header = true
+		return e.w.Write(exportHeader)
+	}
+	return nil
+}
+
+func (e *csvExporter) Write(r ExportRow) error {
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	cells := exportCells(r)
+	rec := make([]string, len(cells))
+	for i, c := range cells {
+		rec[i] = csvText(c)
+	}
+	return e.w.Write(rec)
+}
+
+// csvText: Excel で式として評価される先頭文字（= + - @ タブ CR）で始まる文字列セルは ' を前置して文字列のままにする。
+// 名前・備考などは利用者の入力なので、=HYPERLINK(...) などをそのまま出すと開いた人の手元で式が動く
+func csvText(c exportCell) string {
+	if c.num || c.v == "" {
+		return c.v
+	}
+	switch c.v[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + c.v
+	}
+	return c.v
+}
+
+func (e *csvExporter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
+
+// ===== JSON Lines =====
+
+type jsonlExporter struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLExporter(w io.Writer) *jsonlExporter {
+	bw := bufio.NewWriter(w)
+	return &jsonlExporter{bw: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *jsonlExporter) Write(r ExportRow) error { return e.enc.Encode(r) } // Encode が改行を付ける
+func (e *jsonlExporter) Close() error            { return e.bw.Flush() }
+
+// ===== XLSX =====
+// 共有文字列を使わずインライン文字列で1シートを書く。zip は順に書き出せるので、
+// 固定のパーツを先に書いてから sheet1.xml を行ごとに流す
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="assets" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+type xlsxExporter struct {
+	zw   *zip.Writer
+	bw   *bufio.Writer
+	row  int
+	err  error
+	open bool
+}
+
+func newXLSXExporter(w io.Writer) *xlsxExporter {
+	return &xlsxExporter{zw: zip.NewWriter(w)}
+}
+
+// begin: 固定パーツと sheet1.xml の先頭・見出し行を書く
+func (e *xlsxExporter) begin() error {
+	if e.open || e.err != nil {
+		return e.err
+	}
+	e.open = true
+	for _, p := range xlsxParts {
+		f, err := e.zw.Create(p.name)
+		if err != nil {
+			e.err = err
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			e.err = err
+			return err
+		}
+	}
+	f, err := e.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		e.err = err
+		return err
+	}
+	e.bw = bufio.NewWriter(f)
+	e.bw.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]exportCell, len(exportHeader))
+	for i, h := range exportHeader {
+		header[i] = exportCell{v: h}
+	}
+	return e.writeRow(header)
+}
+
+func (e *xlsxExporter) writeRow(cells []exportCell) error {
+	e.row++
+	rn := strconv.Itoa(e.row)
+	e.bw.WriteString(`<row r="` + rn + `">`)
+	for i, c := range cells {
+		if c.v == "" {
+			continue
+		}
+		ref := xlsxColumn(i) + rn
+		if c.num {
+			e.bw.WriteString(`<c r="` + ref + `"><v>` + c.v + `</v></c>`)
+			continue
+		}
+		e.bw.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		_ = xml.EscapeText(e.bw, []byte(c.v)) // bufio への書き込みエラーは Flush で拾う
+		e.bw.WriteString(`</t></is></c>`)
+	}
+	_, err := e.bw.WriteString(`</row>`)
+	return err
+}
+
+func (e *xlsxExporter) Write(r ExportRow) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	return e.writeRow(exportCells(r))
+}
+
+func (e *xlsxExporter) Close() error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.bw.WriteString(`</sheetData></worksheet>`)
+	if err := e.bw.Flush(); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+// xlsxColumn: 0 → "A", 26 → "AA"
+func xlsxColumn(i int) string {
+	s := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
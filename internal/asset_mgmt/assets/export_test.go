@@ -0,0 +1,91 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		name string
+		cell exportCell
+		want string
+	}{
+		{name: "plain", cell: exportCell{v: "ノートPC"}, want: "ノートPC"},
+		{name: "empty", cell: exportCell{}, want: ""},
+		{name: "equals", cell: exportCell{v: `=HYPERLINK("http://evil","x")`}, want: `'=HYPERLINK("http://evil","x")`},
+		{name: "plus", cell: exportCell{v: "+1+1"}, want: "'+1+1"},
+		{name: "minus", cell: exportCell{v: "-2+3"}, want: "'-2+3"},
+		{name: "at", cell: exportCell{v: "@cmd"}, want: "'@cmd"},
+		{name: "tab", cell: exportCell{v: "\t=1"}, want: "'\t=1"},
+		{name: "carriage return", cell: exportCell{v: "\r=1"}, want: "'\r=1"},
+		{name: "formula char later", cell: exportCell{v: "A=B"}, want: "A=B"},
+		{name: "numeric cell is untouched", cell: exportCell{v: "-1", num: true}, want: "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvText(tt.cell); got != tt.want {
+				t.Errorf("csvText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVExporterNeutralisesFormulas(t *testing.T) {
+	model := "@SUM(A1:A9)"
+	notes := "-1+2"
+	row := ExportRow{
+		AssetID:          12,
+		ManagementNumber: "PC-20250101-00001",
+		Name:             "=HYPERLINK(\"http://evil\",\"x\")",
+		Manufacturer:     "+cmd",
+		Model:            &model,
+		GenreCode:        "PC",
+		GenreName:        "パソコン",
+		Quantity:         3,
+		PurchasedAt:      time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		StatusID:         1,
+		StatusName:       "利用可能",
+		Owner:            "総務",
+		DefaultLocation:  "L00001",
+		Notes:            &notes,
+	}
+
+	var buf bytes.Buffer
+	e := newCSVExporter(&buf, false)
+	if err := e.Write(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("records = %d, want 2", len(recs))
+	}
+	got := map[string]string{}
+	for i, h := range recs[0] {
+		got[h] = recs[1][i]
+	}
+	want := map[string]string{
+		"asset_id":     "12",
+		"name":         "'=HYPERLINK(\"http://evil\",\"x\")",
+		"manufacturer": "'+cmd",
+		"model":        "'@SUM(A1:A9)",
+		"notes":        "'-1+2",
+		"quantity":     "3",
+		"genre_name":   "パソコン",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
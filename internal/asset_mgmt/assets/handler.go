@@ -3,11 +3,16 @@ package assets
 import (
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type Handler struct{ svc *Service }
@@ -16,16 +21,18 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 
 	// masters
-	r.POST("/assets/masters", h.CreateAssetMaster)
-	r.GET("/assets/masters", h.ListAssetMasters)
-	r.GET("/assets/masters/:management_number", h.GetAssetMaster)
-	r.PUT("/assets/masters/:management_number", h.UpdateAssetMaster)
+	r.POST("/assets/masters", auth.Allow(auth.StaffOnly...), h.CreateAssetMaster)
+	r.GET("/assets/masters", auth.Allow(auth.Members...), h.ListAssetMasters)
+	r.GET("/assets/masters/next-number", auth.Allow(auth.StaffOnly...), h.PreviewManagementNumber)
+	r.GET("/assets/masters/:management_number", auth.Allow(auth.Members...), h.GetAssetMaster)
+	r.PUT("/assets/masters/:management_number", auth.Allow(auth.AdminOnly...), h.UpdateAssetMaster)
 
 	// assets
-	r.POST("/assets", h.CreateAsset)
-	r.GET("/assets", h.ListAssets)
-	r.GET("/assets/:asset_id", h.GetAsset)
-	r.PUT("/assets/:asset_id", h.UpdateAsset)
+	r.POST("/assets", auth.Allow(auth.StaffOnly...), h.CreateAsset)
+	r.GET("/assets", auth.Allow(auth.Members...), h.ListAssets)
+	r.GET("/assets/export", auth.Allow(auth.StaffOnly...), h.ExportAssets)
+	r.GET("/assets/:asset_id", auth.Allow(auth.Members...), h.GetAsset)
+	r.PUT("/assets/:asset_id", auth.Allow(auth.StaffOnly...), h.UpdateAsset)
 }
 
 // ===== masters =====
@@ -33,14 +40,15 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 func (h *Handler) CreateAssetMaster(c *gin.Context) {
 	var req CreateAssetMasterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 	res, err := h.svc.CreateAssetMaster(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
 		return
 	}
+	c.Header("ETag", etag(res.Version))
 	c.Header("Location", "/assets/masters/"+res.ManagementNumber)
 	c.JSON(http.StatusCreated, res)
 }
@@ -49,46 +57,110 @@ func (h *Handler) GetAssetMaster(c *gin.Context) {
 	mng := c.Param("management_number")
 	res, err := h.svc.GetAssetMaster(c.Request.Context(), mng)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
+		return
+	}
+	writeWithETag(c, res.Version, res)
+}
+
+// GET /assets/masters/next-number?genre_id=1&management_category_id=2
+func (h *Handler) PreviewManagementNumber(c *gin.Context) {
+	var ids [2]uint
+	for i, key := range []string{"genre_id", "management_category_id"} {
+		v := c.Query(key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid(key+" must be a number"))
+			return
+		}
+		ids[i] = uint(n)
+	}
+	res, err := h.svc.PreviewManagementNumber(c.Request.Context(), ids[0], ids[1])
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
 
+// GET /assets/masters?q=thinkpad&genre_id=1&management_category_id=2&created_from=2025-04-01&created_to=2026-04-01&sort=-relevance,name
+// 属性: attr.os=Windows 11&attr.ram_gb.min=16（型はジャンルの定義から決まる）
 func (h *Handler) ListAssetMasters(c *gin.Context) {
-	var q AssetSearchQuery
-	if v := c.Query("genre"); v != "" {
-		id, err := strconv.Atoi(v)
-		if err == nil {
+	var q MasterSearchQuery
+	if v := c.Query("q"); v != "" {
+		q.Keyword = &v
+	}
+	// genre は旧パラメータ名（互換のため残す）
+	for _, key := range []string{"genre", "genre_id"} {
+		if v := c.Query(key); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				apperr.Abort(c, apperr.Invalid(key+" must be a number"))
+				return
+			}
 			u := uint(id)
 			q.GenreID = &u
 		}
 	}
-	p := Page{
-		Limit:  atoiDef(c.Query("limit"), 50),
-		Offset: atoiDef(c.Query("offset"), 0),
-		Order:  strings.ToLower(c.DefaultQuery("order", "desc")),
+	if v := c.Query("management_category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("management_category_id must be a number"))
+			return
+		}
+		u := uint(id)
+		q.ManagementCategoryID = &u
+	}
+	var err error
+	if q.CreatedFrom, err = parseDateOrTime(c.Query("created_from")); err != nil {
+		apperr.Abort(c, apperr.Invalid("created_from must be YYYY-MM-DD or RFC3339"))
+		return
 	}
-	items, total, err := h.svc.ListAssetMasters(c.Request.Context(), p, q)
+	if q.CreatedTo, err = parseDateOrTime(c.Query("created_to")); err != nil {
+		apperr.Abort(c, apperr.Invalid("created_to must be YYYY-MM-DD or RFC3339"))
+		return
+	}
+	q.Sort = parseSort(c.Query("sort"))
+	if q.Attributes, err = parseAttributeFilters(c); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	req, err := pagination.FromQuery(c)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"items": items, "total": total, "next_offset": nextOffset(total, p)})
+	p := Page{Request: req, Order: strings.ToLower(c.DefaultQuery("order", "desc"))}
+	res, err := h.svc.ListAssetMasters(c.Request.Context(), p, q)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
 }
 
 func (h *Handler) UpdateAssetMaster(c *gin.Context) {
 	mng := c.Param("management_number")
+	ver, err := ifMatchVersion(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
 	var req UpdateAssetMasterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
-	res, err := h.svc.UpdateAssetMaster(c.Request.Context(), mng, req)
+	res, err := h.svc.UpdateAssetMaster(c.Request.Context(), mng, ver, req)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
 		return
 	}
+	c.Header("ETag", etag(res.Version))
 	c.JSON(http.StatusOK, res)
 }
 
@@ -98,14 +170,15 @@ func (h *Handler) CreateAsset(c *gin.Context) {
 	var req CreateAssetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("CreateAsset: bind error: %v", err)
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 	res, err := h.svc.CreateAsset(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
 		return
 	}
+	c.Header("ETag", etag(res.Version))
 	c.Header("Location", "/assets/"+strconv.FormatUint(res.AssetID, 10))
 	c.JSON(http.StatusCreated, res)
 }
@@ -113,23 +186,112 @@ func (h *Handler) CreateAsset(c *gin.Context) {
 func (h *Handler) GetAsset(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("asset_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "asset_id must be a number"))
+		apperr.Abort(c, apperr.Invalid("asset_id must be a number"))
 		return
 	}
 	res, err := h.svc.GetAsset(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		apperr.Abort(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	writeWithETag(c, res.Version, res)
 }
 
 func (h *Handler) ListAssets(c *gin.Context) {
+	q := assetSearchQuery(c)
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	p := Page{Request: req, Order: strings.ToLower(c.DefaultQuery("order", "desc"))}
+	res, err := h.svc.ListAssets(c.Request.Context(), q, p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /assets/export?format=csv|xlsx|jsonl（絞り込みは GET /assets と同じ）。
+// 行は DB から読んだ順に書き出すので件数によらずメモリを使わない
+func (h *Handler) ExportAssets(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", ExportCSV))
+	enc := strings.ToLower(c.DefaultQuery("encoding", "utf-8"))
+	newWriter, contentType, err := exportWriterFor(format, enc)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	q := assetSearchQuery(c)
+
+	// 最初の行（0件なら最後）で初めてヘッダを送る。クエリの失敗は通常のエラーで返せる
+	var w exportWriter
+	start := func() {
+		name := "assets-" + time.Now().Format("20060102-150405") + "." + format
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+		c.Status(http.StatusOK)
+		w = newWriter(c.Writer)
+	}
+	err = h.svc.ExportAssets(c.Request.Context(), q, func(r ExportRow) error {
+		if w == nil {
+			start()
+		}
+		return w.Write(r)
+	})
+	if err != nil {
+		if w == nil {
+			apperr.Abort(c, err)
+			return
+		}
+		// 送信途中の失敗はステータスを変えられないので、途中で切って記録だけ残す
+		log.Printf("ExportAssets: aborted after partial write: %v", err)
+		c.Abort()
+		return
+	}
+	if w == nil {
+		start()
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("ExportAssets: close: %v", err)
+	}
+}
+
+func (h *Handler) UpdateAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("asset_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("invalid asset_id"))
+		return
+	}
+	ver, err := ifMatchVersion(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	var req UpdateAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.UpdateAsset(c.Request.Context(), id, ver, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("ETag", etag(res.Version))
+	c.JSON(http.StatusOK, res)
+}
+
+// ===== helpers =====
+
+// assetSearchQuery: 在庫行一覧・エクスポート共通の絞り込み（数値・日付の誤りは無視）
+func assetSearchQuery(c *gin.Context) AssetSearchQuery {
 	var q AssetSearchQuery
 	if v := c.Query("management_number"); v != "" {
 		q.ManagementNumber = &v
 	}
-	if v:= c.Query("asmi"); v != "" {
+	if v := c.Query("asmi"); v != "" {
 		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
 			q.AssetMasterID = &n
 		}
@@ -140,9 +302,21 @@ func (h *Handler) ListAssets(c *gin.Context) {
 			q.StatusID = &u
 		}
 	}
+	if v := c.Query("genre_id"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			u := uint(n)
+			q.GenreID = &u
+		}
+	}
 	if v := c.Query("owner"); v != "" {
 		q.Owner = &v
 	}
+	if v := c.Query("location_id"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			u := uint(n)
+			q.LocationID = &u
+		}
+	}
 	if v := c.Query("location"); v != "" {
 		q.Location = &v
 	}
@@ -156,75 +330,97 @@ func (h *Handler) ListAssets(c *gin.Context) {
 			q.PurchasedTo = &t
 		}
 	}
-
-	p := Page{
-		Limit:  atoiDef(c.Query("limit"), 50),
-		Offset: atoiDef(c.Query("offset"), 0),
-		Order:  strings.ToLower(c.DefaultQuery("order", "desc")),
-	}
-	items, total, err := h.svc.ListAssets(c.Request.Context(), q, p)
-	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"items": items, "total": total, "next_offset": nextOffset(total, p)})
+	return q
 }
 
-func (h *Handler) UpdateAsset(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("asset_id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid asset_id"))
-		return
+// parseDateOrTime: 空なら nil。日付のみ（YYYY-MM-DD）はその日の 0:00 UTC
+func parseDateOrTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
 	}
-	var req UpdateAssetRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
-		return
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return &t, nil
 	}
-	res, err := h.svc.UpdateAsset(c.Request.Context(), id, req)
+	t, err := time.Parse(time.RFC3339, v)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), apiErrFrom(err))
-		return
+		return nil, err
 	}
-	c.JSON(http.StatusOK, res)
+	return &t, nil
 }
 
-// ===== helpers =====
-
-func atoiDef(s string, d int) int {
-	if s == "" {
-		return d
-	}
-	n, err := strconv.Atoi(s)
-	if err != nil {
-		return d
+// parseAttributeFilters: attr.<key>=v / attr.<key>.min=v / attr.<key>.max=v（キーの順に並べる）
+func parseAttributeFilters(c *gin.Context) ([]AttributeFilter, error) {
+	var out []AttributeFilter
+	for name, vals := range c.Request.URL.Query() {
+		rest, ok := strings.CutPrefix(name, "attr.")
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		f := AttributeFilter{Key: rest, Op: "eq", Value: vals[len(vals)-1]}
+		for _, op := range []string{"min", "max"} {
+			if k, ok := strings.CutSuffix(rest, "."+op); ok {
+				f.Key, f.Op = k, op
+			}
+		}
+		if f.Key == "" || strings.Contains(f.Key, ".") {
+			return nil, apperr.Invalid("validation failed").WithField(name, "must be attr.<key>, attr.<key>.min or attr.<key>.max")
+		}
+		out = append(out, f)
 	}
-	return n
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Key != out[j].Key {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].Op < out[j].Op
+	})
+	return out, nil
 }
-func nextOffset(total int64, p Page) int {
-	n := p.Offset + p.Limit
-	if n >= int(total) {
-		return 0
+
+// parseSort: "-relevance,name" → [{relevance desc} {name asc}]（列名の検証は Service）
+func parseSort(v string) []SortKey {
+	var keys []SortKey
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		k := SortKey{Field: strings.TrimPrefix(f, "-"), Desc: strings.HasPrefix(f, "-")}
+		keys = append(keys, k)
 	}
-	return n
+	return keys
 }
 
-type errDTO struct {
-	Error struct {
-		Code    Code   `json:"code"`
-		Message string `json:"message"`
-	} `json:"error"`
+// ===== ETag / If-Match =====
+
+// etag: 行バージョンをそのまま強い ETag にする（例: "3"）
+func etag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
 }
 
-func apiErr(code Code, msg string) errDTO {
-	var e errDTO
-	e.Error.Code = code
-	e.Error.Message = msg
-	return e
+// writeWithETag: ETag を付けて返す。If-None-Match が一致すれば 304
+func writeWithETag(c *gin.Context, version uint64, body any) {
+	tag := etag(version)
+	c.Header("ETag", tag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && strings.TrimPrefix(inm, "W/") == tag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
 }
-func apiErrFrom(err error) errDTO {
-	if api, ok := err.(*APIError); ok {
-		return apiErr(api.Code, api.Message)
+
+// ifMatchVersion: PUT では If-Match 必須（無ければ 428）。"*" は上書き防止にならないので受け付けない
+func ifMatchVersion(c *gin.Context) (uint64, error) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	if v == "" {
+		return 0, apperr.PreconditionRequired("If-Match header is required; use the ETag from GET")
+	}
+	v = strings.TrimPrefix(v, "W/")
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, apperr.Invalid(`If-Match must be a quoted ETag such as "3"`)
+	}
+	n, err := strconv.ParseUint(v[1:len(v)-1], 10, 64)
+	if err != nil {
+		return 0, apperr.Invalid(`If-Match must be a quoted ETag such as "3"`)
 	}
-	return apiErr(CodeInternal, err.Error())
+	return n, nil
 }
@@ -4,144 +4,367 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"log"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	mysql "github.com/go-sql-driver/mysql"
-	ulid "github.com/oklog/ulid/v2"
-)
-
-// ===== Error model (disposals/lends と同型) =====
-type Code string
 
-const (
-	CodeInvalidArgument Code = "INVALID_ARGUMENT"
-	CodeNotFound        Code = "NOT_FOUND"
-	CodeConflict        Code = "CONFLICT"
-	CodeInternal        Code = "INTERNAL"
+	"IRIS-backend/internal/asset_mgmt/genres"
+	"IRIS-backend/internal/asset_mgmt/numbering"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
 )
 
-type APIError struct {
-	Code    Code
-	Message string
-}
-
-func (e *APIError) Error() string      { return fmt.Sprintf("%s: %s", e.Code, e.Message) }
-func ErrInvalid(msg string) *APIError  { return &APIError{Code: CodeInvalidArgument, Message: msg} }
-func ErrNotFound(msg string) *APIError { return &APIError{Code: CodeNotFound, Message: msg} }
-func ErrConflict(msg string) *APIError { return &APIError{Code: CodeConflict, Message: msg} }
-func ErrInternal(msg string) *APIError { return &APIError{Code: CodeInternal, Message: msg} }
-
-func toHTTPStatus(err error) int {
-	var api *APIError
-	if errors.As(err, &api) {
-		switch api.Code {
-		case CodeInvalidArgument:
-			return 400
-		case CodeNotFound:
-			return 404
-		case CodeConflict:
-			return 409
-		default:
-			return 500
-		}
-	}
-	return 500
+type Service struct {
+	db        *sql.DB
+	store     *Store
+	numbering *numbering.Scheme
 }
 
-type Service struct {
-	db    *sql.DB
-	store *Store
+func NewService(db *sql.DB, num *numbering.Scheme) *Service {
+	return &Service{db: db, store: NewStore(db), numbering: num}
 }
 
-func NewService(db *sql.DB) *Service { return &Service{db: db, store: NewStore(db)} }
+// 旧形式で発番済みの番号と衝突したときに連番を進めて取り直す回数
+const maxNumberingRetries = 5
 
 // ===== Master =====
 
 func (s *Service) CreateAssetMaster(ctx context.Context, in CreateAssetMasterRequest) (AssetMasterResponse, error) {
+	var out AssetMasterResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		var err error
+		out, err = s.CreateAssetMasterTx(ctx, tx, in)
+		return err
+	})
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	return out, nil
+}
+
+// CreateAssetMasterTx: 呼び出し側の Tx で採番・登録・監査まで行う（一括取込で複数行を1Txにまとめる用）
+func (s *Service) CreateAssetMasterTx(ctx context.Context, tx db.DBTX, in CreateAssetMasterRequest) (AssetMasterResponse, error) {
 	// 軽バリデーション
 	if strings.TrimSpace(in.Name) == "" || strings.TrimSpace(in.Manufacturer) == "" ||
 		in.ManagementCategoryID == 0 || in.GenreID == 0 {
-		return AssetMasterResponse{}, ErrInvalid("name, manufacturer, management_category_id, genre_id are required")
+		return AssetMasterResponse{}, apperr.Invalid("name, manufacturer, management_category_id, genre_id are required")
 	}
+	st := NewStore(tx)
 
-	// 仮管理番号（UNIQUEを満たす）
-	tmpMng := "TMP-" + ulid.Make().String()
-
-	// 1) 仮INSERT → PK取得
-	id, err := s.store.InsertMasterTmp(ctx, in, tmpMng)
+	// 1) 採番に使うコードを解決し、属性をジャンルの定義で検証
+	num, err := s.numberInput(ctx, st, in.GenreID, in.ManagementCategoryID)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	defs, err := genres.NewStore(tx).Attributes(ctx, in.GenreID)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	attrs, err := genres.ValidateValues(defs, in.Attributes)
 	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+
+	// 2) 採番 → INSERT（連番の行ロックはこの Tx のコミットまで保持）
+	var id uint64
+	for attempt := 0; ; attempt++ {
+		mng, err := s.numbering.Next(ctx, tx, num)
+		if err != nil {
+			return AssetMasterResponse{}, err
+		}
+		id, err = st.InsertMaster(ctx, in, mng)
+		if err == nil {
+			break
+		}
 		var me *mysql.MySQLError
 		if errors.As(err, &me) {
 			switch me.Number {
 			case 1062: // duplicate key
-				return AssetMasterResponse{}, ErrConflict("management_number already exists")
+				if attempt < maxNumberingRetries {
+					continue
+				}
+				return AssetMasterResponse{}, apperr.Conflict("management_number already exists")
 			case 1452: // foreign key constraint fails
-				return AssetMasterResponse{}, ErrInvalid("invalid management_category_id or genre_id")
+				return AssetMasterResponse{}, apperr.Invalid("invalid management_category_id or genre_id")
 			}
 		}
 		return AssetMasterResponse{}, err
 	}
 
-	// 2) 確定管理番号に置換（DBの created_at と genres.genre_code を使用）
-	if err := s.store.UpdateMngToFinal(ctx, id, tmpMng, 5 /*パディング桁*/); err != nil {
-		// if errors.Is(err, ErrConflict) {
-		// 	return AssetMasterResponse{}, ErrConflict("conflict while finalizing management_number")
-		// }
-		var ae *APIError
-		if errors.As(err, &ae) && ae.Code == CodeConflict {
-			return AssetMasterResponse{}, ErrConflict("conflict while finalizing management_number")
-		}
-
+	if err := st.ReplaceMasterAttributes(ctx, id, attrs); err != nil {
 		return AssetMasterResponse{}, err
 	}
 
 	// 3) IDで取得して返却
-	out, err := s.store.GetMasterByID(ctx, id)
+	m, err := st.GetMasterByID(ctx, id)
 	if err != nil {
 		return AssetMasterResponse{}, err
 	}
-	return *out, nil
+
+	return *m, audit.Record(ctx, tx, audit.Entry{
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityAssetMaster,
+		EntityID:   m.ManagementNumber,
+		After:      *m,
+	})
+}
+
+// PreviewManagementNumber: 次に振られる管理番号（予約はしないので、登録時には変わりうる）
+func (s *Service) PreviewManagementNumber(ctx context.Context, genreID, categoryID uint) (NextNumberResponse, error) {
+	num, err := s.numberInput(ctx, s.store, genreID, categoryID)
+	if err != nil {
+		return NextNumberResponse{}, err
+	}
+	mng, err := s.numbering.Preview(ctx, s.db, num)
+	if err != nil {
+		return NextNumberResponse{}, err
+	}
+	return NextNumberResponse{ManagementNumber: mng, Template: s.numbering.Template()}, nil
+}
+
+// numberInput: テンプレートが使うコードだけを引く
+func (s *Service) numberInput(ctx context.Context, st *Store, genreID, categoryID uint) (numbering.Input, error) {
+	in := numbering.Input{At: time.Now()}
+	useGenre, useCategory := s.numbering.Uses()
+	if useGenre {
+		if genreID == 0 {
+			return in, apperr.Invalid("genre_id is required")
+		}
+		code, err := st.GenreCode(ctx, genreID)
+		if err == sql.ErrNoRows {
+			return in, apperr.Invalid("invalid genre_id")
+		}
+		if err != nil {
+			return in, err
+		}
+		in.GenreCode = code
+	}
+	if useCategory {
+		if categoryID == 0 {
+			return in, apperr.Invalid("management_category_id is required")
+		}
+		code, err := st.CategoryCode(ctx, categoryID)
+		if err == sql.ErrNoRows {
+			return in, apperr.Invalid("invalid management_category_id")
+		}
+		if err != nil {
+			return in, err
+		}
+		in.CategoryCode = code
+	}
+	return in, nil
 }
 
 func (s *Service) GetAssetMaster(ctx context.Context, managementNumber string) (AssetMasterResponse, error) {
 	out, err := s.store.GetMasterByMng(ctx, managementNumber)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return AssetMasterResponse{}, ErrNotFound("master not found")
+			return AssetMasterResponse{}, apperr.NotFound("master not found")
 		}
 		return AssetMasterResponse{}, err
 	}
 	return *out, nil
 }
 
-func (s *Service) ListAssetMasters(ctx context.Context, p Page, q AssetSearchQuery) ([]AssetMasterResponse, int64, error) {
-	items, total, err := s.store.ListMasters(ctx, p, q)
+func (s *Service) ListAssetMasters(ctx context.Context, p Page, q MasterSearchQuery) (pagination.Page[AssetMasterResponse], error) {
+	if q.Keyword != nil {
+		if kw := strings.TrimSpace(*q.Keyword); kw == "" {
+			q.Keyword = nil
+		} else {
+			q.Keyword = &kw
+		}
+	}
+	for _, k := range q.Sort {
+		if _, ok := masterSortColumns[k.Field]; !ok {
+			return pagination.Page[AssetMasterResponse]{}, apperr.Invalid("unknown sort key").WithField("sort", "unknown key '"+k.Field+"'")
+		}
+		if k.Field == "relevance" && q.Keyword == nil {
+			return pagination.Page[AssetMasterResponse]{}, apperr.Invalid("sort by relevance requires q").WithField("sort", "relevance requires q")
+		}
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return pagination.Page[AssetMasterResponse]{}, apperr.Invalid("created_from must be before created_to")
+	}
+	if err := s.resolveAttributeFilters(ctx, &q); err != nil {
+		return pagination.Page[AssetMasterResponse]{}, err
+	}
+	rows, offset, err := s.store.ListMasters(ctx, p, q)
 	if err != nil {
-		return nil, 0, err
+		return pagination.Page[AssetMasterResponse]{}, err
 	}
-	return items, total, nil
+	keys := masterSortKeys(p, q)
+	return pagination.Build(p.Request, rows, func(last AssetMasterResponse) string {
+		return masterCursor(keys, offset+p.Request.Limit, last)
+	}), nil
 }
 
-func (s *Service) UpdateAssetMaster(ctx context.Context, managementNumber string, in UpdateAssetMasterRequest) (AssetMasterResponse, error) {
-	out, err := s.store.UpdateMasterByMng(ctx, managementNumber, in)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return AssetMasterResponse{}, ErrNotFound("master not found")
+// UpdateAssetMaster: ifMatch は If-Match で受け取ったバージョン。現行と違えば 412
+func (s *Service) UpdateAssetMaster(ctx context.Context, managementNumber string, ifMatch uint64, in UpdateAssetMasterRequest) (AssetMasterResponse, error) {
+	var out AssetMasterResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.LockMasterByMng(ctx, managementNumber)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("master not found")
+			}
+			return err
+		}
+		if before.Version != ifMatch {
+			return errStale(before.Version)
+		}
+		touched, err := s.updateMasterAttributes(ctx, st, *before, in)
+		if err != nil {
+			return err
+		}
+		after, err := st.UpdateMasterByMng(ctx, managementNumber, in, touched)
+		if err != nil {
+			var me *mysql.MySQLError
+			if errors.As(err, &me) && me.Number == 1452 {
+				return apperr.Invalid("invalid management_category_id or genre_id")
+			}
+			return err
 		}
+		out = *after
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityAssetMaster,
+			EntityID:   managementNumber,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
 		return AssetMasterResponse{}, err
 	}
-	return *out, nil
+	return out, nil
+}
+
+// updateMasterAttributes: 今の値に指定分を重ね（null は削除）、更新後のジャンルの定義で検証して置き換える。
+// ジャンルを変えたときは新しいジャンルにない属性を落とす。値が変わったら true
+func (s *Service) updateMasterAttributes(ctx context.Context, st *Store, before AssetMasterResponse, in UpdateAssetMasterRequest) (bool, error) {
+	genreID := before.GenreID
+	if in.GenreID != nil && *in.GenreID != genreID {
+		if _, err := st.GenreCode(ctx, *in.GenreID); err == sql.ErrNoRows {
+			return false, apperr.Invalid("invalid management_category_id or genre_id")
+		} else if err != nil {
+			return false, err
+		}
+		genreID = *in.GenreID
+	} else if in.Attributes == nil {
+		return false, nil
+	}
+
+	defs, err := genres.NewStore(st.db).Attributes(ctx, genreID)
+	if err != nil {
+		return false, err
+	}
+	merged := make(map[string]any, len(before.Attributes)+len(in.Attributes))
+	for _, a := range defs {
+		if v, ok := before.Attributes[a.Key]; ok {
+			merged[a.Key] = v
+		}
+	}
+	for k, v := range in.Attributes {
+		merged[k] = v
+	}
+	vals, err := genres.ValidateValues(defs, merged)
+	if err != nil {
+		return false, err
+	}
+
+	types := make(map[string]string, len(defs))
+	for _, a := range defs {
+		types[a.Key] = a.Type
+	}
+	next := make(map[string]any, len(vals))
+	for k, v := range vals {
+		next[k] = v.JSON(types[k])
+	}
+	if len(next) == len(before.Attributes) && (len(next) == 0 || reflect.DeepEqual(next, before.Attributes)) {
+		return false, nil
+	}
+	return true, st.ReplaceMasterAttributes(ctx, before.AssetMasterID, vals)
+}
+
+// resolveAttributeFilters: 絞り込みの属性を定義から型付けする。
+// genre_id を指定しないときは、同じキーの型がジャンル間で揃っている必要がある
+func (s *Service) resolveAttributeFilters(ctx context.Context, q *MasterSearchQuery) error {
+	var genreID uint
+	if q.GenreID != nil {
+		genreID = *q.GenreID
+	}
+	for i := range q.Attributes {
+		f := &q.Attributes[i]
+		field := "attr." + f.Key
+		if f.Op != "eq" {
+			field += "." + f.Op
+		}
+		defs, err := s.store.AttributeDefs(ctx, f.Key, genreID)
+		if err != nil {
+			return err
+		}
+		if len(defs) == 0 {
+			return apperr.Invalid("unknown attribute").WithField(field, "is not defined for any genre")
+		}
+		typ := defs[0].Type
+		for _, d := range defs[1:] {
+			if d.Type != typ {
+				return apperr.Invalid("ambiguous attribute").WithField(field, "has different types across genres; specify genre_id")
+			}
+		}
+		if f.Op != "eq" && typ != genres.TypeNumber && typ != genres.TypeDate {
+			return apperr.Invalid("validation failed").WithField(field, "range is only supported for number and date")
+		}
+		if typ == genres.TypeEnum {
+			typ = genres.TypeString // 選択肢はジャンルごとに違いうるので一致だけ見る
+		}
+		v, msg := genres.ParseValue(genres.Attribute{Key: f.Key, Type: typ}, f.Value)
+		if msg != "" {
+			return apperr.Invalid("validation failed").WithField(field, msg)
+		}
+		f.column = attrColumns[typ]
+		switch {
+		case v.Date != nil:
+			f.arg = *v.Date
+		case v.Number != nil:
+			f.arg = *v.Number
+		default:
+			f.arg = v.Text
+		}
+	}
+	return nil
 }
 
 // ===== Assets =====
 
 func (s *Service) CreateAsset(ctx context.Context, in CreateAssetRequest) (AssetResponse, error) {
+	var out AssetResponse
+	err := db.RunInTx(ctx, s.db, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(ctx context.Context, tx db.DBTX) error {
+		var err error
+		out, err = s.CreateAssetTx(ctx, tx, in)
+		return err
+	})
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	return out, nil
+}
+
+// CreateAssetTx: 呼び出し側の Tx で在庫行を登録・監査する
+func (s *Service) CreateAssetTx(ctx context.Context, tx db.DBTX, in CreateAssetRequest) (AssetResponse, error) {
 	var masterID uint64
 	if in.AssetMasterID == nil {
 		log.Printf("asset_master_id is required")
-		return AssetResponse{}, ErrInvalid("either asset_master_id or management_number is required")
+		return AssetResponse{}, apperr.Invalid("either asset_master_id or management_number is required")
 	} else if in.AssetMasterID != nil {
 		log.Printf("asset_master_id: %d", *in.AssetMasterID)
 		masterID = *in.AssetMasterID
@@ -150,58 +373,115 @@ func (s *Service) CreateAsset(ctx context.Context, in CreateAssetRequest) (Asset
 	// quantity >= 0
 	if int(in.Quantity) < 0 {
 		log.Printf("quantity must be >= 0")
-		return AssetResponse{}, ErrInvalid("quantity must be >= 0")
+		return AssetResponse{}, apperr.Invalid("quantity must be >= 0")
 	}
-	if strings.TrimSpace(in.Owner) == "" || strings.TrimSpace(in.DefaultLocation) == "" {
-		log.Printf("owner/default_location required")
-		return AssetResponse{}, ErrInvalid("owner/default_location required")
+	if strings.TrimSpace(in.Owner) == "" || in.DefaultLocationID == 0 {
+		log.Printf("owner/default_location_id required")
+		return AssetResponse{}, apperr.Invalid("owner/default_location_id required")
 	}
 	if in.PurchasedAt.IsZero() {
 		log.Printf("purchased_at required")
-		return AssetResponse{}, ErrInvalid("purchased_at required")
+		return AssetResponse{}, apperr.Invalid("purchased_at required")
 	}
 
-	id, mgmt, err := s.store.CreateAssetTx(ctx, in, masterID)
+	st := NewStore(tx)
+	id, _, err := st.CreateAsset(ctx, in, masterID)
+	if err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1452 {
+			return AssetResponse{}, apperr.Invalid("invalid asset_master_id, status_id or location_id")
+		}
+		return AssetResponse{}, err
+	}
+	a, err := st.GetAssetByID(ctx, id)
 	if err != nil {
 		return AssetResponse{}, err
 	}
 
-	return AssetResponse{
-		AssetID:          id,
-		ManagementNumber: mgmt,
-		// 必要ならその他の最小項目をここで埋める
-	}, nil
+	return *a, audit.Record(ctx, tx, audit.Entry{
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityAsset,
+		EntityID:   strconv.FormatUint(id, 10),
+		After:      *a,
+	})
 }
 
 func (s *Service) GetAsset(ctx context.Context, id uint64) (AssetResponse, error) {
 	out, err := s.store.GetAssetByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return AssetResponse{}, ErrNotFound("asset not found")
+			return AssetResponse{}, apperr.NotFound("asset not found")
 		}
 		return AssetResponse{}, err
 	}
 	return *out, nil
 }
 
-func (s *Service) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]AssetResponse, int64, error) {
-	items, total, err := s.store.ListAssets(ctx, q, p)
+func (s *Service) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) (pagination.Page[AssetResponse], error) {
+	rows, err := s.store.ListAssets(ctx, q, p)
 	if err != nil {
-		return nil, 0, err
+		return pagination.Page[AssetResponse]{}, err
 	}
-	return items, total, nil
+	sort, _ := assetOrder(p)
+	return pagination.Build(p.Request, rows, func(last AssetResponse) string {
+		return pagination.Encode(sort, last.PurchasedAt, last.AssetID)
+	}), nil
 }
 
-func (s *Service) UpdateAsset(ctx context.Context, id uint64, in UpdateAssetRequest) (AssetResponse, error) {
+// ExportAssets: fn がエラーを返すとそこで打ち切る
+func (s *Service) ExportAssets(ctx context.Context, q AssetSearchQuery, fn func(ExportRow) error) error {
+	return s.store.ExportAssets(ctx, q, fn)
+}
+
+// UpdateAsset: ifMatch は If-Match で受け取ったバージョン。現行と違えば 412
+func (s *Service) UpdateAsset(ctx context.Context, id uint64, ifMatch uint64, in UpdateAssetRequest) (AssetResponse, error) {
 	if in.Quantity != nil && int(*in.Quantity) < 0 {
-		return AssetResponse{}, ErrInvalid("quantity must be >= 0")
+		return AssetResponse{}, apperr.Invalid("quantity must be >= 0")
 	}
-	out, err := s.store.UpdateAssetByID(ctx, id, in)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return AssetResponse{}, ErrNotFound("asset not found")
+	var out AssetResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.LockAssetByID(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("asset not found")
+			}
+			return err
+		}
+		if before.Version != ifMatch {
+			return errStale(before.Version)
+		}
+		if in.StatusID != nil {
+			if err := statuses.CheckTransition(ctx, tx, before.StatusID, *in.StatusID); err != nil {
+				return err
+			}
 		}
+		after, err := st.UpdateAssetByID(ctx, id, in)
+		if err != nil {
+			var me *mysql.MySQLError
+			if errors.As(err, &me) && me.Number == 1452 {
+				return apperr.Invalid("invalid status_id or location_id")
+			}
+			return err
+		}
+		out = *after
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityAsset,
+			EntityID:   strconv.FormatUint(id, 10),
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
 		return AssetResponse{}, err
 	}
-	return *out, nil
+	return out, nil
+}
+
+// errStale: 他の更新が先に入っている（クライアントは GET し直してから再送する）
+func errStale(current uint64) error {
+	return apperr.PreconditionFailed("resource has been modified; fetch it again and retry").
+		WithDetail("current_version", current)
 }
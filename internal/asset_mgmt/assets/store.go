@@ -7,21 +7,27 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"IRIS-backend/internal/asset_mgmt/genres"
+	"IRIS-backend/internal/asset_mgmt/locations"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
 )
 
-type Store struct{ db *sql.DB }
+// 書き込みは Service 側で db.RunInTx を張り、NewStore(tx) で使う（attendance と同じ形）
+type Store struct{ db db.DBTX }
 
-func NewStore(db *sql.DB) *Store { return &Store{db: db} }
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
 
 // ===== master =====
 
-// 1) 仮INSERT（created_at は DB時刻）
-func (s *Store) InsertMasterTmp(ctx context.Context, in CreateAssetMasterRequest, tmpMng string) (uint64, error) {
+// 1) INSERT（管理番号は Service が numbering で採番済み。created_at は DB時刻）
+func (s *Store) InsertMaster(ctx context.Context, in CreateAssetMasterRequest, mng string) (uint64, error) {
 	const q = `
 	INSERT INTO assets_master
 	(management_number, name, management_category_id, genre_id, manufacturer, model, created_at)
 	VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
-	res, err := s.db.ExecContext(ctx, q, tmpMng, in.Name, in.ManagementCategoryID, in.GenreID, in.Manufacturer, in.Model)
+	res, err := s.db.ExecContext(ctx, q, mng, in.Name, in.ManagementCategoryID, in.GenreID, in.Manufacturer, in.Model)
 	if err != nil {
 		return 0, err
 	}
@@ -32,53 +38,64 @@ func (s *Store) InsertMasterTmp(ctx context.Context, in CreateAssetMasterRequest
 	return uint64(id), nil
 }
 
-// 2) 確定番号に置換（DBの created_at / genres.genre_code 利用）
-func (s *Store) UpdateMngToFinal(ctx context.Context, id uint64, tmpMng string, pad int) error {
-	q := fmt.Sprintf(`
-	UPDATE assets_master m
-	JOIN asset_genres g ON g.genre_id = m.genre_id
-	SET m.management_number = CONCAT(g.genre_code, '-', DATE_FORMAT(m.created_at, '%%Y%%m%%d'), '-', LPAD(m.asset_master_id, %d, '0'))
-	WHERE m.asset_master_id = ? AND m.management_number = ?`, pad)
+// 2) 採番用のコード（見つからなければ sql.ErrNoRows）
+func (s *Store) GenreCode(ctx context.Context, genreID uint) (string, error) {
+	var code string
+	err := s.db.QueryRowContext(ctx, `SELECT genre_code FROM asset_genres WHERE genre_id = ?`, genreID).Scan(&code)
+	return code, err
+}
 
-	res, err := s.db.ExecContext(ctx, q, id, tmpMng)
-	if err != nil {
-		return err
-	}
-	if aff, _ := res.RowsAffected(); aff != 1 {
-		return ErrConflict("no row updated")
-	}
-	return nil
+func (s *Store) CategoryCode(ctx context.Context, categoryID uint) (string, error) {
+	var code string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT category_code FROM management_categories WHERE management_category_id = ?`, categoryID).Scan(&code)
+	return code, err
 }
 
 // 3) 取得
 func (s *Store) GetMasterByID(ctx context.Context, id uint64) (*AssetMasterResponse, error) {
 	const sel = `
-	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at
+	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at, version
 	FROM assets_master WHERE asset_master_id = ?`
 	var out AssetMasterResponse
 	if err := s.db.QueryRowContext(ctx, sel, id).Scan(
 		&out.AssetMasterID, &out.ManagementNumber, &out.Name, &out.ManagementCategoryID,
-		&out.GenreID, &out.Manufacturer, &out.Model, &out.CreatedAt,
+		&out.GenreID, &out.Manufacturer, &out.Model, &out.CreatedAt, &out.Version,
 	); err != nil {
 		return nil, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{&out}); err != nil {
+		return nil, err
+	}
 	return &out, nil
 }
 
 func (s *Store) GetMasterByMng(ctx context.Context, mng string) (*AssetMasterResponse, error) {
-	const q = `
-	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at
-	FROM assets_master WHERE management_number = ?`
+	return s.getMasterByMng(ctx, mng, "")
+}
+
+// LockMasterByMng: 更新前の値を取りつつ行ロック（Tx 内で使う）
+func (s *Store) LockMasterByMng(ctx context.Context, mng string) (*AssetMasterResponse, error) {
+	return s.getMasterByMng(ctx, mng, " FOR UPDATE")
+}
+
+func (s *Store) getMasterByMng(ctx context.Context, mng string, lock string) (*AssetMasterResponse, error) {
+	q := `
+	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at, version
+	FROM assets_master WHERE management_number = ?` + lock
 	var r AssetMasterResponse
 	if err := s.db.QueryRowContext(ctx, q, mng).Scan(
 		&r.AssetMasterID, &r.ManagementNumber, &r.Name, &r.ManagementCategoryID, &r.GenreID,
-		&r.Manufacturer, &r.Model, &r.CreatedAt,
+		&r.Manufacturer, &r.Model, &r.CreatedAt, &r.Version,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
 		}
 		return nil, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{&r}); err != nil {
+		return nil, err
+	}
 	return &r, nil
 }
 
@@ -91,7 +108,8 @@ func (s *Store) GetMasterIDByMng(ctx context.Context, mng string) (uint64, error
 	return id, nil
 }
 
-func (s *Store) UpdateMasterByMng(ctx context.Context, mng string, in UpdateAssetMasterRequest) (*AssetMasterResponse, error) {
+// touch: 属性だけ変えたときもバージョンを進める
+func (s *Store) UpdateMasterByMng(ctx context.Context, mng string, in UpdateAssetMasterRequest, touch bool) (*AssetMasterResponse, error) {
 	// 動的アップデート
 	sets := []string{}
 	args := []any{}
@@ -115,196 +133,471 @@ func (s *Store) UpdateMasterByMng(ctx context.Context, mng string, in UpdateAsse
 		sets = append(sets, "model = ?")
 		args = append(args, *in.Model)
 	}
-	if len(sets) == 0 {
+	if len(sets) == 0 && !touch {
 		// 変更なしでも現行値を返す
 		return s.GetMasterByMng(ctx, mng)
 	}
+	sets = append(sets, "version = version + 1")
 	args = append(args, mng)
 	q := fmt.Sprintf(`UPDATE assets_master SET %s WHERE management_number = ?`, strings.Join(sets, ", "))
 
-	res, err := s.db.ExecContext(ctx, q, args...)
-	if err != nil {
+	// 存在確認は呼び出し側の LockMasterByMng で済んでいる（同値更新だと RowsAffected=0 になるため見ない）
+	if _, err := s.db.ExecContext(ctx, q, args...); err != nil {
 		return nil, err
 	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		return nil, sql.ErrNoRows
-	}
 	return s.GetMasterByMng(ctx, mng)
 }
 
-func (s *Store) ListMasters(ctx context.Context, p Page, q AssetSearchQuery) ([]AssetMasterResponse, int64, error) {
-	// --- 1. 動的クエリ構築のための準備 ---
-	var sb strings.Builder
-	args := []any{}
+// マスタ検索の並び順に使える列（sort パラメータの値 → ORDER BY の式）
+var masterSortColumns = map[string]string{
+	"relevance":         "score",
+	"created_at":        "created_at",
+	"name":              "name",
+	"management_number": "management_number",
+	"manufacturer":      "manufacturer",
+	"model":             "IFNULL(model, '')",
+}
 
-	// --- 2. SELECT句の構築 ---
-	sb.WriteString(`
-	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at
-	FROM assets_master
-	WHERE 1=1
-	`)
+// ngram_token_size（既定 2）未満の語は全文索引に載らないので LIKE で拾う
+const ngramTokenSize = 2
 
-	// --- 3. WHERE句（フィルタ条件）の動的な追加 ---
-	if q.GenreID != nil && *q.GenreID != 0 {
-		sb.WriteString(" AND genre_id = ?")
-		args = append(args, *q.GenreID)
+// masterSortKeys: 指定がなければ q ありは relevance、なしは created_at（Page.Order）
+func masterSortKeys(p Page, q MasterSearchQuery) []SortKey {
+	if len(q.Sort) > 0 {
+		return q.Sort
 	}
-	// 他のフィルタ条件もここに追加できます
-	// if q.Name != nil { ... }
+	var keys []SortKey
+	if q.Keyword != nil {
+		keys = []SortKey{{Field: "relevance", Desc: true}}
+	}
+	return append(keys, SortKey{Field: "created_at", Desc: strings.ToLower(p.Order) != "asc"})
+}
+
+// masterOrder: ORDER BY の列と cursor の識別子（"-relevance,name" の正規形）。
+// 関連度は行ごとに計算し直す値なのでキーセットにせず、keyset=false で読み飛ばし件数を使う
+func masterOrder(keys []SortKey) (sort string, cols []pagination.Column, keyset bool) {
+	names := make([]string, 0, len(keys))
+	cols = make([]pagination.Column, 0, len(keys)+1)
+	keyset = true
+	for _, k := range keys {
+		name := k.Field
+		if k.Desc {
+			name = "-" + name
+		}
+		names = append(names, name)
+		cols = append(cols, pagination.Column{Expr: masterSortColumns[k.Field], Desc: k.Desc})
+		if k.Field == "relevance" {
+			keyset = false
+		}
+	}
+	cols = append(cols, pagination.Column{Expr: "asset_master_id", Desc: true})
+	return strings.Join(names, ","), cols, keyset
+}
 
-	// --- 4. ORDER BY句の安全な構築 ---
-	order := "DESC"
-	if strings.ToLower(p.Order) == "asc" {
-		order = "ASC"
+// masterSortValue: cursor に入れる行のソートキー（masterSortColumns の式と同じ値）
+func masterSortValue(field string, r AssetMasterResponse) any {
+	switch field {
+	case "created_at":
+		return r.CreatedAt
+	case "name":
+		return r.Name
+	case "management_number":
+		return r.ManagementNumber
+	case "manufacturer":
+		return r.Manufacturer
+	case "model":
+		if r.Model == nil {
+			return ""
+		}
+		return *r.Model
 	}
-	// Sprintfを使わず、変数を直接埋め込むことでSQLインジェクションを防ぐ
-	sb.WriteString(" ORDER BY created_at " + order)
+	return nil
+}
 
-	// --- 5. LIMIT / OFFSET句の構築 ---
-	if p.Limit <= 0 {
-		p.Limit = 50
+// masterCursor: ページ最後の行から次の cursor を作る（offset は関連度順のときだけ使う）
+func masterCursor(keys []SortKey, offset int, last AssetMasterResponse) string {
+	sort, _, keyset := masterOrder(keys)
+	if !keyset {
+		return pagination.EncodeOffset(sort, offset)
+	}
+	vals := make([]any, 0, len(keys)+1)
+	for _, k := range keys {
+		vals = append(vals, masterSortValue(k.Field, last))
 	}
-	if p.Offset < 0 {
-		p.Offset = 0
+	return pagination.Encode(sort, append(vals, last.AssetMasterID)...)
+}
+
+// ListMasters: cursor の続きから p.Fetch() 件。offset は関連度順で読み飛ばした件数（キーセット時は 0）
+func (s *Store) ListMasters(ctx context.Context, p Page, q MasterSearchQuery) (list []AssetMasterResponse, offset int, err error) {
+	where, whereArgs := masterWhere(q)
+	score, scoreArgs := masterScore(q)
+
+	var sb strings.Builder
+	args := append([]any{}, scoreArgs...)
+	sb.WriteString(`
+	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at, version,
+	` + score + ` AS score
+	FROM assets_master
+	` + where)
+	args = append(args, whereArgs...)
+
+	// ORDER BY（列名は masterSortColumns の値だけを埋め込む）。最後に主キーで順序を固定
+	keys := masterSortKeys(p, q)
+	sort, cols, keyset := masterOrder(keys)
+	if keyset {
+		vals := make([]any, len(cols))
+		dest := make([]any, len(cols))
+		for i, k := range keys {
+			if k.Field == "created_at" {
+				dest[i] = new(time.Time)
+			} else {
+				dest[i] = new(string)
+			}
+		}
+		dest[len(keys)] = new(uint64)
+		ok, err := p.After(sort, dest...)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			for i, d := range dest {
+				switch v := d.(type) {
+				case *time.Time:
+					vals[i] = *v
+				case *string:
+					vals[i] = *v
+				case *uint64:
+					vals[i] = *v
+				}
+			}
+			cond, seekArgs := pagination.Seek(cols, vals)
+			sb.WriteString(" AND " + cond)
+			args = append(args, seekArgs...)
+		}
+		sb.WriteString(" ORDER BY " + pagination.OrderBy(cols) + " LIMIT ?")
+		args = append(args, p.Fetch())
+	} else {
+		if offset, err = p.Offset(sort); err != nil {
+			return nil, 0, err
+		}
+		sb.WriteString(" ORDER BY " + pagination.OrderBy(cols) + " LIMIT ? OFFSET ?")
+		args = append(args, p.Fetch(), offset)
 	}
-	sb.WriteString(" LIMIT ? OFFSET ?")
-	args = append(args, p.Limit, p.Offset)
 
-	// --- 6. クエリの実行 ---
-	query := sb.String()
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	rows, err := s.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	// --- 7. 結果のスキャン (変更なし) ---
-	list := []AssetMasterResponse{}
 	for rows.Next() {
 		var r AssetMasterResponse
+		var sc float64
 		if err := rows.Scan(
 			&r.AssetMasterID, &r.ManagementNumber, &r.Name, &r.ManagementCategoryID, &r.GenreID,
-			&r.Manufacturer, &r.Model, &r.CreatedAt,
+			&r.Manufacturer, &r.Model, &r.CreatedAt, &r.Version, &sc,
 		); err != nil {
 			return nil, 0, err
 		}
+		if q.Keyword != nil {
+			r.Score = &sc
+		}
 		list = append(list, r)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, 0, err
 	}
+	ptrs := make([]*AssetMasterResponse, len(list))
+	for i := range list {
+		ptrs[i] = &list[i]
+	}
+	return list, offset, s.fillAttributes(ctx, ptrs)
+}
 
-	// --- 8. 総件数取得クエリの構築（フィルタを反映） ---
-	var cb strings.Builder
-	countArgs := []any{}
-	cb.WriteString("SELECT COUNT(*) FROM assets_master WHERE 1=1")
-
+// masterWhere: 検索条件の WHERE 句
+func masterWhere(q MasterSearchQuery) (string, []any) {
+	var sb strings.Builder
+	args := []any{}
+	sb.WriteString("WHERE 1=1")
+
+	if q.Keyword != nil {
+		long, short := splitTerms(*q.Keyword)
+		conds := []string{}
+		if len(long) > 0 {
+			conds = append(conds, "MATCH(name, manufacturer, model, management_number) AGAINST (? IN BOOLEAN MODE)")
+			args = append(args, booleanQuery(long))
+		}
+		for _, t := range short {
+			conds = append(conds, "CONCAT_WS(' ', name, manufacturer, model, management_number) LIKE ?")
+			args = append(args, "%"+escapeLike(t)+"%")
+		}
+		if len(conds) > 0 {
+			sb.WriteString(" AND (" + strings.Join(conds, " OR ") + ")")
+		}
+	}
 	if q.GenreID != nil && *q.GenreID != 0 {
-		cb.WriteString(" AND genre_id = ?")
-		countArgs = append(countArgs, *q.GenreID)
+		sb.WriteString(" AND genre_id = ?")
+		args = append(args, *q.GenreID)
 	}
-	// 他のフィルタ条件もここに追加...
+	if q.ManagementCategoryID != nil && *q.ManagementCategoryID != 0 {
+		sb.WriteString(" AND management_category_id = ?")
+		args = append(args, *q.ManagementCategoryID)
+	}
+	if q.CreatedFrom != nil {
+		sb.WriteString(" AND created_at >= ?")
+		args = append(args, *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		sb.WriteString(" AND created_at < ?")
+		args = append(args, *q.CreatedTo)
+	}
+	// 属性（列と値は Service が属性の型から決めたもの）
+	for _, f := range q.Attributes {
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM asset_master_attributes ama
+			WHERE ama.asset_master_id = assets_master.asset_master_id AND ama.attr_key = ? AND ama.` + f.column + ` ` + attrOps[f.Op] + ` ?)`)
+		args = append(args, f.Key, f.arg)
+	}
+	return sb.String(), args
+}
 
-	var total int64
-	if err := s.db.QueryRowContext(ctx, cb.String(), countArgs...).Scan(&total); err != nil {
-		return nil, 0, err
+// masterScore: 関連度。全文検索のスコアに、管理番号の完全一致・名前/型番の前方一致を上乗せする
+func masterScore(q MasterSearchQuery) (string, []any) {
+	if q.Keyword == nil {
+		return "0", nil
+	}
+	kw := strings.TrimSpace(*q.Keyword)
+	expr := "(management_number = ?) * 100 + (name LIKE ? OR IFNULL(model, '') LIKE ?) * 10"
+	prefix := escapeLike(kw) + "%"
+	args := []any{kw, prefix, prefix}
+	if long, _ := splitTerms(kw); len(long) > 0 {
+		expr += " + MATCH(name, manufacturer, model, management_number) AGAINST (? IN BOOLEAN MODE)"
+		args = append(args, booleanQuery(long))
+	}
+	return "(" + expr + ")", args
+}
+
+// splitTerms: 空白区切りの語を、全文索引で引ける語とそれ未満の短い語に分ける
+func splitTerms(kw string) (long, short []string) {
+	for _, t := range strings.Fields(kw) {
+		// BOOLEAN MODE の演算子になる文字は落とす
+		t = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`+-<>()~*"@`, r) {
+				return -1
+			}
+			return r
+		}, t)
+		switch n := len([]rune(t)); {
+		case n == 0:
+		case n < ngramTokenSize:
+			short = append(short, t)
+		default:
+			long = append(long, t)
+		}
+	}
+	return long, short
+}
+
+// booleanQuery: 各語をフレーズとして OR 検索（どれか1語でも当たれば拾い、多く当たるほど上位）
+func booleanQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ===== master の属性 =====
+
+// 属性の絞り込みの比較演算子
+var attrOps = map[string]string{"eq": "=", "min": ">=", "max": "<="}
+
+// attrColumns: 属性の型 → 比較に使う asset_master_attributes の列
+var attrColumns = map[string]string{
+	genres.TypeString: "value_text",
+	genres.TypeEnum:   "value_text",
+	genres.TypeNumber: "value_number",
+	genres.TypeBool:   "value_number",
+	genres.TypeDate:   "value_date",
+}
+
+// fillAttributes: マスタの属性値を1回の問い合わせで埋める（型は今のジャンルの定義から）
+func (s *Store) fillAttributes(ctx context.Context, list []*AssetMasterResponse) error {
+	if len(list) == 0 {
+		return nil
+	}
+	byID := make(map[uint64]*AssetMasterResponse, len(list))
+	args := make([]any, 0, len(list))
+	for _, m := range list {
+		byID[m.AssetMasterID] = m
+		args = append(args, m.AssetMasterID)
+	}
+	q := `
+	SELECT v.asset_master_id, v.attr_key, v.value_text, v.value_number, IFNULL(ga.value_type, '')
+	FROM asset_master_attributes v
+	JOIN assets_master m ON m.asset_master_id = v.asset_master_id
+	LEFT JOIN genre_attributes ga ON ga.genre_id = m.genre_id AND ga.attr_key = v.attr_key
+	WHERE v.asset_master_id IN (?` + strings.Repeat(", ?", len(args)-1) + `)`
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id       uint64
+			key, typ string
+			v        genres.Value
+			num      sql.NullFloat64
+		)
+		if err := rows.Scan(&id, &key, &v.Text, &num, &typ); err != nil {
+			return err
+		}
+		if num.Valid {
+			v.Number = &num.Float64
+		}
+		m := byID[id]
+		if m.Attributes == nil {
+			m.Attributes = make(map[string]any)
+		}
+		m.Attributes[key] = v.JSON(typ)
 	}
+	return rows.Err()
+}
 
-	return list, total, nil
+// ReplaceMasterAttributes: マスタの属性値を丸ごと置き換える
+func (s *Store) ReplaceMasterAttributes(ctx context.Context, masterID uint64, vals map[string]genres.Value) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM asset_master_attributes WHERE asset_master_id = ?`, masterID); err != nil {
+		return err
+	}
+	const q = `
+	INSERT INTO asset_master_attributes (asset_master_id, attr_key, value_text, value_number, value_date)
+	VALUES (?, ?, ?, ?, ?)`
+	for k, v := range vals {
+		if _, err := s.db.ExecContext(ctx, q, masterID, k, v.Text, v.Number, v.Date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttributeDefs: attr_key の定義（genreID が 0 なら全ジャンル）。絞り込みの型を決めるのに使う
+func (s *Store) AttributeDefs(ctx context.Context, key string, genreID uint) ([]genres.Attribute, error) {
+	q := `SELECT genre_id, value_type FROM genre_attributes WHERE attr_key = ?`
+	args := []any{key}
+	if genreID != 0 {
+		q += ` AND genre_id = ?`
+		args = append(args, genreID)
+	}
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []genres.Attribute
+	for rows.Next() {
+		a := genres.Attribute{Key: key}
+		if err := rows.Scan(&a.GenreID, &a.Type); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
 }
 
 // ===== assets =====
 
 type assetRow struct {
-	AssetID          uint64
-	AssetMasterID    uint64
-	ManagementNumber string
-	Serial           sqlNullString
-	Quantity         uint
-	PurchasedAt      time.Time
-	StatusID         uint
-	Owner            string
-	DefaultLocation  string
-	Location         sqlNullString
-	LastCheckedAt    sqlNullTime
-	LastCheckedBy    sqlNullString
-	Notes            sqlNullString
+	AssetID           uint64
+	AssetMasterID     uint64
+	ManagementNumber  string
+	Serial            sqlNullString
+	Quantity          uint
+	PurchasedAt       time.Time
+	StatusID          uint
+	Owner             string
+	DefaultLocationID uint
+	LocationID        sql.NullInt64
+	LastCheckedAt     sqlNullTime
+	LastCheckedBy     sqlNullString
+	Notes             sqlNullString
 }
 
 type sqlNullString struct{ sql.NullString }
 type sqlNullTime struct{ sql.NullTime }
 
-
-func (s *Store) CreateAssetTx(
-    ctx context.Context,
-    in CreateAssetRequest,
-    masterID uint64,
+// CreateAsset: Tx 内で在庫行を作成（last_checked_at は登録時刻）
+func (s *Store) CreateAsset(
+	ctx context.Context,
+	in CreateAssetRequest,
+	masterID uint64,
 ) (assetID uint64, managementNumber string, err error) {
-
-    tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-    if err != nil {
-        return 0, "", err
-    }
-    // 失敗時は必ずRollback
-    defer func() {
-        if err != nil {
-            _ = tx.Rollback()
-        }
-    }()
-
-    const qIns = `
+	const qIns = `
         INSERT INTO assets
-          (asset_master_id, serial, quantity, purchased_at, status_id, owner, default_location,
-           location, last_checked_at, last_checked_by, notes)
+          (asset_master_id, serial, quantity, purchased_at, status_id, owner, default_location_id,
+           location_id, last_checked_at, last_checked_by, notes)
         VALUES (?, ?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP(), ?, ?)`
 
-    res, err := tx.ExecContext(ctx, qIns,
-        masterID,
-        in.Serial,
-        in.Quantity,
-        in.PurchasedAt,
-        in.StatusID,
-        in.Owner,
-        in.DefaultLocation,
-        in.Location,
-        in.LastCheckedBy,
-        in.Notes,
-    )
-    if err != nil {
-        return 0, "", err
-    }
-
-    id64, err := res.LastInsertId()
-    if err != nil {
-        return 0, "", err
-    }
-    assetID = uint64(id64)
-
-    const qMgmt = `SELECT management_number FROM assets_master WHERE asset_master_id = ?`
-    if err = tx.QueryRowContext(ctx, qMgmt, masterID).Scan(&managementNumber); err != nil {
-        log.Printf("Failed to resolve management_number for masterID=%d: %v", masterID, err)
-        return 0, "", err
-    }
-
-    if err = tx.Commit(); err != nil {
-        return 0, "", err
-    }
-    return assetID, managementNumber, nil
+	res, err := s.db.ExecContext(ctx, qIns,
+		masterID,
+		in.Serial,
+		in.Quantity,
+		in.PurchasedAt,
+		in.StatusID,
+		in.Owner,
+		in.DefaultLocationID,
+		in.LocationID,
+		in.LastCheckedBy,
+		in.Notes,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	id64, err := res.LastInsertId()
+	if err != nil {
+		return 0, "", err
+	}
+	assetID = uint64(id64)
+
+	const qMgmt = `SELECT management_number FROM assets_master WHERE asset_master_id = ?`
+	if err = s.db.QueryRowContext(ctx, qMgmt, masterID).Scan(&managementNumber); err != nil {
+		log.Printf("Failed to resolve management_number for masterID=%d: %v", masterID, err)
+		return 0, "", err
+	}
+	return assetID, managementNumber, nil
 }
 
 func (s *Store) GetAssetByID(ctx context.Context, id uint64) (*AssetResponse, error) {
-	const q = `
+	return s.getAssetByID(ctx, id, "")
+}
+
+// LockAssetByID: 更新前の値を取りつつ行ロック（Tx 内で使う）
+func (s *Store) LockAssetByID(ctx context.Context, id uint64) (*AssetResponse, error) {
+	return s.getAssetByID(ctx, id, " FOR UPDATE")
+}
+
+func (s *Store) getAssetByID(ctx context.Context, id uint64, lock string) (*AssetResponse, error) {
+	q := `
 	SELECT a.asset_id, a.asset_master_id, m.management_number, a.serial, a.quantity, a.purchased_at, a.status_id,
-		a.owner, a.default_location, a.location, a.last_checked_at, a.last_checked_by, a.notes
+		a.owner, a.default_location_id, dl.location_code, a.location_id, cl.location_code,
+		a.last_checked_at, a.last_checked_by, a.notes, a.version
 	FROM assets a
 	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
-	WHERE a.asset_master_id = ?`
+	` + joinLocations + `
+	WHERE a.asset_id = ?` + lock
 	var r AssetResponse
 	var serial, loc, lcb, notes sql.NullString
+	var locID sql.NullInt64
 	var lct sql.NullTime
 	if err := s.db.QueryRowContext(ctx, q, id).Scan(
 		&r.AssetID, &r.AssetMasterID, &r.ManagementNumber, &serial, &r.Quantity, &r.PurchasedAt, &r.StatusID,
-		&r.Owner, &r.DefaultLocation, &loc, &lct, &lcb, &notes,
+		&r.Owner, &r.DefaultLocationID, &r.DefaultLocationCode, &locID, &loc, &lct, &lcb, &notes, &r.Version,
 	); err != nil {
 		return nil, err
 	}
@@ -312,9 +605,9 @@ func (s *Store) GetAssetByID(ctx context.Context, id uint64) (*AssetResponse, er
 		v := serial.String
 		r.Serial = &v
 	}
-	if loc.Valid {
-		v := loc.String
-		r.Location = &v
+	if locID.Valid {
+		v, code := uint(locID.Int64), loc.String
+		r.LocationID, r.LocationCode = &v, &code
 	}
 	if lct.Valid {
 		v := lct.Time
@@ -354,13 +647,13 @@ func (s *Store) UpdateAssetByID(ctx context.Context, id uint64, in UpdateAssetRe
 		sets = append(sets, "owner = ?")
 		args = append(args, *in.Owner)
 	}
-	if in.DefaultLocation != nil {
-		sets = append(sets, "default_location = ?")
-		args = append(args, *in.DefaultLocation)
+	if in.DefaultLocationID != nil {
+		sets = append(sets, "default_location_id = ?")
+		args = append(args, *in.DefaultLocationID)
 	}
-	if in.Location != nil {
-		sets = append(sets, "location = ?")
-		args = append(args, *in.Location)
+	if in.LocationID != nil {
+		sets = append(sets, "location_id = NULLIF(?, 0)")
+		args = append(args, *in.LocationID)
 	}
 	if in.LastCheckedAt != nil {
 		sets = append(sets, "last_checked_at = ?")
@@ -379,38 +672,114 @@ func (s *Store) UpdateAssetByID(ctx context.Context, id uint64, in UpdateAssetRe
 		return s.GetAssetByID(ctx, id)
 	}
 
+	sets = append(sets, "version = version + 1")
 	args = append(args, id)
 	q := fmt.Sprintf(`UPDATE assets SET %s WHERE asset_id = ?`, strings.Join(sets, ", "))
-	res, err := s.db.ExecContext(ctx, q, args...)
-	if err != nil {
+	// 存在確認は呼び出し側の LockAssetByID で済んでいる
+	if _, err := s.db.ExecContext(ctx, q, args...); err != nil {
 		return nil, err
 	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		return nil, sql.ErrNoRows
-	}
 	return s.GetAssetByID(ctx, id)
 }
 
-func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]AssetResponse, int64, error) {
-	// 安全な order
-	order := "DESC"
-	if strings.ToLower(p.Order) == "asc" {
-		order = "ASC"
-	}
-	if p.Limit <= 0 {
-		p.Limit = 50
-	}
-	if p.Offset < 0 {
-		p.Offset = 0
+// assetOrder: 個体一覧の並び（purchased_at, asset_id）と cursor の識別子
+func assetOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "purchased_at:asc"
+	if desc {
+		sort = "purchased_at:desc"
 	}
+	return sort, []pagination.Column{{Expr: "a.purchased_at", Desc: desc}, {Expr: "a.asset_id", Desc: desc}}
+}
 
-	// ベース句（SELECT と COUNT で共通）
+// ListAssets: cursor の続きから p.Fetch() 件
+func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]AssetResponse, error) {
 	baseFrom := `
 	FROM assets a
 	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
-	`
+	` + joinLocations
+
+	where, args := assetWhere(q)
+
+	sort, cols := assetOrder(p)
+	var (
+		lastAt time.Time
+		lastID uint64
+	)
+	ok, err := p.After(sort, &lastAt, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastAt, lastID})
+		where += " AND " + cond
+		args = append(args, seekArgs...)
+	}
+
+	// 一覧取得用 SQL
+	selectSQL := `
+	SELECT a.asset_id, a.asset_master_id, m.management_number, a.serial, a.quantity, a.purchased_at, a.status_id,
+		a.owner, a.default_location_id, dl.location_code, a.location_id, cl.location_code,
+		a.last_checked_at, a.last_checked_by, a.notes, a.version
+	` + baseFrom + `
+	` + where + `
+	ORDER BY ` + pagination.OrderBy(cols) + `
+	LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, selectSQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	// WHERE 句と args を共通で作る
+	out := []AssetResponse{}
+	for rows.Next() {
+		var r AssetResponse
+		var serial, loc, lcb, notes sql.NullString
+		var locID sql.NullInt64
+		var lct sql.NullTime
+		if err := rows.Scan(
+			&r.AssetID, &r.AssetMasterID, &r.ManagementNumber, &serial, &r.Quantity, &r.PurchasedAt, &r.StatusID,
+			&r.Owner, &r.DefaultLocationID, &r.DefaultLocationCode, &locID, &loc, &lct, &lcb, &notes, &r.Version,
+		); err != nil {
+			return nil, err
+		}
+		if serial.Valid {
+			v := serial.String
+			r.Serial = &v
+		}
+		if locID.Valid {
+			v, code := uint(locID.Int64), loc.String
+			r.LocationID, r.LocationCode = &v, &code
+		}
+		if lct.Valid {
+			v := lct.Time
+			r.LastCheckedAt = &v
+		}
+		if lcb.Valid {
+			v := lcb.String
+			r.LastCheckedBy = &v
+		}
+		if notes.Valid {
+			v := notes.String
+			r.Notes = &v
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// currentLocation: 在庫行がいまある場所（location_id が空なら既定の場所）
+const currentLocation = "COALESCE(a.location_id, a.default_location_id)"
+
+// joinLocations: 既定の場所（dl）・現在の場所（cl）のコードを引く
+const joinLocations = `
+	JOIN locations dl ON dl.location_id = a.default_location_id
+	LEFT JOIN locations cl ON cl.location_id = a.location_id`
+
+// assetWhere: 在庫行一覧・エクスポート共通の WHERE 句（assets a JOIN assets_master m 前提）
+func assetWhere(q AssetSearchQuery) (string, []any) {
 	where := "WHERE 1=1"
 	args := []any{}
 	if q.ManagementNumber != nil {
@@ -425,12 +794,20 @@ func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]A
 		where += " AND a.status_id = ?"
 		args = append(args, *q.StatusID)
 	}
+	if q.GenreID != nil {
+		where += " AND m.genre_id = ?"
+		args = append(args, *q.GenreID)
+	}
 	if q.Owner != nil {
 		where += " AND a.owner = ?"
 		args = append(args, *q.Owner)
 	}
+	if q.LocationID != nil {
+		where += " AND " + currentLocation + " IN " + locations.Subtree("location_id = ?")
+		args = append(args, *q.LocationID)
+	}
 	if q.Location != nil {
-		where += " AND a.location = ?"
+		where += " AND " + currentLocation + " IN " + locations.Subtree("location_code = ?")
 		args = append(args, *q.Location)
 	}
 	if q.PurchasedFrom != nil {
@@ -441,68 +818,58 @@ func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]A
 		where += " AND a.purchased_at < ?"
 		args = append(args, *q.PurchasedTo)
 	}
+	return where, args
+}
 
-	// 一覧取得用 SQL
-	selectSQL := `
-	SELECT a.asset_id, a.asset_master_id, m.management_number, a.serial, a.quantity, a.purchased_at, a.status_id,
-		a.owner, a.default_location, a.location, a.last_checked_at, a.last_checked_by, a.notes
-	` + baseFrom + `
-	` + where + `
-	ORDER BY a.purchased_at ` + order + `, a.asset_id ` + order + `
-	LIMIT ? OFFSET ?`
-
-	// 実行用引数（where用 + limit/offset）
-	queryArgs := append(append([]any{}, args...), p.Limit, p.Offset)
-
-	rows, err := s.db.QueryContext(ctx, selectSQL, queryArgs...)
+// ExportAssets: 条件に合う在庫行を asset_id 順に1行ずつ fn に渡す（全件をメモリに載せない）
+func (s *Store) ExportAssets(ctx context.Context, q AssetSearchQuery, fn func(ExportRow) error) error {
+	where, args := assetWhere(q)
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT a.asset_id, m.management_number, m.name, m.manufacturer, m.model, g.genre_code, g.genre_name,
+		a.serial, a.quantity,
+		(SELECT COALESCE(SUM(la.quantity - la.returned_quantity - la.lost_quantity), 0) FROM lend_allocations la WHERE la.asset_id = a.asset_id) AS lent_quantity,
+		a.purchased_at, a.status_id, st.status_name, a.owner, dl.location_code, cl.location_code, a.last_checked_at, a.notes
+	FROM assets a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	JOIN asset_genres g ON g.genre_id = m.genre_id
+	JOIN asset_statuses st ON st.status_id = a.status_id
+	`+joinLocations+`
+	`+where+`
+	ORDER BY a.asset_id`, args...)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
 	defer rows.Close()
 
-	out := []AssetResponse{}
 	for rows.Next() {
-		var r AssetResponse
-		var serial, loc, lcb, notes sql.NullString
-		var lct sql.NullTime
+		var (
+			r                         ExportRow
+			model, serial, loc, notes sql.NullString
+			lct                       sql.NullTime
+		)
 		if err := rows.Scan(
-			&r.AssetID, &r.AssetMasterID, &r.ManagementNumber, &serial, &r.Quantity, &r.PurchasedAt, &r.StatusID,
-			&r.Owner, &r.DefaultLocation, &loc, &lct, &lcb, &notes,
+			&r.AssetID, &r.ManagementNumber, &r.Name, &r.Manufacturer, &model, &r.GenreCode, &r.GenreName,
+			&serial, &r.Quantity, &r.LentQuantity,
+			&r.PurchasedAt, &r.StatusID, &r.StatusName, &r.Owner, &r.DefaultLocation, &loc, &lct, &notes,
 		); err != nil {
-			return nil, 0, err
-		}
-		if serial.Valid {
-			v := serial.String
-			r.Serial = &v
-		}
-		if loc.Valid {
-			v := loc.String
-			r.Location = &v
+			return err
 		}
+		r.Model, r.Serial, r.Location, r.Notes = nullStr(model), nullStr(serial), nullStr(loc), nullStr(notes)
 		if lct.Valid {
 			v := lct.Time
 			r.LastCheckedAt = &v
 		}
-		if lcb.Valid {
-			v := lcb.String
-			r.LastCheckedBy = &v
+		if err := fn(r); err != nil {
+			return err
 		}
-		if notes.Valid {
-			v := notes.String
-			r.Notes = &v
-		}
-		out = append(out, r)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
 	}
+	return rows.Err()
+}
 
-	// カウント用 SQL（LIMIT/OFFSETなし・同じWHERE）
-	countSQL := `SELECT COUNT(*) ` + baseFrom + "\n" + where
-	var total int64
-	if err := s.db.QueryRowContext(ctx, countSQL, args...).Scan(&total); err != nil {
-		return nil, 0, err
+func nullStr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
 	}
-
-	return out, total, nil
+	return nil
 }
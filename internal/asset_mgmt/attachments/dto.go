@@ -0,0 +1,29 @@
+package attachments
+
+import "time"
+
+// ---- Requests ----
+
+// multipart のフォーム項目（file 以外）。kind は invoice / manual / checkout / return / evidence など自由な分類
+type UploadRequest struct {
+	Kind *string `form:"kind" binding:"omitempty,max=32"`
+	Note *string `form:"note"`
+}
+
+// ---- Responses ----
+
+type AttachmentResponse struct {
+	AttachmentULID string    `json:"attachment_ulid"`
+	OwnerType      string    `json:"owner_type"`
+	OwnerID        string    `json:"owner_id"`
+	Kind           *string   `json:"kind,omitempty"`
+	FileName       string    `json:"file_name"`
+	ContentType    string    `json:"content_type"` // 中身から判定した値
+	SizeBytes      int64     `json:"size_bytes"`
+	SHA256         string    `json:"sha256"`
+	Note           *string   `json:"note,omitempty"`
+	UploadedByID   *string   `json:"uploaded_by_id,omitempty"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+	ContentURL     string    `json:"content_url"`
+	ThumbnailURL   *string   `json:"thumbnail_url,omitempty"` // 画像で縮小版を作れたときだけ
+}
@@ -0,0 +1,165 @@
+package attachments
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// multipart の file 以外（境界やフォーム項目）に許す余裕
+const formOverhead = 1 << 20
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	// 登録・一覧（親ごと）。POST /assets/:x/... は貸出・廃棄とワイルドカード名を揃える必要があるので
+	// :management_number の位置に asset_id を受ける
+	r.POST("/assets/masters/:management_number/attachments", auth.Allow(auth.StaffOnly...), h.upload(audit.EntityAssetMaster, "management_number"))
+	r.GET("/assets/masters/:management_number/attachments", auth.Allow(auth.Members...), h.list(audit.EntityAssetMaster, "management_number"))
+	r.POST("/assets/:management_number/attachments", auth.Allow(auth.StaffOnly...), h.upload(audit.EntityAsset, "management_number"))
+	r.GET("/assets/:asset_id/attachments", auth.Allow(auth.Members...), h.list(audit.EntityAsset, "asset_id"))
+	r.POST("/lends/:lend_ulid/attachments", auth.Allow(auth.StaffOnly...), h.upload(audit.EntityLend, "lend_ulid"))
+	r.GET("/lends/:lend_ulid/attachments", auth.Allow(auth.Members...), h.list(audit.EntityLend, "lend_ulid"))
+	r.POST("/returns/:return_ulid/attachments", auth.Allow(auth.StaffOnly...), h.upload(audit.EntityReturn, "return_ulid"))
+	r.GET("/returns/:return_ulid/attachments", auth.Allow(auth.Members...), h.list(audit.EntityReturn, "return_ulid"))
+	r.POST("/disposals/:disposal_ulid/attachments", auth.Allow(auth.StaffOnly...), h.upload(audit.EntityDisposal, "disposal_ulid"))
+	r.GET("/disposals/:disposal_ulid/attachments", auth.Allow(auth.StaffOnly...), h.list(audit.EntityDisposal, "disposal_ulid"))
+
+	// 添付単位
+	r.GET("/attachments/:attachment_ulid", auth.Allow(auth.Members...), h.GetAttachment)
+	r.GET("/attachments/:attachment_ulid/content", auth.Allow(auth.Members...), h.GetContent)
+	r.GET("/attachments/:attachment_ulid/thumbnail", auth.Allow(auth.Members...), h.GetThumbnail)
+	r.DELETE("/attachments/:attachment_ulid", auth.Allow(auth.StaffOnly...), h.DeleteAttachment)
+}
+
+// upload: multipart の file（必須）と kind / note
+func (h *Handler) upload(ownerType, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.svc.maxBytes+formOverhead)
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			apperr.Abort(c, uploadErr(err, h.svc.maxBytes))
+			return
+		}
+		var req UploadRequest
+		if err := c.ShouldBind(&req); err != nil {
+			apperr.Abort(c, apperr.Bind(err))
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		defer f.Close()
+
+		res, err := h.svc.Upload(c.Request.Context(), ownerType, c.Param(param), fh.Filename, f, req)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		c.Header("Location", "/attachments/"+res.AttachmentULID)
+		c.JSON(http.StatusCreated, res)
+	}
+}
+
+// list: ?kind= で分類を絞る
+func (h *Handler) list(ownerType, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := pagination.FromQuery(c)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		var kind *string
+		if v := c.Query("kind"); v != "" {
+			kind = &v
+		}
+		res, err := h.svc.List(c.Request.Context(), ownerType, c.Param(param), kind, req)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func (h *Handler) GetAttachment(c *gin.Context) {
+	res, err := h.svc.Get(c.Request.Context(), c.Param("attachment_ulid"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GetContent: 既定はダウンロード。?inline=true は画像・PDF のときだけ効く
+func (h *Handler) GetContent(c *gin.Context) {
+	inline := false
+	if v := c.Query("inline"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("inline must be true or false"))
+			return
+		}
+		inline = b
+	}
+
+	m, obj, err := h.svc.Open(c.Request.Context(), c.Param("attachment_ulid"), false)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	defer obj.Close()
+
+	disp := "attachment"
+	if inline && inlineSafe(m.ContentType) {
+		disp = "inline"
+	}
+	c.Header("Content-Type", m.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disp, map[string]string{"filename": m.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+m.SHA256+`"`)
+	http.ServeContent(c.Writer, c.Request, "", m.UploadedAt, obj)
+}
+
+func (h *Handler) GetThumbnail(c *gin.Context) {
+	m, obj, err := h.svc.Open(c.Request.Context(), c.Param("attachment_ulid"), true)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	defer obj.Close()
+
+	c.Header("Content-Type", "image/jpeg")
+	c.Header("Content-Disposition", "inline")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "", m.UploadedAt, obj)
+}
+
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("attachment_ulid")); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func uploadErr(err error, maxBytes int64) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return apperr.Invalid("file too large").WithDetail("max_bytes", maxBytes)
+	}
+	return apperr.Invalid("file is required (multipart field \"file\")")
+}
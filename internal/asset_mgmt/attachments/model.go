@@ -0,0 +1,24 @@
+package attachments
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DBモデル（attachments と1:1）
+type Attachment struct {
+	AttachmentID   uint64
+	AttachmentULID string
+	OwnerType      string // audit.Entity*（asset_master / asset / lend / disposal）
+	OwnerID        string
+	Kind           sql.NullString
+	FileName       string
+	ContentType    string
+	SizeBytes      int64
+	SHA256         string
+	StorageKey     string
+	ThumbnailKey   sql.NullString
+	Note           sql.NullString
+	UploadedByID   sql.NullString
+	UploadedAt     time.Time
+}
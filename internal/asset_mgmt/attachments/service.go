@@ -0,0 +1,330 @@
+package attachments
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/id"
+	"IRIS-backend/internal/platform/pagination"
+	"IRIS-backend/internal/platform/storage"
+)
+
+type Service struct {
+	db       *sql.DB
+	store    *Store
+	files    storage.Storage
+	maxBytes int64
+	ids      id.Generator
+	clock    id.Clock
+}
+
+func NewService(db *sql.DB, files storage.Storage, maxBytes int64) *Service {
+	return &Service{
+		db:       db,
+		store:    NewStore(db),
+		files:    files,
+		maxBytes: maxBytes,
+		ids:      id.NewULIDGen(),
+		clock:    id.RealClock{},
+	}
+}
+
+// Upload: 中身の先頭で種類を判定してからストレージに流し込み（上限を超えたら捨てる）、画像なら縮小版も作る。
+// メタデータの登録に失敗したらストレージ側も消す
+func (s *Service) Upload(ctx context.Context, ownerType, ownerID, fileName string, file io.Reader, in UploadRequest) (AttachmentResponse, error) {
+	if err := checkOwnerID(ownerType, ownerID); err != nil {
+		return AttachmentResponse{}, err
+	}
+	if err := s.store.OwnerExists(ctx, ownerType, ownerID); err == sql.ErrNoRows {
+		return AttachmentResponse{}, apperr.NotFound(ownerType + " not found")
+	} else if err != nil {
+		return AttachmentResponse{}, err
+	}
+
+	br := bufio.NewReaderSize(file, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return AttachmentResponse{}, err
+	}
+	if len(head) == 0 {
+		return AttachmentResponse{}, apperr.Invalid("file is empty")
+	}
+	fileName = cleanFileName(fileName)
+	ctype, ok := sniff(head, fileName)
+	if !ok {
+		return AttachmentResponse{}, apperr.Invalid("unsupported file type").WithDetail("detected_content_type", ctype)
+	}
+
+	now := s.clock.Now().Truncate(time.Second)
+	ul := s.ids.New()
+	m := &Attachment{
+		AttachmentULID: ul,
+		OwnerType:      ownerType,
+		OwnerID:        ownerID,
+		Kind:           toNullString(trimmed(in.Kind)),
+		FileName:       fileName,
+		ContentType:    ctype,
+		StorageKey:     ownerType + "/" + ul,
+		Note:           toNullString(trimmed(in.Note)),
+		UploadedByID:   toNullString(auth.ActorID(ctx)),
+		UploadedAt:     now,
+	}
+
+	h := sha256.New()
+	cr := &countingReader{r: io.TeeReader(io.LimitReader(br, s.maxBytes+1), h)}
+	if err := s.files.Put(ctx, m.StorageKey, cr); err != nil {
+		return AttachmentResponse{}, err
+	}
+	if cr.n > s.maxBytes {
+		s.discard(m)
+		return AttachmentResponse{}, apperr.Invalid("file too large").WithDetail("max_bytes", s.maxBytes)
+	}
+	m.SizeBytes, m.SHA256 = cr.n, hex.EncodeToString(h.Sum(nil))
+
+	if strings.HasPrefix(ctype, "image/") {
+		if key, err := s.putThumbnail(ctx, m.StorageKey); err != nil {
+			log.Printf("[WARN] thumbnail skipped for %s: %v", ul, err)
+		} else {
+			m.ThumbnailKey = sql.NullString{String: key, Valid: true}
+		}
+	}
+
+	var out AttachmentResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		var err error
+		if m.AttachmentID, err = st.Insert(ctx, m); err != nil {
+			return err
+		}
+		out = toResponse(*m)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityAttachment,
+			EntityID:   ul,
+			After:      out,
+		})
+	})
+	if err != nil {
+		s.discard(m)
+		return AttachmentResponse{}, err
+	}
+	return out, nil
+}
+
+// putThumbnail: 保存済みの画像を読み直して縮小版を key+".thumb.jpg" に置く
+func (s *Service) putThumbnail(ctx context.Context, key string) (string, error) {
+	obj, err := s.files.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+	data, err := makeThumbnail(obj)
+	if err != nil {
+		return "", err
+	}
+	tkey := key + ".thumb.jpg"
+	return tkey, s.files.Put(ctx, tkey, bytes.NewReader(data))
+}
+
+// discard: ストレージ上の実体を消す（失敗してもログだけ。孤立した実体は DB から辿れないだけで害はない）
+func (s *Service) discard(m *Attachment) {
+	ctx := context.Background()
+	keys := []string{m.StorageKey}
+	if m.ThumbnailKey.Valid {
+		keys = append(keys, m.ThumbnailKey.String)
+	}
+	for _, k := range keys {
+		if err := s.files.Delete(ctx, k); err != nil {
+			log.Printf("[WARN] failed to delete %s: %v", k, err)
+		}
+	}
+}
+
+// List: 親ごとの添付（登録順）
+func (s *Service) List(ctx context.Context, ownerType, ownerID string, kind *string, p pagination.Request) (pagination.Page[AttachmentResponse], error) {
+	if err := checkOwnerID(ownerType, ownerID); err != nil {
+		return pagination.Page[AttachmentResponse]{}, err
+	}
+	if err := s.store.OwnerExists(ctx, ownerType, ownerID); err == sql.ErrNoRows {
+		return pagination.Page[AttachmentResponse]{}, apperr.NotFound(ownerType + " not found")
+	} else if err != nil {
+		return pagination.Page[AttachmentResponse]{}, err
+	}
+	rows, err := s.store.ListByOwner(ctx, ownerType, ownerID, kind, p)
+	if err != nil {
+		return pagination.Page[AttachmentResponse]{}, err
+	}
+	page := pagination.Build(p, rows, func(last Attachment) string {
+		return pagination.Encode(listSort, last.AttachmentID)
+	})
+	return pagination.Map(page, toResponse), nil
+}
+
+func (s *Service) Get(ctx context.Context, ul string) (AttachmentResponse, error) {
+	m, err := s.get(ctx, ul)
+	if err != nil {
+		return AttachmentResponse{}, err
+	}
+	return toResponse(*m), nil
+}
+
+// Open: 実体（thumb なら縮小版）を開く。呼び出し側で Close する
+func (s *Service) Open(ctx context.Context, ul string, thumb bool) (*Attachment, storage.Object, error) {
+	m, err := s.get(ctx, ul)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := m.StorageKey
+	if thumb {
+		if !m.ThumbnailKey.Valid {
+			return nil, nil, apperr.NotFound("thumbnail not available")
+		}
+		key = m.ThumbnailKey.String
+	}
+	obj, err := s.files.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, apperr.NotFound("attachment content not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, obj, nil
+}
+
+// Delete: メタデータを消してから（コミット後に）実体を消す
+func (s *Service) Delete(ctx context.Context, ul string) error {
+	var removed *Attachment
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		m, err := st.LockByULID(ctx, ul)
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("attachment not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := st.Delete(ctx, m.AttachmentID); err != nil {
+			return err
+		}
+		removed = m
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityAttachment,
+			EntityID:   ul,
+			Before:     toResponse(*m),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.discard(removed)
+	return nil
+}
+
+// ---- helpers ----
+
+func (s *Service) get(ctx context.Context, ul string) (*Attachment, error) {
+	m, err := s.store.GetByULID(ctx, ul)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("attachment not found")
+	}
+	return m, err
+}
+
+// checkOwnerID: asset は asset_id（数値）
+func checkOwnerID(ownerType, ownerID string) error {
+	if ownerType == audit.EntityAsset {
+		if _, err := strconv.ParseUint(ownerID, 10, 64); err != nil {
+			return apperr.Invalid("asset_id must be a number")
+		}
+	}
+	return nil
+}
+
+// cleanFileName: パスを落とし、制御文字を除いて 255 バイトに収める
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+func trimmed(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
+
+func toResponse(m Attachment) AttachmentResponse {
+	base := "/attachments/" + m.AttachmentULID
+	out := AttachmentResponse{
+		AttachmentULID: m.AttachmentULID,
+		OwnerType:      m.OwnerType,
+		OwnerID:        m.OwnerID,
+		Kind:           nullToPtr(m.Kind),
+		FileName:       m.FileName,
+		ContentType:    m.ContentType,
+		SizeBytes:      m.SizeBytes,
+		SHA256:         m.SHA256,
+		Note:           nullToPtr(m.Note),
+		UploadedByID:   nullToPtr(m.UploadedByID),
+		UploadedAt:     m.UploadedAt,
+		ContentURL:     base + "/content",
+	}
+	if m.ThumbnailKey.Valid {
+		v := base + "/thumbnail"
+		out.ThumbnailURL = &v
+	}
+	return out
+}
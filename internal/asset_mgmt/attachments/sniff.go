@@ -0,0 +1,117 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // image.Decode で読めるようにする
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// 受け付ける種類（http.DetectContentType の結果）。zip は拡張子で Office 文書か判断する
+var allowedTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+var zipTypes = map[string]string{
+	".zip":  "application/zip",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// sniff: 先頭（最大 512 バイト）から種類を決める。クライアントの Content-Type は信用しない。
+// 受け付けない種類なら ok=false と判定結果を返す
+func sniff(head []byte, name string) (string, bool) {
+	ct := http.DetectContentType(head)
+	switch {
+	case allowedTypes[ct]:
+		return ct, true
+	case strings.HasPrefix(ct, "text/plain"):
+		return ct, true
+	case ct == "application/zip":
+		if v, ok := zipTypes[strings.ToLower(filepath.Ext(name))]; ok {
+			return v, true
+		}
+	}
+	return ct, false
+}
+
+// inlineSafe: ブラウザにそのまま表示させてよい種類（スクリプトを含み得るものは常にダウンロード）
+func inlineSafe(ct string) bool {
+	return ct == "image/jpeg" || ct == "image/png" || ct == "image/gif" || ct == "image/webp" || ct == "application/pdf"
+}
+
+// ---- 縮小版 ----
+
+const (
+	thumbSize      = 320        // 長辺
+	maxThumbPixels = 50_000_000 // これより大きい画像は縮小版を作らない（展開でメモリを使い切らないように）
+)
+
+var errTooLarge = errors.New("image too large for thumbnail")
+
+// makeThumbnail: 長辺 thumbSize 以下に平均画素法で縮め、白地に合成して JPEG にする（stdlib で読める jpeg/png/gif のみ）
+func makeThumbnail(r io.ReadSeeker) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxThumbPixels {
+		return nil, errTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > thumbSize || h > thumbSize {
+		if w >= h {
+			tw, th = thumbSize, max(1, h*thumbSize/w)
+		} else {
+			tw, th = max(1, w*thumbSize/h), thumbSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var sr, sg, sb, sa, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					sr, sg, sb, sa, n = sr+uint64(cr), sg+uint64(cg), sb+uint64(cb), sa+uint64(ca), n+1
+				}
+			}
+			// RGBA() は乗算済みアルファなので、白地との合成は「色 + (1 - α)」
+			bg := 0xffff - sa/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(sr/n + bg), G: uint16(sg/n + bg), B: uint16(sb/n + bg), A: 0xffff,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
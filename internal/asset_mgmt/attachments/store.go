@@ -0,0 +1,131 @@
+package attachments
+
+import (
+	"context"
+	"database/sql"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+// 添付できる親と、その存在確認の SQL（owner_id で1行引ければ存在する）
+var ownerQueries = map[string]string{
+	audit.EntityAssetMaster: `SELECT 1 FROM assets_master WHERE management_number = ?`,
+	audit.EntityAsset:       `SELECT 1 FROM assets WHERE asset_id = ?`,
+	audit.EntityLend:        `SELECT 1 FROM lends WHERE lend_ulid = ?`,
+	audit.EntityReturn:      `SELECT 1 FROM returns WHERE return_ulid = ?`,
+	audit.EntityDisposal:    `SELECT 1 FROM disposals WHERE disposal_ulid = ?`,
+}
+
+// OwnerExists: 見つからなければ sql.ErrNoRows
+func (s *Store) OwnerExists(ctx context.Context, ownerType, ownerID string) error {
+	var one int
+	return s.db.QueryRowContext(ctx, ownerQueries[ownerType], ownerID).Scan(&one)
+}
+
+func (s *Store) Insert(ctx context.Context, m *Attachment) (uint64, error) {
+	const q = `
+	INSERT INTO attachments
+	  (attachment_ulid, owner_type, owner_id, kind, file_name, content_type, size_bytes, sha256,
+	   storage_key, thumbnail_key, note, uploaded_by_id, uploaded_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, q,
+		m.AttachmentULID, m.OwnerType, m.OwnerID, m.Kind, m.FileName, m.ContentType, m.SizeBytes, m.SHA256,
+		m.StorageKey, m.ThumbnailKey, m.Note, m.UploadedByID, m.UploadedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+const selectAttachment = `
+	SELECT attachment_id, attachment_ulid, owner_type, owner_id, kind, file_name, content_type, size_bytes, sha256,
+		storage_key, thumbnail_key, note, uploaded_by_id, uploaded_at
+	FROM attachments`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanAttachment(sc scanner) (Attachment, error) {
+	var m Attachment
+	err := sc.Scan(&m.AttachmentID, &m.AttachmentULID, &m.OwnerType, &m.OwnerID, &m.Kind, &m.FileName, &m.ContentType,
+		&m.SizeBytes, &m.SHA256, &m.StorageKey, &m.ThumbnailKey, &m.Note, &m.UploadedByID, &m.UploadedAt)
+	return m, err
+}
+
+// GetByULID: 見つからなければ sql.ErrNoRows
+func (s *Store) GetByULID(ctx context.Context, ul string) (*Attachment, error) {
+	return s.get(ctx, selectAttachment+` WHERE attachment_ulid = ?`, ul)
+}
+
+func (s *Store) LockByULID(ctx context.Context, ul string) (*Attachment, error) {
+	return s.get(ctx, selectAttachment+` WHERE attachment_ulid = ? FOR UPDATE`, ul)
+}
+
+func (s *Store) get(ctx context.Context, q, ul string) (*Attachment, error) {
+	m, err := scanAttachment(s.db.QueryRowContext(ctx, q, ul))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+const listSort = "attachment_id:asc"
+
+// ListByOwner: 登録順。cursor の続きから p.Fetch() 件
+func (s *Store) ListByOwner(ctx context.Context, ownerType, ownerID string, kind *string, p pagination.Request) ([]Attachment, error) {
+	q := selectAttachment + ` WHERE owner_type = ? AND owner_id = ?`
+	args := []any{ownerType, ownerID}
+	if kind != nil {
+		q += ` AND kind = ?`
+		args = append(args, *kind)
+	}
+	var lastID uint64
+	ok, err := p.After(listSort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		q += ` AND attachment_id > ?`
+		args = append(args, lastID)
+	}
+	q += ` ORDER BY attachment_id LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Attachment
+	for rows.Next() {
+		m, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Delete(ctx context.Context, id uint64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM attachments WHERE attachment_id = ?`, id)
+	return err
+}
+
+func toNullString(s *string) (ns sql.NullString) {
+	if s != nil {
+		ns.Valid, ns.String = true, *s
+	}
+	return
+}
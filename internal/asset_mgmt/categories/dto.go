@@ -0,0 +1,25 @@
+package categories
+
+import "time"
+
+// ---- Requests ----
+
+// category_code は英大文字・数字のみ（ジャンルと同じ形式に揃える）
+type CreateCategoryRequest struct {
+	CategoryCode string `json:"category_code" binding:"required,max=16"`
+	CategoryName string `json:"category_name" binding:"required,max=100"`
+}
+
+type UpdateCategoryRequest struct {
+	CategoryCode *string `json:"category_code,omitempty" binding:"omitempty,max=16"`
+	CategoryName *string `json:"category_name,omitempty" binding:"omitempty,max=100"`
+}
+
+// ---- Responses ----
+
+type CategoryResponse struct {
+	ManagementCategoryID uint      `json:"management_category_id"`
+	CategoryCode         string    `json:"category_code"`
+	CategoryName         string    `json:"category_name"`
+	CreatedAt            time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package categories
+
+import "time"
+
+// DBモデル（management_categories と1:1）
+type Category struct {
+	ManagementCategoryID uint
+	CategoryCode         string
+	CategoryName         string
+	CreatedAt            time.Time
+}
@@ -0,0 +1,197 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9]{2,16}$`)
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, store: NewStore(db)}
+}
+
+// GET /management-categories
+func (s *Service) List(ctx context.Context) ([]CategoryResponse, error) {
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CategoryResponse, 0, len(items))
+	for _, g := range items {
+		out = append(out, toResponse(g))
+	}
+	return out, nil
+}
+
+// GET /management-categories/:management_category_id
+func (s *Service) Get(ctx context.Context, id uint) (CategoryResponse, error) {
+	g, err := s.store.Get(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return CategoryResponse{}, apperr.NotFound("management category not found")
+		}
+		return CategoryResponse{}, err
+	}
+	return toResponse(*g), nil
+}
+
+// POST /management-categories
+func (s *Service) Create(ctx context.Context, in CreateCategoryRequest) (CategoryResponse, error) {
+	code, name, err := normalize(&in.CategoryCode, &in.CategoryName)
+	if err != nil {
+		return CategoryResponse{}, err
+	}
+
+	var out CategoryResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		id, err := st.Insert(ctx, *code, *name)
+		if err != nil {
+			return mapWriteErr(err)
+		}
+		g, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*g)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityManagementCategory,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return CategoryResponse{}, err
+	}
+	return out, nil
+}
+
+// PUT /management-categories/:management_category_id
+func (s *Service) Update(ctx context.Context, id uint, in UpdateCategoryRequest) (CategoryResponse, error) {
+	code, name, err := normalize(in.CategoryCode, in.CategoryName)
+	if err != nil {
+		return CategoryResponse{}, err
+	}
+
+	var out CategoryResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("management category not found")
+			}
+			return err
+		}
+		if err := st.Update(ctx, id, code, name); err != nil {
+			return mapWriteErr(err)
+		}
+		after, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*after)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityManagementCategory,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return CategoryResponse{}, err
+	}
+	return out, nil
+}
+
+// DELETE /management-categories/:management_category_id（マスタから参照されている間は削除できない）
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("management category not found")
+			}
+			return err
+		}
+		n, err := st.CountMasters(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return apperr.Conflict("management category is referenced by asset masters").WithDetail("master_count", n)
+		}
+		if err := st.Delete(ctx, id); err != nil {
+			return mapWriteErr(err)
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityManagementCategory,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+		})
+	})
+}
+
+// ---- helpers ----
+
+// normalize: 前後の空白を除き、コードは大文字に寄せてから形式を検証する（nil はそのまま）
+func normalize(code, name *string) (*string, *string, error) {
+	if code != nil {
+		v := strings.ToUpper(strings.TrimSpace(*code))
+		if !codePattern.MatchString(v) {
+			return nil, nil, apperr.Invalid("validation failed").
+				WithField("category_code", "must be 2-16 characters of A-Z and 0-9")
+		}
+		code = &v
+	}
+	if name != nil {
+		v := strings.TrimSpace(*name)
+		if v == "" {
+			return nil, nil, apperr.Invalid("validation failed").WithField("category_name", "must not be empty")
+		}
+		name = &v
+	}
+	return code, name, nil
+}
+
+func mapWriteErr(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return apperr.Conflict("category_code already exists")
+		case 1451:
+			return apperr.Conflict("management category is referenced by asset masters")
+		}
+	}
+	return err
+}
+
+func toResponse(g Category) CategoryResponse {
+	return CategoryResponse{
+		ManagementCategoryID: g.ManagementCategoryID,
+		CategoryCode:         g.CategoryCode,
+		CategoryName:         g.CategoryName,
+		CreatedAt:            g.CreatedAt,
+	}
+}
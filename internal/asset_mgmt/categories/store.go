@@ -0,0 +1,95 @@
+package categories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+const selectCategory = `SELECT management_category_id, category_code, category_name, created_at FROM management_categories`
+
+func (s *Store) List(ctx context.Context) ([]Category, error) {
+	rows, err := s.db.QueryContext(ctx, selectCategory+` ORDER BY category_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Category
+	for rows.Next() {
+		var g Category
+		if err := rows.Scan(&g.ManagementCategoryID, &g.CategoryCode, &g.CategoryName, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// Get: 見つからなければ sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uint) (*Category, error) {
+	return s.get(ctx, selectCategory+` WHERE management_category_id = ?`, id)
+}
+
+func (s *Store) Lock(ctx context.Context, id uint) (*Category, error) {
+	return s.get(ctx, selectCategory+` WHERE management_category_id = ? FOR UPDATE`, id)
+}
+
+func (s *Store) get(ctx context.Context, q string, id uint) (*Category, error) {
+	var g Category
+	if err := s.db.QueryRowContext(ctx, q, id).Scan(&g.ManagementCategoryID, &g.CategoryCode, &g.CategoryName, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (s *Store) Insert(ctx context.Context, code, name string) (uint, error) {
+	const q = `INSERT INTO management_categories (category_code, category_name, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	res, err := s.db.ExecContext(ctx, q, code, name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func (s *Store) Update(ctx context.Context, id uint, code, name *string) error {
+	sets := []string{}
+	args := []any{}
+	if code != nil {
+		sets = append(sets, "category_code = ?")
+		args = append(args, *code)
+	}
+	if name != nil {
+		sets = append(sets, "category_name = ?")
+		args = append(args, *name)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE management_categories SET %s WHERE management_category_id = ?`, strings.Join(sets, ", "))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, id uint) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM management_categories WHERE management_category_id = ?`, id)
+	return err
+}
+
+// CountMasters: この管理区分を参照している assets_master の件数
+func (s *Store) CountMasters(ctx context.Context, id uint) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM assets_master WHERE management_category_id = ?`, id).Scan(&n)
+	return n, err
+}
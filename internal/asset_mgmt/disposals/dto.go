@@ -1,13 +1,19 @@
 package disposals
 
-import "time"
+import (
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/platform/pagination"
+)
 
 // ---- Requests ----
 
 type CreateDisposalRequest struct {
-	Quantity      uint    `json:"quantity" binding:"required"` // >0
-	Reason        *string `json:"reason,omitempty"`
-	ProcessedByID *string `json:"processed_by_id,omitempty"`
+	Quantity uint    `json:"quantity" binding:"required"` // >0
+	Reason   *string `json:"reason,omitempty"`
+	AssetID  *uint64 `json:"asset_id,omitempty"` // 在庫行を指定して廃棄する場合（省略時は asset_id 順に割り当て）
+	// processed_by_id は認証済みの呼び出し元で埋める
 }
 
 // ---- Responses ----
@@ -19,14 +25,15 @@ type DisposalResponse struct {
 	Reason           *string   `json:"reason,omitempty"`
 	ProcessedByID    *string   `json:"processed_by_id,omitempty"`
 	DisposedAt       time.Time `json:"disposed_at"`
+	// どの在庫行から何個廃棄したか（作成時のみ）
+	Allocations []stock.Allocation `json:"allocations,omitempty"`
 }
 
 // ---- List payload ----
 
 type Page struct {
-	Limit  int
-	Offset int
-	Order  string // "asc" or "desc"
+	pagination.Request
+	Order string // "asc" or "desc"
 }
 
 type DisposalFilter struct {
@@ -3,10 +3,14 @@ package disposals
 import (
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/idempotency"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type Handler struct{ svc *Service }
@@ -14,26 +18,26 @@ type Handler struct{ svc *Service }
 func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 	// 登録
-	r.POST("/assets/:management_number/disposals", h.CreateDisposal) //OK
+	r.POST("/assets/:management_number/disposals", auth.Allow(auth.AdminOnly...), idempotency.Middleware(svc.db), h.CreateDisposal) //OK
 	// 参照
-	r.GET("/disposals", h.ListDisposals)              //OK
-	r.GET("/disposals/:disposal_ulid", h.GetDisposal) //OK
+	r.GET("/disposals", auth.Allow(auth.StaffOnly...), h.ListDisposals)              //OK
+	r.GET("/disposals/:disposal_ulid", auth.Allow(auth.StaffOnly...), h.GetDisposal) //OK
 }
 
 func (h *Handler) CreateDisposal(c *gin.Context) {
 	mng := c.Param("management_number")
 	var req CreateDisposalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorBody(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	log.Printf("CreateDisposal called with management_number: %s, quantity: %d,  reason: %+v, processed_by_id: %+v",
-		mng, req.Quantity, req.Reason, req.ProcessedByID)
+		mng, req.Quantity, req.Reason, auth.ActorID(c.Request.Context()))
 
 	res, err := h.svc.CreateDisposal(c.Request.Context(), mng, req)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.Header("Location", "/disposals/"+res.DisposalULID)
@@ -44,7 +48,7 @@ func (h *Handler) GetDisposal(c *gin.Context) {
 	ul := c.Param("disposal_ulid")
 	res, err := h.svc.GetDisposalByULID(c.Request.Context(), ul)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -68,49 +72,16 @@ func (h *Handler) ListDisposals(c *gin.Context) {
 			f.To = &t
 		}
 	}
-	p := Page{
-		Limit:  parseIntDefault(c.Query("limit"), 50),
-		Offset: parseIntDefault(c.Query("offset"), 0),
-		Order:  c.DefaultQuery("order", "desc"),
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", "desc")}
 	res, err := h.svc.ListDisposals(c.Request.Context(), f, p)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
-
-// ---- helpers ----
-
-func parseIntDefault(s string, d int) int {
-	if s == "" {
-		return d
-	}
-	v, err := strconv.Atoi(s)
-	if err != nil {
-		return d
-	}
-	return v
-}
-
-type errorDTO struct {
-	Error struct {
-		Code    Code   `json:"code"`
-		Message string `json:"message"`
-	} `json:"error"`
-}
-
-func errorBody(code Code, msg string) errorDTO {
-	var e errorDTO
-	e.Error.Code = code
-	e.Error.Message = msg
-	return e
-}
-func errorFromErr(err error) errorDTO {
-	msg := err.Error()
-	if api, ok := err.(*APIError); ok {
-		return errorBody(api.Code, api.Message)
-	}
-	return errorBody(CodeInternal, msg)
-}
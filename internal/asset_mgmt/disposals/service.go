@@ -4,43 +4,31 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
-	"errors"
-	"fmt"
+	"log"
+	"strconv"
 	"strings"
 	"time"
-	"log"
 
 	ulid "github.com/oklog/ulid/v2"
-)
-
-// ---- Error model ----
-type Code string
 
-const (
-	CodeInvalidArgument Code = "INVALID_ARGUMENT"
-	CodeNotFound        Code = "NOT_FOUND"
-	CodeConflict        Code = "CONFLICT"
-	CodeInternal        Code = "INTERNAL"
+	"IRIS-backend/internal/asset_mgmt/reservations"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
 )
 
-type APIError struct {
-	Code    Code
-	Message string
-}
-
-func (e *APIError) Error() string { return fmt.Sprintf("%s: %s", e.Code, e.Message) }
-func ErrInvalid(msg string) *APIError  { return &APIError{Code: CodeInvalidArgument, Message: msg} }
-func ErrNotFound(msg string) *APIError { return &APIError{Code: CodeNotFound, Message: msg} }
-func ErrConflict(msg string) *APIError { return &APIError{Code: CodeConflict, Message: msg} }
-func ErrInternal(msg string) *APIError { return &APIError{Code: CodeInternal, Message: msg} }
-
 // ---- Clock & ID ----
 type Clock interface{ Now() time.Time }
 type realClock struct{}
+
 func (realClock) Now() time.Time { return time.Now().UTC() }
 
 type IDGen interface{ NewULID(t time.Time) string }
 type ulidGen struct{}
+
 func (ulidGen) NewULID(t time.Time) string {
 	entropy := ulid.Monotonic(rand.Reader, 0)
 	return ulid.MustNew(ulid.Timestamp(t), entropy).String()
@@ -49,24 +37,28 @@ func (ulidGen) NewULID(t time.Time) string {
 // ---- Service ----
 
 type Service struct {
-	db    *sql.DB
-	store *Store
-	clock Clock
-	id    IDGen
+	db           *sql.DB
+	store        *Store
+	reservations *reservations.Service
+	clock        Clock
+	id           IDGen
 }
 
-func NewService(db *sql.DB) *Service {
+func NewService(db *sql.DB, rsv *reservations.Service) *Service {
 	return &Service{
-		db:    db,
-		store: NewStore(db),
-		clock: realClock{},
-		id:    ulidGen{},
+		db:           db,
+		store:        NewStore(db),
+		reservations: rsv,
+		clock:        realClock{},
+		id:           ulidGen{},
 	}
 }
 
 func (s *Service) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
 	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
-	if err != nil { return err }
+	if err != nil {
+		return err
+	}
 	if err := fn(tx); err != nil {
 		_ = tx.Rollback()
 		return err
@@ -77,39 +69,65 @@ func (s *Service) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
 // POST /assets/:management_number/disposals
 func (s *Service) CreateDisposal(ctx context.Context, managementNumber string, in CreateDisposalRequest) (DisposalResponse, error) {
 	if in.Quantity == 0 {
-		return DisposalResponse{}, ErrInvalid("quantity must be > 0")
+		return DisposalResponse{}, apperr.Invalid("quantity must be > 0")
 	}
 	now := s.clock.Now()
 	duid := s.id.NewULID(now)
+	processedBy := auth.ActorID(ctx)
 
-	var resp DisposalResponse
+	var (
+		resp  DisposalResponse
+		ready []reservations.Reservation
+	)
 	err := s.withTx(ctx, func(tx *sql.Tx) error {
 		// master解決
 		masterID, err := s.store.ResolveMasterID(ctx, managementNumber)
-		if err != nil { return err }
+		if err != nil {
+			return err
+		}
 
-		// 在庫ロック & チェック
-		assetID, qty, err := s.store.LockAssetRow(ctx, tx, masterID) // SELECT ... FOR UPDATE
-		if err != nil { return err }
-		if int(qty) - int(in.Quantity) < 0 {
-			return ErrConflict("insufficient stock")
+		// 在庫ロック & 割り当て（asset_id 指定時はその行のみ）
+		var rows []stock.Row
+		if in.AssetID != nil {
+			r, err := stock.LockRow(ctx, tx, masterID, *in.AssetID)
+			if err != nil {
+				return err
+			}
+			rows = []stock.Row{r}
+		} else if rows, err = stock.LockRows(ctx, tx, masterID); err != nil {
+			return err
+		}
+		allocs, err := stock.Allocate(rows, in.Quantity, nil)
+		if err != nil {
+			return err
+		}
+		before := make(map[uint64]stock.Row, len(rows))
+		for _, r := range rows {
+			before[r.AssetID] = r
 		}
-		// log.Printf("Locked assetID: %d with quantity: %d", assetID, qty)
 
-		// 在庫減算
-		if err := s.store.UpdateAssetQuantity(ctx, tx, assetID, -int(in.Quantity)); err != nil {
+		// 予約の取り置き分は廃棄で食いつぶさない（在庫行のロックの後に待ち行列をロックする）
+		if ready, err = s.reservations.Promote(ctx, tx, masterID); err != nil {
+			return err
+		}
+		claim, err := s.reservations.Claim(ctx, tx, masterID, "")
+		if err != nil {
+			return err
+		}
+		if err := checkHeld(ctx, tx, masterID, allocs, before, claim.HeldByOthers); err != nil {
 			return err
 		}
-		// log.Printf("Updated assetID: %d quantity by %d", assetID, -int(in.Quantity))
 
-		// 減算後が0ならだけステータス変更
-		newQty := int(qty) - int(in.Quantity)
-		if newQty == 0 {
-			const StatusZeroStock = 5
-			if err := s.store.UpdateAssetStatus(ctx, tx, assetID, StatusZeroStock); err != nil {
-				log.Printf("Failed to update asset status: %v", err)
+		// 在庫減算。減算後が0になった行だけステータスを廃棄済に
+		for _, a := range allocs {
+			if err := stock.Move(ctx, tx, a.AssetID, -int(a.Quantity)); err != nil {
 				return err
 			}
+			if before[a.AssetID].Quantity == a.Quantity {
+				if err := stock.SetStatus(ctx, tx, before[a.AssetID], statuses.Disposed); err != nil {
+					return err
+				}
+			}
 		}
 
 		// 廃棄挿入
@@ -118,30 +136,99 @@ func (s *Service) CreateDisposal(ctx context.Context, managementNumber string, i
 			ManagementNumber: managementNumber,
 			Quantity:         in.Quantity,
 			Reason:           toNullString(in.Reason),
-			ProcessedByID:    toNullString(in.ProcessedByID),
+			ProcessedByID:    toNullString(processedBy),
 		}
-		if _, err := s.store.InsertDisposal(ctx, tx, m); err != nil {
+		disposalID, err := s.store.InsertDisposal(ctx, tx, m)
+		if err != nil {
 			log.Printf("Failed to insert disposal record: %v", err)
 			return err
 		}
+		if err := s.store.InsertDisposalAllocations(ctx, tx, disposalID, allocs); err != nil {
+			return err
+		}
 
 		resp = DisposalResponse{
 			DisposalULID:     duid,
 			ManagementNumber: managementNumber,
 			Quantity:         in.Quantity,
 			Reason:           in.Reason,
-			ProcessedByID:    in.ProcessedByID,
+			ProcessedByID:    processedBy,
 			DisposedAt:       now,
+			Allocations:      allocs,
+		}
+
+		// 監査ログ（廃棄レコードと在庫行の変化）
+		if err := audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityDisposal,
+			EntityID:   duid,
+			After:      resp,
+		}); err != nil {
+			return err
+		}
+		for _, a := range allocs {
+			after, err := stock.Snapshot(ctx, tx, a.AssetID)
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, tx, audit.Entry{
+				Action:     audit.ActionDispose,
+				EntityType: audit.EntityAsset,
+				EntityID:   strconv.FormatUint(a.AssetID, 10),
+				Before:     before[a.AssetID],
+				After:      after,
+			}); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
-	return resp, err
+	if err != nil {
+		return DisposalResponse{}, err
+	}
+	s.reservations.Notify(ctx, ready)
+	return resp, nil
 }
 
+// checkHeld: 廃棄後も、貸出に回せる在庫が取り置き中の数を下回らないこと。
+// 修理中など貸出に回せない行からの廃棄は取り置きに影響しない
+func checkHeld(ctx context.Context, tx *sql.Tx, masterID uint64, allocs []stock.Allocation, before map[uint64]stock.Row, held uint) error {
+	if held == 0 {
+		return nil
+	}
+	rows, err := stock.Rows(ctx, tx, masterID)
+	if err != nil {
+		return err
+	}
+	g, err := statuses.LoadGraph(ctx, tx)
+	if err != nil {
+		return err
+	}
+	lendable := stock.Lendable(g)
+	var disposed uint
+	for _, a := range allocs {
+		if lendable(before[a.AssetID]) {
+			disposed += a.Quantity
+		}
+	}
+	total := stock.Total(rows, lendable)
+	if total < held+disposed {
+		var free uint
+		if total > held {
+			free = total - held
+		}
+		return apperr.Conflict("units are held for reservations").
+			WithDetail("available", free).
+			WithDetail("reserved", held)
+	}
+	return nil
+}
 
 func (s *Service) GetDisposalByULID(ctx context.Context, ul string) (DisposalResponse, error) {
 	m, err := s.store.GetByULID(ctx, ul)
-	if err != nil { return DisposalResponse{}, err }
+	if err != nil {
+		return DisposalResponse{}, err
+	}
 	return DisposalResponse{
 		DisposalULID:     m.DisposalULID,
 		ManagementNumber: m.ManagementNumber,
@@ -152,29 +239,25 @@ func (s *Service) GetDisposalByULID(ctx context.Context, ul string) (DisposalRes
 	}, nil
 }
 
-type ListResult struct {
-	Items      []DisposalResponse `json:"items"`
-	Total      int64              `json:"total"`
-	NextOffset int                `json:"next_offset"`
-}
-
-func (s *Service) ListDisposals(ctx context.Context, f DisposalFilter, p Page) (ListResult, error) {
-	rows, total, err := s.store.List(ctx, f, p)
-	if err != nil { return ListResult{}, err }
-	items := make([]DisposalResponse, 0, len(rows))
-	for _, m := range rows {
-		items = append(items, DisposalResponse{
+func (s *Service) ListDisposals(ctx context.Context, f DisposalFilter, p Page) (pagination.Page[DisposalResponse], error) {
+	rows, err := s.store.List(ctx, f, p)
+	if err != nil {
+		return pagination.Page[DisposalResponse]{}, err
+	}
+	sort, _ := listOrder(p)
+	page := pagination.Build(p.Request, rows, func(last Disposal) string {
+		return pagination.Encode(sort, last.DisposedAt, last.DisposalID)
+	})
+	return pagination.Map(page, func(m Disposal) DisposalResponse {
+		return DisposalResponse{
 			DisposalULID:     m.DisposalULID,
 			ManagementNumber: m.ManagementNumber,
 			Quantity:         m.Quantity,
 			Reason:           nullToPtr(m.Reason),
 			ProcessedByID:    nullToPtr(m.ProcessedByID),
 			DisposedAt:       m.DisposedAt,
-		})
-	}
-	next := p.Offset + p.Limit
-	if next >= int(total) { next = 0 }
-	return ListResult{Items: items, Total: total, NextOffset: next}, nil
+		}
+	}), nil
 }
 
 // ---- helpers ----
@@ -191,20 +274,3 @@ func nullToPtr(ns sql.NullString) *string {
 	}
 	return nil
 }
-
-func ToHTTPStatus(err error) int {
-	var api *APIError
-	if errors.As(err, &api) {
-		switch api.Code {
-		case CodeInvalidArgument:
-			return 400
-		case CodeNotFound:
-			return 404
-		case CodeConflict:
-			return 409
-		default:
-			return 500
-		}
-	}
-	return 500
-}
@@ -3,10 +3,12 @@ package disposals
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"log"
 	"strings"
-	"errors"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type Store struct{ db *sql.DB }
@@ -20,78 +22,13 @@ func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (u
 	var id uint64
 	if err := s.db.QueryRowContext(ctx, q, managementNumber).Scan(&id); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, ErrNotFound("assets_master not found")
+			return 0, apperr.NotFound("assets_master not found")
 		}
 		return 0, err
 	}
 	return id, nil
 }
 
-func (s *Store) LockAssetRow(ctx context.Context, tx *sql.Tx, masterID uint64) (assetID uint64, quantity uint, err error) {
-	const q = `SELECT asset_id, quantity FROM assets WHERE asset_master_id = ? LIMIT 1 FOR UPDATE`
-	row := tx.QueryRowContext(ctx, q, masterID)
-	if err = row.Scan(&assetID, &quantity); err != nil {
-		if err == sql.ErrNoRows {
-			return 0, 0, ErrNotFound("asset row not found")
-		}
-		return 0, 0, err
-	}
-	return assetID, quantity, nil
-}
-
-func (s *Store) UpdateAssetQuantity(ctx context.Context, tx *sql.Tx, assetID uint64, delta int) error {
-	const q = `UPDATE assets SET quantity = quantity + ? WHERE asset_id = ?`
-	res, err := tx.ExecContext(ctx, q, delta, assetID)
-	if err != nil {
-		return err
-	}
-	if aff, _ := res.RowsAffected(); aff != 1 {
-		return ErrInternal("failed to update assets.quantity")
-	}
-	return nil
-}
-
-func (s *Store) UpdateAssetStatus(ctx context.Context, tx *sql.Tx, assetID uint64, statusID int) error {
-	const q = `
-		UPDATE assets
-		SET status_id = ?
-		WHERE asset_id = ?
-		AND quantity = 0`
-	res, err := tx.ExecContext(ctx, q, statusID, assetID)
-	if err != nil {
-		log.Printf("Error executing UpdateAssetStatus: %v", err)
-		return err
-	}
-	aff, err := res.RowsAffected()
-	if err != nil {
-		log.Printf("Error getting affected rows: %v", err)
-		return err
-	}
-	if aff == 1 {
-		return nil // 変更あり
-	}
-
-	// 0件の理由を切り分け（冪等成功 or 前提崩れ）
-	var curQty, curStatus int
-	err = tx.QueryRowContext(ctx,
-		`SELECT quantity, status_id FROM assets WHERE asset_id = ? FOR UPDATE`,
-		assetID,
-	).Scan(&curQty, &curStatus)
-	if errors.Is(err, sql.ErrNoRows) {
-		return ErrNotFound("asset")
-	}
-	if err != nil {
-		return err
-	}
-	if curQty != 0 {
-		return fmt.Errorf("precondition failed: quantity must be 0 (got %d)", curQty)
-	}
-	if curStatus == statusID {
-		return nil // 変更不要（冪等成功）
-	}
-	return ErrInternal("failed to update assets.status")
-}
-
 // --- disposals ---
 
 func (s *Store) InsertDisposal(ctx context.Context, tx *sql.Tx, m *Disposal) (uint64, error) {
@@ -111,6 +48,16 @@ func (s *Store) InsertDisposal(ctx context.Context, tx *sql.Tx, m *Disposal) (ui
 	return uint64(id), nil
 }
 
+func (s *Store) InsertDisposalAllocations(ctx context.Context, tx *sql.Tx, disposalID uint64, allocs []stock.Allocation) error {
+	const q = `INSERT INTO disposal_allocations (disposal_id, asset_id, quantity) VALUES (?, ?, ?)`
+	for _, a := range allocs {
+		if _, err := tx.ExecContext(ctx, q, disposalID, a.AssetID, a.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Store) GetByULID(ctx context.Context, ul string) (*Disposal, error) {
 	const q = `
 	SELECT disposal_id, disposal_ulid, management_number, quantity, disposed_at, reason, processed_by_id
@@ -121,14 +68,25 @@ func (s *Store) GetByULID(ctx context.Context, ul string) (*Disposal, error) {
 		&m.DisposedAt, &m.Reason, &m.ProcessedByID,
 	); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, ErrNotFound("disposal not found")
+			return nil, apperr.NotFound("disposal not found")
 		}
 		return nil, err
 	}
 	return &m, nil
 }
 
-func (s *Store) List(ctx context.Context, f DisposalFilter, p Page) ([]Disposal, int64, error) {
+// listOrder: 一覧の並び（disposed_at, disposal_id）と cursor の識別子
+func listOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "disposed_at:asc"
+	if desc {
+		sort = "disposed_at:desc"
+	}
+	return sort, []pagination.Column{{Expr: "disposed_at", Desc: desc}, {Expr: "disposal_id", Desc: desc}}
+}
+
+// List: cursor の続きから p.Fetch() 件
+func (s *Store) List(ctx context.Context, f DisposalFilter, p Page) ([]Disposal, error) {
 	sb := strings.Builder{}
 	sb.WriteString(`
 	SELECT disposal_id, disposal_ulid, management_number, quantity, disposed_at, reason, processed_by_id
@@ -152,22 +110,26 @@ func (s *Store) List(ctx context.Context, f DisposalFilter, p Page) ([]Disposal,
 		args = append(args, *f.To)
 	}
 
-	order := "DESC"
-	if strings.ToLower(p.Order) == "asc" {
-		order = "ASC"
-	}
-	if p.Limit <= 0 {
-		p.Limit = 50
+	sort, cols := listOrder(p)
+	var (
+		lastAt time.Time
+		lastID uint64
+	)
+	ok, err := p.After(sort, &lastAt, &lastID)
+	if err != nil {
+		return nil, err
 	}
-	if p.Offset < 0 {
-		p.Offset = 0
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastAt, lastID})
+		sb.WriteString(` AND ` + cond)
+		args = append(args, seekArgs...)
 	}
-	sb.WriteString(fmt.Sprintf(` ORDER BY disposed_at %s LIMIT ? OFFSET ?`, order))
-	args = append(args, p.Limit, p.Offset)
+	sb.WriteString(` ORDER BY ` + pagination.OrderBy(cols) + ` LIMIT ?`)
+	args = append(args, p.Fetch())
 
 	rows, err := s.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -175,39 +137,11 @@ func (s *Store) List(ctx context.Context, f DisposalFilter, p Page) ([]Disposal,
 	for rows.Next() {
 		var m Disposal
 		if err := rows.Scan(&m.DisposalID, &m.DisposalULID, &m.ManagementNumber, &m.Quantity, &m.DisposedAt, &m.Reason, &m.ProcessedByID); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		items = append(items, m)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
-
-	// count
-	cb := strings.Builder{}
-	cb.WriteString(`SELECT COUNT(*) FROM disposals WHERE 1=1`)
-	argsC := []any{}
-	if f.ManagementNumber != nil {
-		cb.WriteString(` AND management_number = ?`)
-		argsC = append(argsC, *f.ManagementNumber)
-	}
-	if f.ProcessedByID != nil {
-		cb.WriteString(` AND processed_by_id = ?`)
-		argsC = append(argsC, *f.ProcessedByID)
-	}
-	if f.From != nil {
-		cb.WriteString(` AND disposed_at >= ?`)
-		argsC = append(argsC, *f.From)
-	}
-	if f.To != nil {
-		cb.WriteString(` AND disposed_at < ?`)
-		argsC = append(argsC, *f.To)
-	}
-	var total int64
-	if err := s.db.QueryRowContext(ctx, cb.String(), argsC...).Scan(&total); err != nil {
-		return nil, 0, err
-	}
-	return items, total, nil
+	return items, rows.Err()
 }
 
 func nullStrOrNil(ns sql.NullString) any {
@@ -0,0 +1,221 @@
+package genres
+
+import (
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+// 属性キーはクエリパラメータ（attr.<key>）にもそのまま使う
+var attrKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// 値の上限（asset_master_attributes.value_text）
+const maxValueLen = 255
+
+// Value: 検証・正規化済みの属性値。Text は表示・一致検索用の正規形、Number / Date は範囲検索用
+type Value struct {
+	Text   string
+	Number *float64
+	Date   *time.Time
+}
+
+// JSON: レスポンスに載せる値（string / number / "YYYY-MM-DD" / bool）
+func (v Value) JSON(typ string) any {
+	switch typ {
+	case TypeNumber:
+		if v.Number != nil {
+			return *v.Number
+		}
+	case TypeBool:
+		return v.Text == "true"
+	}
+	return v.Text
+}
+
+// ParseValue: JSON の値（または CSV・クエリの文字列）を属性の型に合わせて正規化する
+func ParseValue(a Attribute, raw any) (Value, string) {
+	s, isString := raw.(string)
+	if isString {
+		s = strings.TrimSpace(s)
+	}
+	switch a.Type {
+	case TypeString, TypeEnum:
+		if !isString {
+			return Value{}, "must be a string"
+		}
+		if s == "" {
+			return Value{}, "must not be empty"
+		}
+		if utf8.RuneCountInString(s) > maxValueLen {
+			return Value{}, "must be at most 255 characters"
+		}
+		if a.Type == TypeEnum && !slices.Contains(a.EnumValues, s) {
+			return Value{}, "must be one of: " + strings.Join(a.EnumValues, ", ")
+		}
+		return Value{Text: s}, ""
+	case TypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return Value{}, "must be a number"
+			}
+			n = f
+		default:
+			return Value{}, "must be a number"
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) || math.Abs(n) >= 1e14 {
+			return Value{}, "must be a finite number below 1e14"
+		}
+		return Value{Text: strconv.FormatFloat(n, 'f', -1, 64), Number: &n}, ""
+	case TypeDate:
+		if !isString {
+			return Value{}, "must be a date (YYYY-MM-DD)"
+		}
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return Value{}, "must be a date (YYYY-MM-DD)"
+		}
+		return Value{Text: t.Format("2006-01-02"), Date: &t}, ""
+	case TypeBool:
+		var b bool
+		switch v := raw.(type) {
+		case bool:
+			b = v
+		case string:
+			pb, err := strconv.ParseBool(s)
+			if err != nil {
+				return Value{}, "must be true or false"
+			}
+			b = pb
+		default:
+			return Value{}, "must be true or false"
+		}
+		n := 0.0
+		if b {
+			n = 1
+		}
+		return Value{Text: strconv.FormatBool(b), Number: &n}, ""
+	}
+	return Value{}, "has an unknown type"
+}
+
+// ValidateValues: 属性値をジャンルの定義で検証する。null は未指定と同じ扱い。
+// エラーは attributes.<key> の項目エラーにまとめて返す
+func ValidateValues(defs []Attribute, in map[string]any) (map[string]Value, error) {
+	byKey := make(map[string]Attribute, len(defs))
+	for _, a := range defs {
+		byKey[a.Key] = a
+	}
+
+	out := make(map[string]Value, len(in))
+	verr := apperr.Invalid("validation failed")
+	for _, k := range slices.Sorted(maps.Keys(in)) {
+		raw := in[k]
+		a, ok := byKey[k]
+		if !ok {
+			if raw != nil {
+				verr = verr.WithField("attributes."+k, "is not defined for this genre")
+			}
+			continue
+		}
+		if raw == nil {
+			continue
+		}
+		v, msg := ParseValue(a, raw)
+		if msg != "" {
+			verr = verr.WithField("attributes."+k, msg)
+			continue
+		}
+		out[k] = v
+	}
+	for _, a := range defs {
+		if _, ok := out[a.Key]; a.Required && !ok {
+			verr = verr.WithField("attributes."+a.Key, "is required")
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return out, nil
+}
+
+// normalizeDefs: 定義の形式を検証し、並び順を振る
+func normalizeDefs(genreID uint, in []AttributeDef) ([]Attribute, error) {
+	out := make([]Attribute, 0, len(in))
+	seen := map[string]bool{}
+	verr := apperr.Invalid("validation failed")
+	for i, d := range in {
+		field := "attributes[" + strconv.Itoa(i) + "]"
+		key := strings.TrimSpace(d.Key)
+		if !attrKeyPattern.MatchString(key) {
+			verr = verr.WithField(field+".key", "must be 1-32 characters of a-z, 0-9 and _ starting with a letter")
+			continue
+		}
+		if seen[key] {
+			verr = verr.WithField(field+".key", "duplicates '"+key+"'")
+			continue
+		}
+		seen[key] = true
+
+		label := strings.TrimSpace(d.Label)
+		if label == "" {
+			verr = verr.WithField(field+".label", "must not be empty")
+		}
+		var enum []string
+		if d.Type == TypeEnum {
+			opts := map[string]bool{}
+			for _, v := range d.EnumValues {
+				v = strings.TrimSpace(v)
+				if v == "" || opts[v] {
+					verr = verr.WithField(field+".enum_values", "must be non-empty and unique")
+					break
+				}
+				opts[v] = true
+				enum = append(enum, v)
+			}
+			if len(enum) == 0 {
+				verr = verr.WithField(field+".enum_values", "is required for enum")
+			}
+		} else if len(d.EnumValues) > 0 {
+			verr = verr.WithField(field+".enum_values", "is only allowed for enum")
+		}
+		out = append(out, Attribute{
+			GenreID:    genreID,
+			Key:        key,
+			Label:      label,
+			Type:       d.Type,
+			Required:   d.Required,
+			EnumValues: enum,
+			SortOrder:  uint(i),
+		})
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return out, nil
+}
+
+func toAttributeDefs(attrs []Attribute) []AttributeDef {
+	out := make([]AttributeDef, 0, len(attrs))
+	for _, a := range attrs {
+		out = append(out, AttributeDef{
+			Key:        a.Key,
+			Label:      a.Label,
+			Type:       a.Type,
+			Required:   a.Required,
+			EnumValues: a.EnumValues,
+		})
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package genres
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseValue(t *testing.T) {
+	str := Attribute{Key: "color", Type: TypeString}
+	num := Attribute{Key: "watts", Type: TypeNumber}
+	enum := Attribute{Key: "size", Type: TypeEnum, EnumValues: []string{"S", "M", "L"}}
+	date := Attribute{Key: "warranty", Type: TypeDate}
+	boolean := Attribute{Key: "wireless", Type: TypeBool}
+
+	tests := []struct {
+		name     string
+		attr     Attribute
+		raw      any
+		wantText string
+		wantNum  *float64
+		wantDate string // "YYYY-MM-DD"。空なら Date は nil
+		wantMsg  string
+	}{
+		{name: "string is trimmed", attr: str, raw: "  黒 ", wantText: "黒"},
+		{name: "string must be a string", attr: str, raw: 1.0, wantMsg: "must be a string"},
+		{name: "string must not be empty", attr: str, raw: "   ", wantMsg: "must not be empty"},
+		{name: "string at the limit", attr: str, raw: strings.Repeat("あ", maxValueLen), wantText: strings.Repeat("あ", maxValueLen)},
+		{name: "string over the limit", attr: str, raw: strings.Repeat("あ", maxValueLen+1), wantMsg: "must be at most 255 characters"},
+
+		{name: "enum member", attr: enum, raw: "M", wantText: "M"},
+		{name: "enum non-member", attr: enum, raw: "XL", wantMsg: "must be one of: S, M, L"},
+		{name: "enum is case sensitive", attr: enum, raw: "m", wantMsg: "must be one of: S, M, L"},
+
+		{name: "number from json", attr: num, raw: 12.5, wantText: "12.5", wantNum: ptr(12.5)},
+		{name: "number from string", attr: num, raw: " 100 ", wantText: "100", wantNum: ptr(100)},
+		{name: "number normalizes exponent", attr: num, raw: "1e3", wantText: "1000", wantNum: ptr(1000)},
+		{name: "negative number", attr: num, raw: -0.5, wantText: "-0.5", wantNum: ptr(-0.5)},
+		{name: "number not numeric", attr: num, raw: "abc", wantMsg: "must be a number"},
+		{name: "number wrong type", attr: num, raw: true, wantMsg: "must be a number"},
+		{name: "number NaN", attr: num, raw: math.NaN(), wantMsg: "must be a finite number below 1e14"},
+		{name: "number infinite", attr: num, raw: "Inf", wantMsg: "must be a finite number below 1e14"},
+		{name: "number too large", attr: num, raw: 1e14, wantMsg: "must be a finite number below 1e14"},
+
+		{name: "date", attr: date, raw: "2025-04-01", wantText: "2025-04-01", wantDate: "2025-04-01"},
+		{name: "date with time", attr: date, raw: "2025-04-01T00:00:00Z", wantMsg: "must be a date (YYYY-MM-DD)"},
+		{name: "date out of range", attr: date, raw: "2025-02-30", wantMsg: "must be a date (YYYY-MM-DD)"},
+		{name: "date wrong type", attr: date, raw: 20250401.0, wantMsg: "must be a date (YYYY-MM-DD)"},
+
+		{name: "bool from json", attr: boolean, raw: true, wantText: "true", wantNum: ptr(1)},
+		{name: "bool from string", attr: boolean, raw: "FALSE", wantText: "false", wantNum: ptr(0)},
+		{name: "bool from 1", attr: boolean, raw: "1", wantText: "true", wantNum: ptr(1)},
+		{name: "bool not boolean", attr: boolean, raw: "yes", wantMsg: "must be true or false"},
+		{name: "bool wrong type", attr: boolean, raw: 1.0, wantMsg: "must be true or false"},
+
+		{name: "unknown type", attr: Attribute{Key: "x", Type: "json"}, raw: "v", wantMsg: "has an unknown type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := ParseValue(tt.attr, tt.raw)
+			if msg != tt.wantMsg {
+				t.Fatalf("ParseValue() msg = %q, want %q", msg, tt.wantMsg)
+			}
+			if msg != "" {
+				return
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", got.Text, tt.wantText)
+			}
+			switch {
+			case tt.wantNum == nil && got.Number != nil:
+				t.Errorf("Number = %v, want nil", *got.Number)
+			case tt.wantNum != nil && (got.Number == nil || *got.Number != *tt.wantNum):
+				t.Errorf("Number = %v, want %v", got.Number, *tt.wantNum)
+			}
+			switch {
+			case tt.wantDate == "" && got.Date != nil:
+				t.Errorf("Date = %v, want nil", got.Date)
+			case tt.wantDate != "" && (got.Date == nil || got.Date.Format(time.DateOnly) != tt.wantDate):
+				t.Errorf("Date = %v, want %s", got.Date, tt.wantDate)
+			}
+		})
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
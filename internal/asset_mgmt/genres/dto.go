@@ -0,0 +1,44 @@
+package genres
+
+import "time"
+
+// ---- Requests ----
+
+// genre_code は管理番号の先頭（"<genre_code>-YYYYMMDD-NNNNN"）になるため英大文字・数字のみ
+type CreateGenreRequest struct {
+	GenreCode string `json:"genre_code" binding:"required,max=16"`
+	GenreName string `json:"genre_name" binding:"required,max=100"`
+	// 貸出期間の上限（日。貸出日から延長後の返却期限まで）。省略・0 なら上限なし
+	MaxLoanDays *uint `json:"max_loan_days,omitempty" binding:"omitempty,max=3650"`
+}
+
+type UpdateGenreRequest struct {
+	GenreCode   *string `json:"genre_code,omitempty" binding:"omitempty,max=16"`
+	GenreName   *string `json:"genre_name,omitempty" binding:"omitempty,max=100"`
+	MaxLoanDays *uint   `json:"max_loan_days,omitempty" binding:"omitempty,max=3650"` // 0 で上限なし
+}
+
+// PUT /genres/:genre_id/attributes（定義を丸ごと置き換える。並び順は配列の順）
+type PutAttributesRequest struct {
+	Attributes []AttributeDef `json:"attributes" binding:"omitempty,dive"`
+}
+
+type AttributeDef struct {
+	Key        string   `json:"key" binding:"required,max=32"`
+	Label      string   `json:"label" binding:"required,max=100"`
+	Type       string   `json:"type" binding:"required,oneof=string number enum date bool"`
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enum_values,omitempty" binding:"omitempty,dive,max=100"`
+}
+
+// ---- Responses ----
+
+type GenreResponse struct {
+	GenreID     uint      `json:"genre_id"`
+	GenreCode   string    `json:"genre_code"`
+	GenreName   string    `json:"genre_name"`
+	MaxLoanDays *uint     `json:"max_loan_days,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// 詳細（GET /genres/:genre_id）のときだけ
+	Attributes []AttributeDef `json:"attributes,omitempty"`
+}
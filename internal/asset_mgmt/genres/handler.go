@@ -0,0 +1,121 @@
+package genres
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.GET("/genres", auth.Allow(auth.Members...), h.List)
+	r.GET("/genres/:genre_id", auth.Allow(auth.Members...), h.Get)
+	r.POST("/genres", auth.Allow(auth.AdminOnly...), h.Create)
+	r.PUT("/genres/:genre_id", auth.Allow(auth.AdminOnly...), h.Update)
+	r.DELETE("/genres/:genre_id", auth.Allow(auth.AdminOnly...), h.Delete)
+	r.PUT("/genres/:genre_id/attributes", auth.Allow(auth.AdminOnly...), h.PutAttributes)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	items, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := genreIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/genres/"+strconv.FormatUint(uint64(res.GenreID), 10))
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := genreIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := genreIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PutAttributes: 資産マスタの追加属性の定義を置き換える（定義は GET /genres/:genre_id の attributes）
+func (h *Handler) PutAttributes(c *gin.Context) {
+	id, ok := genreIDParam(c)
+	if !ok {
+		return
+	}
+	var req PutAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.PutAttributes(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ---- helpers ----
+
+func genreIDParam(c *gin.Context) (uint, bool) {
+	v, err := strconv.ParseUint(c.Param("genre_id"), 10, 32)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("genre_id must be a number"))
+		return 0, false
+	}
+	return uint(v), true
+}
@@ -0,0 +1,35 @@
+package genres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DBモデル（asset_genres と1:1）
+type Genre struct {
+	GenreID     uint
+	GenreCode   string
+	GenreName   string
+	MaxLoanDays sql.NullInt32 // 貸出期間の上限（日）。NULL なら上限なし
+	CreatedAt   time.Time
+}
+
+// DBモデル（genre_attributes と1:1）。資産マスタに持たせる追加属性の定義
+type Attribute struct {
+	GenreID    uint
+	Key        string
+	Label      string
+	Type       string // Type* のいずれか
+	Required   bool
+	EnumValues []string // Type == TypeEnum のときの選択肢
+	SortOrder  uint
+}
+
+// 属性の型
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeEnum   = "enum"
+	TypeDate   = "date"
+	TypeBool   = "bool"
+)
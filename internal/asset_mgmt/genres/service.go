@@ -0,0 +1,324 @@
+package genres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+// 管理番号の区切り文字 '-' を含めない
+var codePattern = regexp.MustCompile(`^[A-Z0-9]{2,16}$`)
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, store: NewStore(db)}
+}
+
+// GET /genres
+func (s *Service) List(ctx context.Context) ([]GenreResponse, error) {
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]GenreResponse, 0, len(items))
+	for _, g := range items {
+		out = append(out, toResponse(g))
+	}
+	return out, nil
+}
+
+// GET /genres/:genre_id
+func (s *Service) Get(ctx context.Context, id uint) (GenreResponse, error) {
+	g, err := s.store.Get(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return GenreResponse{}, apperr.NotFound("genre not found")
+		}
+		return GenreResponse{}, err
+	}
+	attrs, err := s.store.Attributes(ctx, id)
+	if err != nil {
+		return GenreResponse{}, err
+	}
+	out := toResponse(*g)
+	out.Attributes = toAttributeDefs(attrs)
+	return out, nil
+}
+
+// POST /genres
+func (s *Service) Create(ctx context.Context, in CreateGenreRequest) (GenreResponse, error) {
+	code, name, err := normalize(&in.GenreCode, &in.GenreName)
+	if err != nil {
+		return GenreResponse{}, err
+	}
+
+	var out GenreResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		id, err := st.Insert(ctx, *code, *name, in.MaxLoanDays)
+		if err != nil {
+			return mapWriteErr(err)
+		}
+		g, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*g)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityGenre,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return GenreResponse{}, err
+	}
+	return out, nil
+}
+
+// PUT /genres/:genre_id
+// genre_code は発番済みの管理番号に埋め込まれているため、参照しているマスタがある間は変更できない
+func (s *Service) Update(ctx context.Context, id uint, in UpdateGenreRequest) (GenreResponse, error) {
+	code, name, err := normalize(in.GenreCode, in.GenreName)
+	if err != nil {
+		return GenreResponse{}, err
+	}
+
+	var out GenreResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("genre not found")
+			}
+			return err
+		}
+		if code != nil && *code != before.GenreCode {
+			n, err := st.CountMasters(ctx, id)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return apperr.Conflict("genre_code cannot be changed while asset masters use this genre").
+					WithDetail("master_count", n)
+			}
+		}
+		if err := st.Update(ctx, id, code, name, in.MaxLoanDays); err != nil {
+			return mapWriteErr(err)
+		}
+		after, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*after)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityGenre,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return GenreResponse{}, err
+	}
+	return out, nil
+}
+
+// PUT /genres/:genre_id/attributes
+// 定義を丸ごと置き換える。既存の値と矛盾する変更（型の変更、選択肢から外れる値、値のないマスタがある状態での必須化）は 409。
+// 定義から外した属性の値は消す
+func (s *Service) PutAttributes(ctx context.Context, id uint, in PutAttributesRequest) (GenreResponse, error) {
+	attrs, err := normalizeDefs(id, in.Attributes)
+	if err != nil {
+		return GenreResponse{}, err
+	}
+
+	var out GenreResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		g, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("genre not found")
+			}
+			return err
+		}
+		current, err := st.Attributes(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkSchemaChange(ctx, st, id, current, attrs); err != nil {
+			return err
+		}
+		kept := map[string]bool{}
+		for _, a := range attrs {
+			kept[a.Key] = true
+		}
+		for _, a := range current {
+			if !kept[a.Key] {
+				if _, err := st.DeleteValues(ctx, id, a.Key); err != nil {
+					return err
+				}
+			}
+		}
+		if err := st.ReplaceAttributes(ctx, id, attrs); err != nil {
+			return err
+		}
+
+		before := toResponse(*g)
+		before.Attributes = toAttributeDefs(current)
+		out = toResponse(*g)
+		out.Attributes = toAttributeDefs(attrs)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityGenre,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     before,
+			After:      out,
+		})
+	})
+	if err != nil {
+		return GenreResponse{}, err
+	}
+	return out, nil
+}
+
+// checkSchemaChange: 残す属性について、既存の値が新しい定義でも有効かを確かめる
+func checkSchemaChange(ctx context.Context, st *Store, id uint, current, next []Attribute) error {
+	prev := make(map[string]Attribute, len(current))
+	for _, a := range current {
+		prev[a.Key] = a
+	}
+	for _, a := range next {
+		old, existed := prev[a.Key]
+		if existed && old.Type != a.Type {
+			n, err := st.CountValues(ctx, id, a.Key)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return apperr.Conflict("attribute type cannot be changed while asset masters have values").
+					WithDetail("key", a.Key).WithDetail("value_count", n)
+			}
+		} else if existed && a.Type == TypeEnum {
+			n, err := st.CountValuesNotIn(ctx, id, a.Key, a.EnumValues)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return apperr.Conflict("asset masters have values outside the new enum_values").
+					WithDetail("key", a.Key).WithDetail("value_count", n)
+			}
+		}
+		if a.Required && !(existed && old.Required) {
+			n, err := st.CountMissing(ctx, id, a.Key)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return apperr.Conflict("asset masters without a value exist; cannot make the attribute required").
+					WithDetail("key", a.Key).WithDetail("missing_count", n)
+			}
+		}
+	}
+	return nil
+}
+
+// DELETE /genres/:genre_id（マスタから参照されている間は削除できない）
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("genre not found")
+			}
+			return err
+		}
+		n, err := st.CountMasters(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return apperr.Conflict("genre is referenced by asset masters").WithDetail("master_count", n)
+		}
+		if err := st.Delete(ctx, id); err != nil {
+			return mapWriteErr(err)
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityGenre,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+		})
+	})
+}
+
+// ---- helpers ----
+
+// normalize: 前後の空白を除き、コードは大文字に寄せてから形式を検証する（nil はそのまま）
+func normalize(code, name *string) (*string, *string, error) {
+	if code != nil {
+		v := strings.ToUpper(strings.TrimSpace(*code))
+		if !codePattern.MatchString(v) {
+			return nil, nil, apperr.Invalid("validation failed").
+				WithField("genre_code", "must be 2-16 characters of A-Z and 0-9")
+		}
+		code = &v
+	}
+	if name != nil {
+		v := strings.TrimSpace(*name)
+		if v == "" {
+			return nil, nil, apperr.Invalid("validation failed").WithField("genre_name", "must not be empty")
+		}
+		name = &v
+	}
+	return code, name, nil
+}
+
+func mapWriteErr(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return apperr.Conflict("genre_code already exists")
+		case 1451:
+			return apperr.Conflict("genre is referenced by asset masters")
+		}
+	}
+	return err
+}
+
+func nullToUint(n sql.NullInt32) *uint {
+	if !n.Valid {
+		return nil
+	}
+	v := uint(n.Int32)
+	return &v
+}
+
+func toResponse(g Genre) GenreResponse {
+	return GenreResponse{
+		GenreID:     g.GenreID,
+		GenreCode:   g.GenreCode,
+		GenreName:   g.GenreName,
+		MaxLoanDays: nullToUint(g.MaxLoanDays),
+		CreatedAt:   g.CreatedAt,
+	}
+}
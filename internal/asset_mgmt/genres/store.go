@@ -0,0 +1,215 @@
+package genres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+const selectGenre = `SELECT genre_id, genre_code, genre_name, max_loan_days, created_at FROM asset_genres`
+
+func (s *Store) List(ctx context.Context) ([]Genre, error) {
+	rows, err := s.db.QueryContext(ctx, selectGenre+` ORDER BY genre_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Genre
+	for rows.Next() {
+		var g Genre
+		if err := rows.Scan(&g.GenreID, &g.GenreCode, &g.GenreName, &g.MaxLoanDays, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// Get: 見つからなければ sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uint) (*Genre, error) {
+	return s.get(ctx, selectGenre+` WHERE genre_id = ?`, id)
+}
+
+func (s *Store) Lock(ctx context.Context, id uint) (*Genre, error) {
+	return s.get(ctx, selectGenre+` WHERE genre_id = ? FOR UPDATE`, id)
+}
+
+func (s *Store) get(ctx context.Context, q string, id uint) (*Genre, error) {
+	var g Genre
+	if err := s.db.QueryRowContext(ctx, q, id).Scan(&g.GenreID, &g.GenreCode, &g.GenreName, &g.MaxLoanDays, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Insert / Update: maxLoanDays の 0 は NULL（上限なし）
+func (s *Store) Insert(ctx context.Context, code, name string, maxLoanDays *uint) (uint, error) {
+	const q = `INSERT INTO asset_genres (genre_code, genre_name, max_loan_days, created_at) VALUES (?, ?, NULLIF(?, 0), CURRENT_TIMESTAMP)`
+	var days uint
+	if maxLoanDays != nil {
+		days = *maxLoanDays
+	}
+	res, err := s.db.ExecContext(ctx, q, code, name, days)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func (s *Store) Update(ctx context.Context, id uint, code, name *string, maxLoanDays *uint) error {
+	sets := []string{}
+	args := []any{}
+	if code != nil {
+		sets = append(sets, "genre_code = ?")
+		args = append(args, *code)
+	}
+	if name != nil {
+		sets = append(sets, "genre_name = ?")
+		args = append(args, *name)
+	}
+	if maxLoanDays != nil {
+		sets = append(sets, "max_loan_days = NULLIF(?, 0)")
+		args = append(args, *maxLoanDays)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE asset_genres SET %s WHERE genre_id = ?`, strings.Join(sets, ", "))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, id uint) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM asset_genres WHERE genre_id = ?`, id)
+	return err
+}
+
+// CountMasters: このジャンルを参照している assets_master の件数
+func (s *Store) CountMasters(ctx context.Context, id uint) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM assets_master WHERE genre_id = ?`, id).Scan(&n)
+	return n, err
+}
+
+// ===== 属性の定義 =====
+
+// Attributes: ジャンルの属性定義（並び順どおり）
+func (s *Store) Attributes(ctx context.Context, genreID uint) ([]Attribute, error) {
+	const q = `
+	SELECT genre_id, attr_key, label, value_type, required, enum_values, sort_order
+	FROM genre_attributes WHERE genre_id = ? ORDER BY sort_order, attr_key`
+	rows, err := s.db.QueryContext(ctx, q, genreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Attribute
+	for rows.Next() {
+		var (
+			a    Attribute
+			enum []byte
+		)
+		if err := rows.Scan(&a.GenreID, &a.Key, &a.Label, &a.Type, &a.Required, &enum, &a.SortOrder); err != nil {
+			return nil, err
+		}
+		if len(enum) > 0 {
+			if err := json.Unmarshal(enum, &a.EnumValues); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// ReplaceAttributes: 定義を丸ごと置き換える
+func (s *Store) ReplaceAttributes(ctx context.Context, genreID uint, attrs []Attribute) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM genre_attributes WHERE genre_id = ?`, genreID); err != nil {
+		return err
+	}
+	const q = `
+	INSERT INTO genre_attributes (genre_id, attr_key, label, value_type, required, enum_values, sort_order)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for _, a := range attrs {
+		var enum []byte
+		if a.EnumValues != nil {
+			b, err := json.Marshal(a.EnumValues)
+			if err != nil {
+				return err
+			}
+			enum = b
+		}
+		if _, err := s.db.ExecContext(ctx, q, genreID, a.Key, a.Label, a.Type, a.Required, enum, a.SortOrder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ===== 定義変更時の既存値の確認 =====
+
+// genreValues: このジャンルのマスタが持つ属性値
+const genreValues = `
+	FROM asset_master_attributes v
+	JOIN assets_master m ON m.asset_master_id = v.asset_master_id
+	WHERE m.genre_id = ? AND v.attr_key = ?`
+
+// CountValues: attr_key の値を持つマスタの件数
+func (s *Store) CountValues(ctx context.Context, genreID uint, key string) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)`+genreValues, genreID, key).Scan(&n)
+	return n, err
+}
+
+// CountValuesNotIn: 選択肢 opts にない値を持つマスタの件数
+func (s *Store) CountValuesNotIn(ctx context.Context, genreID uint, key string, opts []string) (int64, error) {
+	q := `SELECT COUNT(*)` + genreValues
+	args := []any{genreID, key}
+	if len(opts) > 0 {
+		q += ` AND v.value_text NOT IN (?` + strings.Repeat(", ?", len(opts)-1) + `)`
+		for _, o := range opts {
+			args = append(args, o)
+		}
+	}
+	var n int64
+	err := s.db.QueryRowContext(ctx, q, args...).Scan(&n)
+	return n, err
+}
+
+// CountMissing: attr_key の値を持たないマスタの件数
+func (s *Store) CountMissing(ctx context.Context, genreID uint, key string) (int64, error) {
+	const q = `
+	SELECT COUNT(*) FROM assets_master m
+	WHERE m.genre_id = ?
+	  AND NOT EXISTS (SELECT 1 FROM asset_master_attributes v WHERE v.asset_master_id = m.asset_master_id AND v.attr_key = ?)`
+	var n int64
+	err := s.db.QueryRowContext(ctx, q, genreID, key).Scan(&n)
+	return n, err
+}
+
+// DeleteValues: 定義から外した属性の値を消す
+func (s *Store) DeleteValues(ctx context.Context, genreID uint, key string) (int64, error) {
+	const q = `
+	DELETE v FROM asset_master_attributes v
+	JOIN assets_master m ON m.asset_master_id = v.asset_master_id
+	WHERE m.genre_id = ? AND v.attr_key = ?`
+	res, err := s.db.ExecContext(ctx, q, genreID, key)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
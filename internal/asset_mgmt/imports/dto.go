@@ -0,0 +1,42 @@
+package imports
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	EncodingAuto  = "auto" // BOM / UTF-8 として正しいかで判定し、だめなら CP932
+	EncodingUTF8  = "utf-8"
+	EncodingCP932 = "cp932"
+)
+
+// Options: POST /assets/import?dry_run=&format=&encoding=
+type Options struct {
+	Format   string // 空ならファイル名・中身から判定
+	Encoding string // CSV のみ
+	DryRun   bool
+}
+
+// ImportReport: 取込結果。dry_run ではロールバックした結果（管理番号は本番で変わりうる）
+type ImportReport struct {
+	DryRun         bool        `json:"dry_run"`
+	Format         string      `json:"format"`
+	TotalRows      int         `json:"total_rows"`
+	MastersCreated int         `json:"masters_created"`
+	AssetsCreated  int         `json:"assets_created"`
+	Errors         []RowError  `json:"errors"`
+	Rows           []RowResult `json:"rows,omitempty"`
+}
+
+// RowError: row はファイル上の行番号（見出しが 1 行目）
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type RowResult struct {
+	Row              int     `json:"row"`
+	ManagementNumber string  `json:"management_number"`
+	MasterCreated    bool    `json:"master_created"`
+	AssetID          *uint64 `json:"asset_id,omitempty"`
+}
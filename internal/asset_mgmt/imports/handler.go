@@ -0,0 +1,105 @@
+package imports
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+)
+
+// アップロードの上限（数千行の XLSX でも数 MB に収まる）
+const maxUploadBytes = 10 << 20
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.POST("/assets/import", auth.Allow(auth.StaffOnly...), h.Import)
+}
+
+// Import: multipart の file、またはリクエストボディそのものを取り込む
+//
+//	?dry_run=true   検証と採番だけ行ってロールバック
+//	?format=csv|xlsx（省略時はファイル名・中身から判定）
+//	?encoding=auto|utf-8|cp932（CSV のみ）
+func (h *Handler) Import(c *gin.Context) {
+	opt := Options{Format: c.Query("format"), Encoding: c.Query("encoding")}
+	if v := c.Query("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("dry_run must be true or false"))
+			return
+		}
+		opt.DryRun = b
+	}
+
+	data, name, err := readUpload(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if opt.Format == "" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".csv":
+			opt.Format = FormatCSV
+		case ".xlsx":
+			opt.Format = FormatXLSX
+		}
+	}
+
+	rep, err := h.svc.Import(c.Request.Context(), data, opt)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if opt.DryRun {
+		c.JSON(http.StatusOK, rep)
+		return
+	}
+	c.JSON(http.StatusCreated, rep)
+}
+
+func readUpload(c *gin.Context) ([]byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes)
+
+	var (
+		r    io.Reader = c.Request.Body
+		name string
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", uploadErr(err, "file is required (multipart field \"file\")")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer f.Close()
+		r, name = f, fh.Filename
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", uploadErr(err, "cannot read upload")
+	}
+	if len(data) == 0 {
+		return nil, "", apperr.Invalid("file is empty")
+	}
+	return data, name, nil
+}
+
+func uploadErr(err error, msg string) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return apperr.Invalid("file too large").WithDetail("max_bytes", mbe.Limit)
+	}
+	return apperr.Invalid(msg)
+}
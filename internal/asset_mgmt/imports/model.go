@@ -0,0 +1,52 @@
+package imports
+
+import (
+	"fmt"
+	"sort"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+)
+
+// 見出し（列名は API の JSON キーと同じ。大文字小文字・前後の空白は無視）。
+// default_location / location は location_code（エクスポートと同じ）、*_id 列なら location_id で指定する
+var (
+	masterColumns = []string{"management_number", "name", "genre_id", "genre_code", "management_category_id", "category_code", "manufacturer", "model"}
+	assetColumns  = []string{"serial", "quantity", "purchased_at", "status_id", "owner",
+		"default_location", "default_location_id", "location", "location_id", "notes"}
+)
+
+// attrPrefix: ジャンルの追加属性の列（attr.<key>。値の検証は登録時にジャンルの定義で行う）
+const attrPrefix = "attr."
+
+// row: 検証済みの1行。management_number があれば既存マスタに在庫行だけ足す。
+// なければ同じマスタ列の行を1つのマスタにまとめて新規採番する
+type row struct {
+	Line             int
+	ManagementNumber string
+	MasterID         uint64
+	Master           assets.CreateAssetMasterRequest
+	Asset            *assets.CreateAssetRequest // 在庫列がすべて空ならマスタのみ
+}
+
+// masterKey: ファイル内で同じマスタとみなすキー
+type masterKey struct {
+	genreID, categoryID       uint
+	name, manufacturer, model string
+	attributes                string
+}
+
+func (r row) masterKey() masterKey {
+	k := masterKey{genreID: r.Master.GenreID, categoryID: r.Master.ManagementCategoryID, name: r.Master.Name, manufacturer: r.Master.Manufacturer}
+	if r.Master.Model != nil {
+		k.model = *r.Master.Model
+	}
+	keys := make([]string, 0, len(r.Master.Attributes))
+	for name := range r.Master.Attributes {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		k.attributes += name + "=" + fmt.Sprint(r.Master.Attributes[name]) + "\x00"
+	}
+	return k
+}
@@ -0,0 +1,301 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+// detectFormat: 明示がなければ zip の先頭（PK\x03\x04）なら xlsx、それ以外は csv
+func detectFormat(data []byte, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "":
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			return FormatXLSX, nil
+		}
+		return FormatCSV, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", apperr.Invalid("unsupported format").WithField("format", "must be csv or xlsx")
+}
+
+// readTable: 1枚目のシート（CSV は全体）を行ごとのセル文字列にする。添字 i がファイルの i+1 行目
+func readTable(data []byte, format, encoding string) ([][]string, error) {
+	if format == FormatXLSX {
+		t, err := readXLSX(data)
+		if err != nil {
+			return nil, apperr.Invalid("cannot read xlsx: " + err.Error())
+		}
+		return t, nil
+	}
+	return readCSV(data, encoding)
+}
+
+// ===== CSV =====
+
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+func readCSV(data []byte, encoding string) ([][]string, error) {
+	var r io.Reader
+	switch strings.ToLower(encoding) {
+	case "", EncodingAuto:
+		if b, ok := bytes.CutPrefix(data, utf8BOM); ok || utf8.Valid(data) {
+			r = bytes.NewReader(b)
+		} else {
+			r = transform.NewReader(bytes.NewReader(data), japanese.ShiftJIS.NewDecoder())
+		}
+	case EncodingUTF8, "utf8":
+		r = bytes.NewReader(bytes.TrimPrefix(data, utf8BOM))
+	case EncodingCP932, "shift_jis", "sjis":
+		r = transform.NewReader(bytes.NewReader(data), japanese.ShiftJIS.NewDecoder())
+	default:
+		return nil, apperr.Invalid("unsupported encoding").WithField("encoding", "must be auto, utf-8 or cp932")
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // 末尾の空列を省いた行も受ける
+	var out [][]string
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				return nil, apperr.Invalid("malformed csv").WithDetail("row", pe.Line).WithDetail("reason", pe.Err.Error())
+			}
+			return nil, apperr.Invalid("cannot read csv: " + err.Error())
+		}
+		out = append(out, rec)
+	}
+}
+
+// ===== XLSX =====
+// 取込に要るのは1枚目のシートのセル文字列だけなので、外部ライブラリを使わず
+// workbook.xml → rels → sheetN.xml と sharedStrings.xml を直接読む。
+// 展開後のサイズと行・列の位置には上限を設ける（小さな zip で巨大なメモリを確保させない）
+
+const (
+	maxEntryBytes = 32 << 20    // zip の1エントリを展開したときの上限
+	maxSheetRows  = MaxRows + 1 // 見出し行 + データ行
+	maxSheetCols  = 256
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText: <si> / <is>。書式付きの文字列は <r><t> に分かれる（ふりがな <rPh> は読まない）
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x xlsxText) String() string {
+	if len(x.R) == 0 {
+		return x.T
+	}
+	var sb strings.Builder
+	for _, r := range x.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int        `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref string    `xml:"r,attr"`
+	T   string    `xml:"t,attr"`
+	V   string    `xml:"v"`
+	Is  *xlsxText `xml:"is"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			SI []xlsxText `xml:"si"`
+		}
+		if err := decodeXML(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.SI {
+			shared = append(shared, si.String())
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("worksheet not found: " + sheetPath)
+	}
+	var sheet xlsxSheet
+	if err := decodeXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var out [][]string
+	for _, r := range sheet.Rows {
+		n := r.R
+		if n <= 0 { // r 省略時は直前の行の次
+			n = len(out) + 1
+		}
+		if n > maxSheetRows {
+			// 書式だけの空行は末尾に残りがちなので読み飛ばす。値があれば行数超過
+			if rowHasValue(r.Cells) {
+				return nil, errors.New("too many rows (max " + strconv.Itoa(MaxRows) + ")")
+			}
+			continue
+		}
+		for len(out) < n {
+			out = append(out, nil)
+		}
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if col >= maxSheetCols {
+				if c.V == "" && c.Is == nil {
+					continue
+				}
+				return nil, errors.New("too many columns (max " + strconv.Itoa(maxSheetCols) + ")")
+			}
+			v := c.V
+			switch c.T {
+			case "s":
+				idx, err := strconv.Atoi(c.V)
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, errors.New("bad shared string index in " + c.Ref)
+				}
+				v = shared[idx]
+			case "inlineStr":
+				if c.Is != nil {
+					v = c.Is.String()
+				}
+			case "b":
+				v = map[string]string{"1": "TRUE", "0": "FALSE"}[c.V]
+			}
+			cells := out[n-1]
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			cells[col] = v
+			out[n-1] = cells
+		}
+	}
+	return out, nil
+}
+
+// firstSheetPath: workbook の先頭シートの実ファイル名（見つからなければ sheet1.xml）
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wf, ok := files["xl/workbook.xml"]
+	rf, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !ok2 {
+		return fallback, nil
+	}
+	var wb xlsxWorkbook
+	if err := decodeXML(wf, &wb); err != nil {
+		return "", err
+	}
+	var rels xlsxRels
+	if err := decodeXML(rf, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	for _, r := range rels.Rels {
+		if r.ID != wb.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/"), nil
+		}
+		return path.Join("xl", r.Target), nil
+	}
+	return fallback, nil
+}
+
+func rowHasValue(cells []xlsxCell) bool {
+	for _, c := range cells {
+		if c.V != "" || c.Is != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeXML: 申告サイズが上限を超えるエントリは開かない。申告が偽でも上限までしか読まない
+func decodeXML(f *zip.File, v any) error {
+	if f.UncompressedSize64 > maxEntryBytes {
+		return errors.New(f.Name + " is too large (max " + strconv.Itoa(maxEntryBytes) + " bytes uncompressed)")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, maxEntryBytes)).Decode(v)
+}
+
+// columnIndex: "AB12" → 27（0 始まり）
+func columnIndex(ref string) (int, error) {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 || i > 3 { // Excel の列は XFD（3文字）まで
+		return 0, errors.New("bad cell reference " + ref)
+	}
+	return n - 1, nil
+}
@@ -0,0 +1,494 @@
+package imports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+// 1回で取り込める行数（見出しを除く）
+const MaxRows = 5000
+
+type Service struct {
+	db     *sql.DB
+	store  *Store
+	assets *assets.Service
+}
+
+func NewService(db *sql.DB, assetsSvc *assets.Service) *Service {
+	return &Service{db: db, store: NewStore(db), assets: assetsSvc}
+}
+
+// errRollback: dry_run と行エラーで Tx を巻き戻すための番兵
+var errRollback = errors.New("imports: rollback")
+
+// Import: 全行を検証し、問題がなければ1つの Tx でマスタ採番・在庫行登録まで行う。
+// dry_run は同じ処理を流して最後にロールバックする（行エラーがあれば 200 で報告だけ返す）。
+// 本番で行エラーがあれば何も登録せず UNPROCESSABLE_ENTITY（details.errors に行ごとの内容）
+func (s *Service) Import(ctx context.Context, data []byte, opt Options) (ImportReport, error) {
+	format, err := detectFormat(data, opt.Format)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	table, err := readTable(data, format, opt.Encoding)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	rep := ImportReport{DryRun: opt.DryRun, Format: format, Errors: []RowError{}}
+
+	rows, err := s.parse(ctx, table, &rep)
+	if err != nil {
+		return rep, err
+	}
+	if len(rep.Errors) == 0 {
+		err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+			if err := s.apply(ctx, tx, rows, &rep); err != nil {
+				return err
+			}
+			if opt.DryRun {
+				return errRollback
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errRollback) {
+			return rep, err
+		}
+	}
+
+	if len(rep.Errors) > 0 {
+		rep.MastersCreated, rep.AssetsCreated, rep.Rows = 0, 0, nil
+		if !opt.DryRun {
+			return rep, apperr.Unprocessable("import has invalid rows; nothing was imported").
+				WithDetail("errors", rep.Errors)
+		}
+	}
+	return rep, nil
+}
+
+// parse: 見出しを解釈し、各行を検証して row にする（行ごとの誤りは rep.Errors に積む）
+func (s *Service) parse(ctx context.Context, table [][]string, rep *ImportReport) ([]row, error) {
+	if len(table) == 0 {
+		return nil, apperr.Invalid("file is empty")
+	}
+	cols, err := headerIndex(table[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cols["management_number"]; !ok {
+		if _, ok := cols["name"]; !ok {
+			return nil, apperr.Invalid("missing columns").WithField("name", "name or management_number column is required")
+		}
+	}
+
+	ref := newResolver(s.store)
+	var rows []row
+	for i, rec := range table[1:] {
+		line := i + 2
+		cells := make(map[string]string, len(cols))
+		blank := true
+		for name, idx := range cols {
+			if idx < len(rec) {
+				if v := strings.TrimSpace(rec[idx]); v != "" {
+					cells[name] = v
+					blank = false
+				}
+			}
+		}
+		if blank {
+			continue
+		}
+		rep.TotalRows++
+		if rep.TotalRows > MaxRows {
+			return nil, apperr.Invalid(fmt.Sprintf("too many rows (max %d)", MaxRows))
+		}
+
+		p := rowParser{line: line, cells: cells, rep: rep}
+		r, err := p.row(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r)
+	}
+	if rep.TotalRows == 0 {
+		return nil, apperr.Invalid("file has no data rows")
+	}
+	return rows, nil
+}
+
+// apply: 検証済みの行を登録する。apperr の誤りは行エラーとして積んでロールバック
+func (s *Service) apply(ctx context.Context, tx db.DBTX, rows []row, rep *ImportReport) error {
+	created := make(map[masterKey]assets.AssetMasterResponse)
+	fail := func(line int, err error) error {
+		var ae *apperr.Error
+		if !errors.As(err, &ae) {
+			return err // DB 障害などはそのまま 500
+		}
+		if len(ae.Fields) == 0 {
+			rep.Errors = append(rep.Errors, RowError{Row: line, Message: ae.Message})
+		}
+		// 属性の検証エラーなどは項目ごとに積む（attributes.<key> → attr.<key> 列）
+		for _, f := range ae.Fields {
+			col := f.Field
+			if k, ok := strings.CutPrefix(col, "attributes."); ok {
+				col = attrPrefix + k
+			}
+			rep.Errors = append(rep.Errors, RowError{Row: line, Column: col, Message: f.Message})
+		}
+		return errRollback
+	}
+
+	for _, r := range rows {
+		res := RowResult{Row: r.Line, ManagementNumber: r.ManagementNumber}
+		masterID := r.MasterID
+		if r.ManagementNumber == "" {
+			m, ok := created[r.masterKey()]
+			if !ok {
+				var err error
+				if m, err = s.assets.CreateAssetMasterTx(ctx, tx, r.Master); err != nil {
+					return fail(r.Line, err)
+				}
+				created[r.masterKey()] = m
+				rep.MastersCreated++
+				res.MasterCreated = true
+			}
+			masterID, res.ManagementNumber = m.AssetMasterID, m.ManagementNumber
+		}
+		if r.Asset != nil {
+			in := *r.Asset
+			in.AssetMasterID = &masterID
+			a, err := s.assets.CreateAssetTx(ctx, tx, in)
+			if err != nil {
+				return fail(r.Line, err)
+			}
+			rep.AssetsCreated++
+			res.AssetID = &a.AssetID
+		}
+		rep.Rows = append(rep.Rows, res)
+	}
+	return nil
+}
+
+// headerIndex: 列名 → 列番号。未知の列は誤字で値を落とさないよう弾く
+func headerIndex(header []string) (map[string]int, error) {
+	known := make(map[string]bool)
+	for _, c := range append(append([]string{}, masterColumns...), assetColumns...) {
+		known[c] = true
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if name == "" {
+			continue
+		}
+		if !known[name] && !strings.HasPrefix(name, attrPrefix) {
+			return nil, apperr.Invalid("unknown column").WithField(name, "unknown column")
+		}
+		if _, dup := cols[name]; dup {
+			return nil, apperr.Invalid("duplicate column").WithField(name, "appears more than once")
+		}
+		cols[name] = i
+	}
+	return cols, nil
+}
+
+// ===== 行の検証 =====
+
+// resolver: コード・ID の存在確認をファイル内でキャッシュする
+type resolver struct {
+	store    *Store
+	genres   map[string]uint
+	cats     map[string]uint
+	places   map[string]uint
+	statuses map[uint]bool
+	masters  map[string]uint64
+}
+
+func newResolver(st *Store) *resolver {
+	return &resolver{
+		store:    st,
+		genres:   make(map[string]uint),
+		cats:     make(map[string]uint),
+		places:   make(map[string]uint),
+		statuses: make(map[uint]bool),
+		masters:  make(map[string]uint64),
+	}
+}
+
+type rowParser struct {
+	line  int
+	cells map[string]string
+	rep   *ImportReport
+}
+
+func (p *rowParser) fail(col, msg string) {
+	p.rep.Errors = append(p.rep.Errors, RowError{Row: p.line, Column: col, Message: msg})
+}
+
+func (p *rowParser) str(col string) string { return p.cells[col] }
+
+func (p *rowParser) ptr(col string) *string {
+	if v, ok := p.cells[col]; ok {
+		return &v
+	}
+	return nil
+}
+
+func (p *rowParser) uint(col string) (uint, bool) {
+	v, ok := p.cells[col]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimSuffix(v, ".0"), 10, 32)
+	if err != nil {
+		p.fail(col, "must be a non-negative integer")
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// row: 1行を検証して row にする。戻り値の error は DB 障害のみ
+func (p *rowParser) row(ctx context.Context, ref *resolver) (row, error) {
+	r := row{Line: p.line, ManagementNumber: p.str("management_number")}
+
+	// マスタ
+	if r.ManagementNumber != "" {
+		id, err := ref.master(ctx, r.ManagementNumber)
+		if err == sql.ErrNoRows {
+			p.fail("management_number", "asset master not found")
+		} else if err != nil {
+			return row{}, err
+		}
+		r.MasterID = id
+	} else {
+		r.Master.Name = p.str("name")
+		r.Master.Manufacturer = p.str("manufacturer")
+		r.Master.Model = p.ptr("model")
+		for col, v := range p.cells {
+			if k, ok := strings.CutPrefix(col, attrPrefix); ok {
+				if r.Master.Attributes == nil {
+					r.Master.Attributes = make(map[string]any)
+				}
+				r.Master.Attributes[k] = v
+			}
+		}
+		if r.Master.Name == "" {
+			p.fail("name", "required")
+		}
+		if r.Master.Manufacturer == "" {
+			p.fail("manufacturer", "required")
+		}
+		var err error
+		if r.Master.GenreID, err = p.code(ctx, "genre", ref.genre); err != nil {
+			return row{}, err
+		}
+		if r.Master.ManagementCategoryID, err = p.code(ctx, "category", ref.category); err != nil {
+			return row{}, err
+		}
+	}
+
+	// 在庫行（列がすべて空ならマスタのみ）
+	hasAsset := false
+	for _, c := range assetColumns {
+		if _, ok := p.cells[c]; ok {
+			hasAsset = true
+		}
+	}
+	if !hasAsset {
+		if r.ManagementNumber != "" {
+			p.fail("", "asset columns are empty; nothing to import for an existing master")
+		}
+		return r, nil
+	}
+
+	a := assets.CreateAssetRequest{
+		Serial:   p.ptr("serial"),
+		Quantity: 1,
+		StatusID: statuses.Available,
+		Owner:    p.str("owner"),
+		Notes:    p.ptr("notes"),
+	}
+	if n, ok := p.uint("quantity"); ok {
+		a.Quantity = n
+	}
+	if a.Owner == "" {
+		p.fail("owner", "required")
+	}
+	var err error
+	if a.DefaultLocationID, err = p.location(ctx, "default_location", true, ref); err != nil {
+		return row{}, err
+	}
+	if id, err := p.location(ctx, "location", false, ref); err != nil {
+		return row{}, err
+	} else if id != 0 {
+		a.LocationID = &id
+	}
+	if v := p.str("purchased_at"); v == "" {
+		p.fail("purchased_at", "required")
+	} else if t, err := parseDate(v); err != nil {
+		p.fail("purchased_at", "must be YYYY-MM-DD, YYYY/MM/DD, RFC3339 or an Excel date")
+	} else {
+		a.PurchasedAt = t
+	}
+	if id, ok := p.uint("status_id"); ok {
+		found, err := ref.status(ctx, id)
+		if err != nil {
+			return row{}, err
+		}
+		if !found {
+			p.fail("status_id", "unknown status")
+		}
+		a.StatusID = id
+	}
+	r.Asset = &a
+	return r, nil
+}
+
+// code: {kind}_code（コード）か {kind}_id の列から ID を引く。どちらも無ければ必須エラー
+func (p *rowParser) code(ctx context.Context, kind string, lookup func(context.Context, string, uint) (uint, error)) (uint, error) {
+	idCol := kind + "_id"
+	if kind == "category" {
+		idCol = "management_category_id"
+	}
+	code := strings.ToUpper(p.str(kind + "_code"))
+	id, hasID := p.uint(idCol)
+	if code == "" && !hasID {
+		if p.str(idCol) == "" { // 数値でない場合は p.uint が報告済み
+			p.fail(idCol, "required ("+idCol+" or "+kind+"_code)")
+		}
+		return 0, nil
+	}
+	got, err := lookup(ctx, code, id)
+	if err == sql.ErrNoRows {
+		if code != "" {
+			p.fail(kind+"_code", "unknown code "+code)
+		} else {
+			p.fail(idCol, "not found")
+		}
+		return 0, nil
+	}
+	return got, err
+}
+
+// location: col（location_code）か col_id（location_id）の列から ID を引く。空なら 0（required ならエラーにする）
+func (p *rowParser) location(ctx context.Context, col string, required bool, ref *resolver) (uint, error) {
+	idCol := col + "_id"
+	code := strings.ToUpper(p.str(col))
+	id, hasID := p.uint(idCol)
+	if code == "" && !hasID {
+		if required && p.str(idCol) == "" {
+			p.fail(col, "required ("+col+" or "+idCol+")")
+		}
+		return 0, nil
+	}
+	got, err := ref.place(ctx, code, id)
+	if err == sql.ErrNoRows {
+		if code != "" {
+			p.fail(col, "unknown location code "+code)
+		} else {
+			p.fail(idCol, "not found")
+		}
+		return 0, nil
+	}
+	return got, err
+}
+
+func (r *resolver) genre(ctx context.Context, code string, id uint) (uint, error) {
+	return r.lookup(ctx, r.genres, code, id, r.store.GenreIDByCode, r.store.GenreExists)
+}
+
+func (r *resolver) category(ctx context.Context, code string, id uint) (uint, error) {
+	return r.lookup(ctx, r.cats, code, id, r.store.CategoryIDByCode, r.store.CategoryExists)
+}
+
+func (r *resolver) place(ctx context.Context, code string, id uint) (uint, error) {
+	return r.lookup(ctx, r.places, code, id, r.store.LocationIDByCode, r.store.LocationExists)
+}
+
+// lookup: コード優先。キャッシュのキーはコード、ID 指定は "#<id>"
+func (r *resolver) lookup(ctx context.Context, cache map[string]uint, code string, id uint,
+	byCode func(context.Context, string) (uint, error), exists func(context.Context, uint) error) (uint, error) {
+	key := code
+	if key == "" {
+		key = "#" + strconv.FormatUint(uint64(id), 10)
+	}
+	if v, ok := cache[key]; ok {
+		if v == 0 {
+			return 0, sql.ErrNoRows
+		}
+		return v, nil
+	}
+	var err error
+	if code != "" {
+		id, err = byCode(ctx, code)
+	} else {
+		err = exists(ctx, id)
+	}
+	if err == sql.ErrNoRows {
+		cache[key] = 0
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	cache[key] = id
+	return id, nil
+}
+
+func (r *resolver) status(ctx context.Context, id uint) (bool, error) {
+	if v, ok := r.statuses[id]; ok {
+		return v, nil
+	}
+	err := r.store.StatusExists(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	r.statuses[id] = err == nil
+	return err == nil, nil
+}
+
+func (r *resolver) master(ctx context.Context, mng string) (uint64, error) {
+	if v, ok := r.masters[mng]; ok {
+		if v == 0 {
+			return 0, sql.ErrNoRows
+		}
+		return v, nil
+	}
+	id, err := r.store.MasterIDByMng(ctx, mng)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	r.masters[mng] = id
+	return id, err
+}
+
+// ===== 値 =====
+
+// Excel の日付シリアル値の起点（1900 年方式。1900/2/29 のずれを含めた慣例の値）
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// parseDate: 日付のみは 0:00 UTC（assets の parseDateOrTime と同じ扱い）
+func parseDate(v string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006/1/2", time.RFC3339, "2006-01-02 15:04:05", "2006/1/2 15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	// XLSX の日付セルは書式付きの数値で入ってくる
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 1 || f > 2958465 { // 2958465 = 9999-12-31
+		return time.Time{}, errors.New("bad date")
+	}
+	days := math.Floor(f)
+	sec := math.Round((f - days) * 86400)
+	return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(sec) * time.Second), nil
+}
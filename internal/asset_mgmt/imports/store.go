@@ -0,0 +1,54 @@
+package imports
+
+import (
+	"context"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// 検証用の参照（見つからなければ sql.ErrNoRows）
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+func (s *Store) GenreIDByCode(ctx context.Context, code string) (uint, error) {
+	var id uint
+	err := s.db.QueryRowContext(ctx, `SELECT genre_id FROM asset_genres WHERE genre_code = ?`, code).Scan(&id)
+	return id, err
+}
+
+func (s *Store) GenreExists(ctx context.Context, id uint) error {
+	return s.db.QueryRowContext(ctx, `SELECT genre_id FROM asset_genres WHERE genre_id = ?`, id).Scan(&id)
+}
+
+func (s *Store) CategoryIDByCode(ctx context.Context, code string) (uint, error) {
+	var id uint
+	err := s.db.QueryRowContext(ctx,
+		`SELECT management_category_id FROM management_categories WHERE category_code = ?`, code).Scan(&id)
+	return id, err
+}
+
+func (s *Store) CategoryExists(ctx context.Context, id uint) error {
+	return s.db.QueryRowContext(ctx,
+		`SELECT management_category_id FROM management_categories WHERE management_category_id = ?`, id).Scan(&id)
+}
+
+func (s *Store) StatusExists(ctx context.Context, id uint) error {
+	return s.db.QueryRowContext(ctx, `SELECT status_id FROM asset_statuses WHERE status_id = ?`, id).Scan(&id)
+}
+
+func (s *Store) LocationIDByCode(ctx context.Context, code string) (uint, error) {
+	var id uint
+	err := s.db.QueryRowContext(ctx, `SELECT location_id FROM locations WHERE location_code = ?`, code).Scan(&id)
+	return id, err
+}
+
+func (s *Store) LocationExists(ctx context.Context, id uint) error {
+	return s.db.QueryRowContext(ctx, `SELECT location_id FROM locations WHERE location_id = ?`, id).Scan(&id)
+}
+
+func (s *Store) MasterIDByMng(ctx context.Context, mng string) (uint64, error) {
+	var id uint64
+	err := s.db.QueryRowContext(ctx, `SELECT asset_master_id FROM assets_master WHERE management_number = ?`, mng).Scan(&id)
+	return id, err
+}
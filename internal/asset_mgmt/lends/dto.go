@@ -1,6 +1,11 @@
 package lends
 
-import "time"
+import (
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/platform/pagination"
+)
 
 // ---- Requests ----
 
@@ -8,20 +13,59 @@ type CreateLendRequest struct {
 	Quantity   uint    `json:"quantity" binding:"required"`    // >0 をサービス層で検証
 	BorrowerID string  `json:"borrower_id" binding:"required"` // 借受者
 	DueOn      *string `json:"due_on,omitempty"`               // "YYYY-MM-DD"
-	LentByID   *string `json:"lent_by_id,omitempty"`
 	Note       *string `json:"note,omitempty"`
+	AssetID    *uint64 `json:"asset_id,omitempty"` // 在庫行を指定して貸す場合（省略時は自動割り当て）
+	// lent_by_id はクライアントから受け取らず、認証済みの呼び出し元で埋める
+}
+
+// POST /lends/batch（1人の借受者にまとめて貸す。管理番号の重複は不可）
+type CreateCheckoutRequest struct {
+	BorrowerID string         `json:"borrower_id" binding:"required"`
+	DueOn      *string        `json:"due_on,omitempty"` // 全明細共通 "YYYY-MM-DD"
+	Note       *string        `json:"note,omitempty"`
+	Items      []CheckoutItem `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+type CheckoutItem struct {
+	ManagementNumber string  `json:"management_number" binding:"required"`
+	Quantity         uint    `json:"quantity" binding:"required"`
+	AssetID          *uint64 `json:"asset_id,omitempty"`
 }
 
 type CreateReturnRequest struct {
-	Quantity      uint    `json:"quantity" binding:"required"` // >0
-	ProcessedByID *string `json:"processed_by_id,omitempty"`
-	Note          *string `json:"note,omitempty"`
+	Quantity uint    `json:"quantity" binding:"required"` // >0
+	Note     *string `json:"note,omitempty"`
+	AssetID  *uint64 `json:"asset_id,omitempty"` // 戻す在庫行を限定する場合
+	// 返却時の状態（省略時は good）。damaged は damage_description 必須で、在庫には戻さず修理中の行へ回す
+	Condition         *string `json:"condition,omitempty" binding:"omitempty,oneof=good worn damaged"`
+	DamageDescription *string `json:"damage_description,omitempty" binding:"omitempty,max=1000"`
+	// processed_by_id は認証済みの呼び出し元で埋める
+	// 写真は POST /returns/:return_ulid/attachments で添付する
+}
+
+// POST /lends/:lend_ulid/losses（届出者は認証済みの呼び出し元で埋める）
+type CreateLossRequest struct {
+	Quantity    *uint   `json:"quantity,omitempty"` // 省略時は未返却の全数
+	AssetID     *uint64 `json:"asset_id,omitempty"` // 紛失した在庫行を限定する場合
+	Description string  `json:"description" binding:"required,max=1000"`
+}
+
+// POST /losses/:loss_ulid/close
+type CloseLossRequest struct {
+	Resolution string `json:"resolution" binding:"required,max=500"` // 弁償済み・見つからず償却など
+}
+
+// POST /lends/:lend_ulid/renewals（承認者は認証済みの呼び出し元で埋める）
+type CreateRenewalRequest struct {
+	DueOn  string `json:"due_on" binding:"required"` // 延長後の返却期限 "YYYY-MM-DD"
+	Reason string `json:"reason" binding:"required,max=500"`
 }
 
 // ---- Responses ----
 
 type LendResponse struct {
 	LendULID            string    `json:"lend_ulid"`
+	CheckoutULID        *string   `json:"checkout_ulid,omitempty"` // まとめて貸したとき（POST /lends/batch）
 	AssetMasterID       uint64    `json:"asset_master_id"`
 	ManagementNumber    string    `json:"management_number"`
 	Quantity            uint      `json:"quantity"`
@@ -30,33 +74,93 @@ type LendResponse struct {
 	LentByID            *string   `json:"lent_by_id,omitempty"`
 	LentAt              time.Time `json:"lent_at"`
 	ReturnedQuantity    uint      `json:"returned_quantity"`
+	LostQuantity        uint      `json:"lost_quantity"`
 	OutstandingQuantity uint      `json:"outstanding_quantity"`
 	Note                *string   `json:"note,omitempty"`
-	Returned            bool      `json:"returned"`
+	Returned            bool      `json:"returned"` // 未返却なし（紛失で閉じた分を含む）
+	Overdue             bool      `json:"overdue"`  // 未返却のまま返却期限（due_on）を過ぎている
+	// どの在庫行から何個貸したか（一覧では省略）
+	Allocations []AllocationResponse `json:"allocations,omitempty"`
+}
+
+type CheckoutResponse struct {
+	CheckoutULID string         `json:"checkout_ulid"`
+	BorrowerID   string         `json:"borrower_id"`
+	DueOn        *string        `json:"due_on,omitempty"`
+	Lends        []LendResponse `json:"lends"` // リクエストの items と同じ順
+}
+
+type AllocationResponse struct {
+	AssetID          uint64 `json:"asset_id"`
+	Quantity         uint   `json:"quantity"`
+	ReturnedQuantity uint   `json:"returned_quantity"`
+	LostQuantity     uint   `json:"lost_quantity"`
 }
 
 type ReturnResponse struct {
-	ReturnULID    string    `json:"return_ulid"`
-	LendULID      string    `json:"lend_ulid"`
-	Quantity      uint      `json:"quantity"`
-	ProcessedByID *string   `json:"processed_by_id,omitempty"`
-	ReturnedAt    time.Time `json:"returned_at"`
-	Note          *string   `json:"note,omitempty"`
+	ReturnULID        string    `json:"return_ulid"`
+	LendULID          string    `json:"lend_ulid"`
+	Quantity          uint      `json:"quantity"`
+	Condition         string    `json:"condition"`
+	DamageDescription *string   `json:"damage_description,omitempty"`
+	ProcessedByID     *string   `json:"processed_by_id,omitempty"`
+	ReturnedAt        time.Time `json:"returned_at"`
+	Note              *string   `json:"note,omitempty"`
+	// どの在庫行の貸出分を何個返したか（作成時のみ）
+	Allocations []stock.Allocation `json:"allocations,omitempty"`
+	// 破損で修理中にした在庫行と数（作成時のみ。分けて作った行なら元の行とは別の asset_id）
+	RepairAllocations []stock.Allocation `json:"repair_allocations,omitempty"`
+}
+
+type LossResponse struct {
+	LossULID         string     `json:"loss_ulid"`
+	LendULID         string     `json:"lend_ulid"`
+	ManagementNumber string     `json:"management_number"`
+	BorrowerID       string     `json:"borrower_id"`
+	Quantity         uint       `json:"quantity"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"` // open / closed
+	ReportedByID     *string    `json:"reported_by_id,omitempty"`
+	ReportedAt       time.Time  `json:"reported_at"`
+	ClosedByID       *string    `json:"closed_by_id,omitempty"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
+	Resolution       *string    `json:"resolution,omitempty"`
+	// どの在庫行の貸出分を何個閉じたか（作成時のみ）
+	Allocations []stock.Allocation `json:"allocations,omitempty"`
+}
+
+type RenewalResponse struct {
+	RenewalULID  string    `json:"renewal_ulid"`
+	LendULID     string    `json:"lend_ulid"`
+	OldDueOn     string    `json:"old_due_on"`
+	NewDueOn     string    `json:"new_due_on"`
+	ApprovedByID *string   `json:"approved_by_id,omitempty"`
+	Reason       string    `json:"reason"`
+	RenewedAt    time.Time `json:"renewed_at"`
 }
 
 // ---- List payload ----
 
 type Page struct {
-	Limit  int
-	Offset int
-	Order  string // "asc" or "desc"
+	pagination.Request
+	Order string // "asc" or "desc"
 }
 
 type LendFilter struct {
 	ManagementNumber *string
+	CheckoutULID     *string
 	BorrowerID       *string
 	From             *time.Time
 	To               *time.Time
 	OnlyOutstanding  bool
 	Returned         *bool
+	Overdue          bool    // 未返却のまま返却期限を過ぎたもの（今日はサーバーのローカル日付）
+	DueBefore        *string // due_on がこの日付（"YYYY-MM-DD"）より前のもの
+
+	today string // Overdue の基準日。Service で埋める
+}
+
+type LossFilter struct {
+	Status     *string // open / closed
+	BorrowerID *string
 }
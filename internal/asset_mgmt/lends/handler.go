@@ -6,6 +6,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/idempotency"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type Handler struct{ svc *Service }
@@ -13,17 +18,35 @@ type Handler struct{ svc *Service }
 func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 
-	// 貸出（管理番号単位）
-	r.POST("/assets/:management_number/lends", h.CreateLend) //OK
+	// 在庫を動かす POST は Idempotency-Key による再送対策を付ける
+	idem := idempotency.Middleware(svc.db)
+
+	// 貸出（管理番号単位）… member は自分名義のみ（Service で判定）
+	r.POST("/assets/:management_number/lends", auth.Allow(auth.Members...), idem, h.CreateLend) //OK
+
+	// まとめて貸出（全件貸せたときだけ確定）
+	r.POST("/lends/batch", auth.Allow(auth.Members...), idem, h.CreateCheckout)
 
 	// 貸出リソース
-	r.GET("/lends", h.ListLends)          //OK
-	r.GET("/lends/:lend_ulid", h.GetLend) //OK
+	r.GET("/lends", auth.Allow(auth.Members...), h.ListLends)          //OK
+	r.GET("/lends/:lend_ulid", auth.Allow(auth.Members...), h.GetLend) //OK
 	//r.GET("/lends/:management_number", h.ListLendsByManagementNumber) //
 
 	// 返却
-	r.POST("/lends/:lend_ulid/returns", h.CreateReturn)     //OK
-	r.GET("/lends/:lend_ulid/returns", h.ListReturnsByLend) //要修正
+	r.POST("/lends/:lend_ulid/returns", auth.Allow(auth.StaffOnly...), idem, h.CreateReturn) //OK
+	r.GET("/lends/:lend_ulid/returns", auth.Allow(auth.Members...), h.ListReturnsByLend)     //要修正
+	r.GET("/returns/:return_ulid", auth.Allow(auth.Members...), h.GetReturn)
+
+	// 紛失の届出（未返却数を閉じる。在庫には戻さない）と、その後の対応
+	r.POST("/lends/:lend_ulid/losses", auth.Allow(auth.StaffOnly...), idem, h.CreateLoss)
+	r.GET("/lends/:lend_ulid/losses", auth.Allow(auth.Members...), h.ListLossesByLend)
+	r.GET("/losses", auth.Allow(auth.Members...), h.ListLosses)
+	r.GET("/losses/:loss_ulid", auth.Allow(auth.Members...), h.GetLoss)
+	r.POST("/losses/:loss_ulid/close", auth.Allow(auth.StaffOnly...), h.CloseLoss)
+
+	// 返却期限の延長（承認した staff を記録する）
+	r.POST("/lends/:lend_ulid/renewals", auth.Allow(auth.StaffOnly...), idem, h.CreateRenewal)
+	r.GET("/lends/:lend_ulid/renewals", auth.Allow(auth.Members...), h.ListRenewalsByLend)
 }
 
 // ---------- handlers ----------
@@ -32,23 +55,38 @@ func (h *Handler) CreateLend(c *gin.Context) {
 	mng := c.Param("management_number")
 	var req CreateLendRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorBody(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 	res, err := h.svc.CreateLend(c.Request.Context(), mng, req)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.Header("Location", "/lends/"+res.LendULID)
 	c.JSON(http.StatusCreated, res)
 }
 
+func (h *Handler) CreateCheckout(c *gin.Context) {
+	var req CreateCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CreateCheckout(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/lends?checkout_ulid="+res.CheckoutULID)
+	c.JSON(http.StatusCreated, res)
+}
+
 func (h *Handler) GetLend(c *gin.Context) {
 	ul := c.Param("lend_ulid")
 	res, err := h.svc.GetLendByULID(c.Request.Context(), ul)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -59,6 +97,9 @@ func (h *Handler) ListLends(c *gin.Context) {
 	if v := c.Query("management_number"); v != "" {
 		f.ManagementNumber = &v
 	}
+	if v := c.Query("checkout_ulid"); v != "" {
+		f.CheckoutULID = &v
+	}
 	if v := c.Query("returned"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			f.Returned = &b
@@ -80,14 +121,25 @@ func (h *Handler) ListLends(c *gin.Context) {
 	if v := c.Query("only_outstanding"); v == "true" || v == "1" {
 		f.OnlyOutstanding = true
 	}
-	p := Page{
-		Limit:  parseIntDefault(c.Query("limit"), 50),
-		Offset: parseIntDefault(c.Query("offset"), 0),
-		Order:  c.DefaultQuery("order", "desc"),
+	if v := c.Query("overdue"); v == "true" || v == "1" {
+		f.Overdue = true
+	}
+	if v := c.Query("due_before"); v != "" {
+		if !ValidDate(v) {
+			apperr.Abort(c, apperr.Invalid("due_before must be a date (YYYY-MM-DD)"))
+			return
+		}
+		f.DueBefore = &v
+	}
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", "desc")}
 	res, err := h.svc.ListLends(c.Request.Context(), f, p)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -97,12 +149,12 @@ func (h *Handler) CreateReturn(c *gin.Context) {
 	luid := c.Param("lend_ulid")
 	var req CreateReturnRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorBody(CodeInvalidArgument, "invalid json"))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 	res, err := h.svc.CreateReturn(c.Request.Context(), luid, req)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.Header("Location", "/returns/"+res.ReturnULID)
@@ -111,53 +163,131 @@ func (h *Handler) CreateReturn(c *gin.Context) {
 
 func (h *Handler) ListReturnsByLend(c *gin.Context) {
 	luid := c.Param("lend_ulid")
-	p := Page{
-		Limit:  parseIntDefault(c.Query("limit"), 50),
-		Offset: parseIntDefault(c.Query("offset"), 0),
-		Order:  c.DefaultQuery("order", "desc"),
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", "desc")}
 	res, err := h.svc.ListReturnsByLend(c.Request.Context(), luid, p)
 	if err != nil {
-		c.JSON(ToHTTPStatus(err), errorFromErr(err))
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) GetReturn(c *gin.Context) {
+	res, err := h.svc.GetReturn(c.Request.Context(), c.Param("return_ulid"))
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
 
-// ---------- helpers ----------
+func (h *Handler) CreateLoss(c *gin.Context) {
+	var req CreateLossRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CreateLoss(c.Request.Context(), c.Param("lend_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/losses/"+res.LossULID)
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) ListLossesByLend(c *gin.Context) {
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	res, err := h.svc.ListLossesByLend(c.Request.Context(), c.Param("lend_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
 
-func parseIntDefault(s string, d int) int {
-	if s == "" {
-		return d
+// GET /losses?status=open|closed&borrower_id=
+func (h *Handler) ListLosses(c *gin.Context) {
+	f := LossFilter{}
+	if v := c.Query("status"); v != "" {
+		if v != LossOpen && v != LossClosed {
+			apperr.Abort(c, apperr.Invalid("status must be open or closed"))
+			return
+		}
+		f.Status = &v
+	}
+	if v := c.Query("borrower_id"); v != "" {
+		f.BorrowerID = &v
+	}
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	res, err := h.svc.ListLosses(c.Request.Context(), f, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
-	v, err := strconv.Atoi(s)
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) GetLoss(c *gin.Context) {
+	res, err := h.svc.GetLoss(c.Request.Context(), c.Param("loss_ulid"))
 	if err != nil {
-		return d
+		apperr.Abort(c, err)
+		return
 	}
-	return v
+	c.JSON(http.StatusOK, res)
 }
 
-type errorDTO struct {
-	Error struct {
-		Code    Code   `json:"code"`
-		Message string `json:"message"`
-	} `json:"error"`
+func (h *Handler) CloseLoss(c *gin.Context) {
+	var req CloseLossRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CloseLoss(c.Request.Context(), c.Param("loss_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
 }
 
-func errorBody(code Code, msg string) errorDTO {
-	var e errorDTO
-	e.Error.Code = code
-	e.Error.Message = msg
-	return e
+func (h *Handler) CreateRenewal(c *gin.Context) {
+	var req CreateRenewalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CreateRenewal(c.Request.Context(), c.Param("lend_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
 }
 
-func errorFromErr(err error) errorDTO {
-	var msg string
-	var code Code = CodeInternal
-	if api, ok := err.(*APIError); ok {
-		code, msg = api.Code, api.Message
-	} else {
-		msg = err.Error()
+func (h *Handler) ListRenewalsByLend(c *gin.Context) {
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
-	return errorBody(code, msg)
+	res, err := h.svc.ListRenewalsByLend(c.Request.Context(), c.Param("lend_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
 }
@@ -9,6 +9,7 @@ import (
 type Lend struct {
 	LendID           uint64
 	LendULID         string
+	CheckoutULID     sql.NullString // まとめて貸したときの checkout_ulid
 	AssetMasterID    uint64
 	ManagementNumber string
 	Quantity         uint
@@ -17,15 +18,69 @@ type Lend struct {
 	LentByID         sql.NullString
 	LentAt           time.Time
 	Note             sql.NullString
-	Returned         bool
+	Returned         bool // 未返却がなくなった（紛失の届出で閉じた分を含む）
 }
 
+// 返却時の状態（returns.condition_grade）
+const (
+	ConditionGood    = "good"    // 問題なし
+	ConditionWorn    = "worn"    // 使用感・軽い傷あり（在庫に戻す）
+	ConditionDamaged = "damaged" // 破損（修理中の在庫行へ回す）
+)
+
+// 紛失の届出の状態（lend_losses.status）
+const (
+	LossOpen   = "open"   // 届出済み・対応中
+	LossClosed = "closed" // 弁償・償却などで対応済み
+)
+
 type Return struct {
-	ReturnID      uint64
-	ReturnULID    string
-	LendID        uint64
-	Quantity      uint
-	ProcessedByID sql.NullString
-	ReturnedAt    time.Time
-	Note          sql.NullString
+	ReturnID          uint64
+	ReturnULID        string
+	LendID            uint64
+	Quantity          uint
+	ConditionGrade    string
+	DamageDescription sql.NullString
+	ProcessedByID     sql.NullString
+	ReturnedAt        time.Time
+	Note              sql.NullString
+}
+
+// lend_allocations と1:1
+type LendAllocation struct {
+	LendID           uint64
+	AssetID          uint64
+	Quantity         uint
+	ReturnedQuantity uint
+	LostQuantity     uint
+}
+
+// lend_renewals と1:1（返却期限の延長）
+type Renewal struct {
+	RenewalID    uint64
+	RenewalULID  string
+	LendID       uint64
+	OldDueOn     string
+	NewDueOn     string
+	ApprovedByID sql.NullString
+	Reason       string
+	RenewedAt    time.Time
+}
+
+// lend_losses と1:1（紛失の届出）。LendULID 以降は一覧用に lends / assets_master から引く
+type Loss struct {
+	LossID           uint64
+	LossULID         string
+	LendID           uint64
+	Quantity         uint
+	Description      string
+	Status           string
+	ReportedByID     sql.NullString
+	ReportedAt       time.Time
+	ClosedByID       sql.NullString
+	ClosedAt         sql.NullTime
+	Resolution       sql.NullString
+	LendULID         string
+	ManagementNumber string
+	BorrowerID       string
 }
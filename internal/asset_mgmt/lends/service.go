@@ -5,37 +5,24 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"errors"
-	"fmt"
-	"log"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	ulid "github.com/oklog/ulid/v2"
-)
-
-// -------------- Error model & mapping --------------
-
-type Code string
 
-const (
-	CodeInvalidArgument Code = "INVALID_ARGUMENT"
-	CodeNotFound        Code = "NOT_FOUND"
-	CodeConflict        Code = "CONFLICT" // 在庫不足・返却過多など
-	CodeUnprocessable   Code = "UNPROCESSABLE_ENTITY"
-	CodeInternal        Code = "INTERNAL"
+	"IRIS-backend/internal/asset_mgmt/reservations"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
 )
 
-type APIError struct {
-	Code    Code
-	Message string
-}
-
-func (e *APIError) Error() string      { return fmt.Sprintf("%s: %s", e.Code, e.Message) }
-func ErrInvalid(msg string) *APIError  { return &APIError{Code: CodeInvalidArgument, Message: msg} }
-func ErrNotFound(msg string) *APIError { return &APIError{Code: CodeNotFound, Message: msg} }
-func ErrConflict(msg string) *APIError { return &APIError{Code: CodeConflict, Message: msg} }
-func ErrInternal(msg string) *APIError { return &APIError{Code: CodeInternal, Message: msg} }
-
 // -------------- Clock & ID --------------
 
 type Clock interface{ Now() time.Time }
@@ -54,18 +41,22 @@ func (ulidGen) NewULID(t time.Time) string {
 // -------------- Service --------------
 
 type Service struct {
-	db    *sql.DB
-	store *Store
-	clock Clock
-	id    IDGen
+	db           *sql.DB
+	store        *Store
+	cfg          db.LendConfig
+	reservations *reservations.Service
+	clock        Clock
+	id           IDGen
 }
 
-func NewService(db *sql.DB) *Service {
+func NewService(db *sql.DB, cfg db.LendConfig, rsv *reservations.Service) *Service {
 	return &Service{
-		db:    db,
-		store: NewStore(db),
-		clock: realClock{},
-		id:    ulidGen{},
+		db:           db,
+		store:        NewStore(db),
+		cfg:          cfg,
+		reservations: rsv,
+		clock:        realClock{},
+		id:           ulidGen{},
 	}
 }
 
@@ -83,88 +74,319 @@ func (s *Service) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
 }
 
 // POST /assets/:management_number/lends
+// asset_id を指定すればその在庫行から、省略時は asset_id 順に貸出可能な行から割り当てる。
+// 他の人の取り置き・順番待ちがある分は貸さない。借受者本人の取り置きがあればこの貸出で受け取ったことにする
 func (s *Service) CreateLend(ctx context.Context, managementNumber string, in CreateLendRequest) (LendResponse, error) {
 	if in.Quantity == 0 {
-		return LendResponse{}, ErrInvalid("quantity must be > 0")
+		return LendResponse{}, apperr.Invalid("quantity must be > 0")
 	}
-	if strings.TrimSpace(in.BorrowerID) == "" {
-		return LendResponse{}, ErrInvalid("borrower_id required")
+	if err := checkBorrower(ctx, in.BorrowerID, in.DueOn); err != nil {
+		return LendResponse{}, err
+	}
+	out, err := s.createLends(ctx, lendHeader{BorrowerID: in.BorrowerID, DueOn: in.DueOn, Note: in.Note}, []lendItem{
+		{ManagementNumber: managementNumber, Quantity: in.Quantity, AssetID: in.AssetID},
+	})
+	if err != nil {
+		return LendResponse{}, err
+	}
+	return out[0], nil
+}
+
+// POST /lends/batch
+// 複数の管理番号を1人にまとめて貸す。すべて貸せたときだけコミットし、貸出は checkout_ulid でまとめる
+func (s *Service) CreateCheckout(ctx context.Context, in CreateCheckoutRequest) (CheckoutResponse, error) {
+	if err := checkBorrower(ctx, in.BorrowerID, in.DueOn); err != nil {
+		return CheckoutResponse{}, err
+	}
+	items := make([]lendItem, 0, len(in.Items))
+	seen := map[string]bool{}
+	verr := apperr.Invalid("validation failed")
+	for i, it := range in.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		mng := strings.TrimSpace(it.ManagementNumber)
+		switch {
+		case mng == "":
+			verr = verr.WithField(field+".management_number", "must not be empty")
+		case seen[mng]:
+			verr = verr.WithField(field+".management_number", "duplicates '"+mng+"'")
+		}
+		if it.Quantity == 0 {
+			verr = verr.WithField(field+".quantity", "must be > 0")
+		}
+		seen[mng] = true
+		items = append(items, lendItem{ManagementNumber: mng, Quantity: it.Quantity, AssetID: it.AssetID})
+	}
+	if len(verr.Fields) > 0 {
+		return CheckoutResponse{}, verr
 	}
 
 	now := s.clock.Now()
-	luid := s.id.NewULID(now)
+	checkout := s.id.NewULID(now)
+	lends, err := s.createLends(ctx, lendHeader{BorrowerID: in.BorrowerID, DueOn: in.DueOn, Note: in.Note, CheckoutULID: &checkout}, items)
+	if err != nil {
+		return CheckoutResponse{}, err
+	}
+	return CheckoutResponse{
+		CheckoutULID: checkout,
+		BorrowerID:   in.BorrowerID,
+		DueOn:        in.DueOn,
+		Lends:        lends,
+	}, nil
+}
+
+// lendHeader / lendItem: 貸出の共通部分と管理番号ごとの部分
+type lendHeader struct {
+	BorrowerID   string
+	DueOn        *string
+	Note         *string
+	CheckoutULID *string
+}
 
-	var resp LendResponse
+type lendItem struct {
+	ManagementNumber string
+	Quantity         uint
+	AssetID          *uint64
+}
+
+// lendPlan: 1件の貸出の割り当て（ロック済み）
+type lendPlan struct {
+	index    int // 入力での位置（レスポンスの順）
+	item     lendItem
+	masterID uint64
+	allocs   []stock.Allocation
+	before   map[uint64]stock.Row
+	claim    reservations.Claim
+}
+
+// createLends: 1つの Tx で全件貸す。デッドロックを避けるため、資産マスタを asset_master_id 順に並べて
+// 在庫行 → 予約の待ち行列の順でロックする（1件だけの貸出も同じ順になる）
+func (s *Service) createLends(ctx context.Context, h lendHeader, items []lendItem) ([]LendResponse, error) {
+	now := s.clock.Now()
+	lentBy := auth.ActorID(ctx)
+
+	var (
+		out   = make([]LendResponse, len(items))
+		ready []reservations.Reservation
+	)
 
 	err := s.withTx(ctx, func(tx *sql.Tx) error {
-		// Resolve master
-		masterID, err := s.store.ResolveMasterID(ctx, managementNumber)
-		if err != nil {
-			return err
+		plans := make([]*lendPlan, 0, len(items))
+		for i, it := range items {
+			masterID, err := s.store.ResolveMasterID(ctx, it.ManagementNumber)
+			if err != nil {
+				if len(items) > 1 {
+					return withItem(err, i, it.ManagementNumber)
+				}
+				return err
+			}
+			plans = append(plans, &lendPlan{index: i, item: it, masterID: masterID})
 		}
+		sort.Slice(plans, func(i, j int) bool { return plans[i].masterID < plans[j].masterID })
 
-		// Lock asset row
-		assetID, qty, err := s.store.LockAssetRow(ctx, tx, masterID)
-		if err != nil {
-			return err
+		// Lock rows & allocate
+		for _, p := range plans {
+			var err error
+			if p.allocs, p.before, err = allocateLend(ctx, tx, p.masterID, p.item.AssetID, p.item.Quantity); err != nil {
+				if len(items) > 1 {
+					return withItem(err, p.index, p.item.ManagementNumber)
+				}
+				return err
+			}
 		}
 
-		// Stock check
-		if int(qty)-int(in.Quantity) < 0 {
-			return ErrConflict("insufficient stock")
-		}
-		// Decrement stock
-		if err := s.store.UpdateAssetQuantity(ctx, tx, assetID, -int(in.Quantity)); err != nil {
-			return err
+		// 予約（在庫行のロックの後に待ち行列をロックする）
+		for _, p := range plans {
+			promoted, err := s.reservations.Promote(ctx, tx, p.masterID)
+			if err != nil {
+				return err
+			}
+			ready = append(ready, promoted...)
+			if p.claim, err = s.reservations.Claim(ctx, tx, p.masterID, h.BorrowerID); err != nil {
+				return err
+			}
+			if err := checkReserved(ctx, tx, p.masterID, p.item.Quantity, p.claim); err != nil {
+				if len(items) > 1 {
+					return withItem(err, p.index, p.item.ManagementNumber)
+				}
+				return err
+			}
 		}
 
-		// Insert lend
-		l := &Lend{
-			LendULID:         luid,
-			AssetMasterID:    masterID,
-			ManagementNumber: managementNumber,
-			Quantity:         in.Quantity,
-			BorrowerID:       in.BorrowerID,
-			DueOn:            toNullString(in.DueOn),
-			LentByID:         toNullString(in.LentByID),
-			Note:             toNullString(in.Note),
-		}
-		_, err = s.store.InsertLend(ctx, tx, l)
-		if err != nil {
-			return err
+		for _, p := range plans {
+			resp, err := s.applyLend(ctx, tx, h, p, lentBy, now)
+			if err != nil {
+				return err
+			}
+			out[p.index] = resp
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 繰り上がった取り置きの通知（この貸出で受け取った本人の分は除く）
+	s.reservations.Notify(ctx, slices.DeleteFunc(ready, func(r reservations.Reservation) bool {
+		return r.BorrowerID == h.BorrowerID
+	}))
+	return out, nil
+}
 
-		resp = LendResponse{
-			LendULID:            luid,
-			AssetMasterID:       masterID,
-			ManagementNumber:    managementNumber,
-			Quantity:            in.Quantity,
-			BorrowerID:          in.BorrowerID,
-			DueOn:               in.DueOn,
-			LentByID:            in.LentByID,
-			LentAt:              now,
-			ReturnedQuantity:    0,
-			OutstandingQuantity: in.Quantity,
-			Note:                in.Note,
+// applyLend: 割り当て済みの在庫を減らし、貸出を登録して監査ログに残す
+func (s *Service) applyLend(ctx context.Context, tx *sql.Tx, h lendHeader, p *lendPlan, lentBy *string, now time.Time) (LendResponse, error) {
+	luid := s.id.NewULID(now)
+	for _, a := range p.allocs {
+		if err := stock.Move(ctx, tx, a.AssetID, -int(a.Quantity)); err != nil {
+			return LendResponse{}, err
 		}
+	}
 
-		// 複数在庫がある場合、１つの管理番号に対して複数の状態が存在することになるのでここでUPDATEかけると
-		// sql: no rows in result setが返ってくるので，更新操作するけどエラーは無視する
-		// updateAssets status
-		statusID := 4 // 貸出中
-		if err := s.store.UpdateAssetsStatus(ctx, tx, masterID, statusID); err != nil {
-			log.Printf("failed to update assets.status: %v", err)
-			// return err
+	// Insert lend
+	l := &Lend{
+		LendULID:         luid,
+		CheckoutULID:     toNullString(h.CheckoutULID),
+		AssetMasterID:    p.masterID,
+		ManagementNumber: p.item.ManagementNumber,
+		Quantity:         p.item.Quantity,
+		BorrowerID:       h.BorrowerID,
+		DueOn:            toNullString(h.DueOn),
+		LentByID:         toNullString(lentBy),
+		Note:             toNullString(h.Note),
+	}
+	lendID, err := s.store.InsertLend(ctx, tx, l)
+	if err != nil {
+		return LendResponse{}, err
+	}
+	if err := s.store.InsertLendAllocations(ctx, tx, lendID, p.allocs); err != nil {
+		return LendResponse{}, err
+	}
+	if p.claim.Mine != nil {
+		if err := s.reservations.Fulfill(ctx, tx, *p.claim.Mine, lendID, luid); err != nil {
+			return LendResponse{}, err
 		}
+	}
 
-		// update location
-		if err := s.store.UpdateAssetOnLend(ctx, tx, l, assetID); err != nil {
-			log.Printf("failed to update assets.location: %v", err)
+	// 行ごとのステータス: 在庫を出し切った行だけ「貸出中」にする。
+	// 借用者は lends.borrower_id に持ち、在庫行の場所（location_id）は変えない
+	for _, a := range p.allocs {
+		if p.before[a.AssetID].Quantity == a.Quantity {
+			if err := stock.SetStatus(ctx, tx, p.before[a.AssetID], statuses.Lent); err != nil {
+				return LendResponse{}, err
+			}
 		}
+	}
+
+	resp := LendResponse{
+		LendULID:            luid,
+		CheckoutULID:        h.CheckoutULID,
+		AssetMasterID:       p.masterID,
+		ManagementNumber:    p.item.ManagementNumber,
+		Quantity:            p.item.Quantity,
+		BorrowerID:          h.BorrowerID,
+		DueOn:               h.DueOn,
+		LentByID:            lentBy,
+		LentAt:              now,
+		ReturnedQuantity:    0,
+		OutstandingQuantity: p.item.Quantity,
+		Note:                h.Note,
+		Allocations:         toAllocationResponses(p.allocs),
+	}
+
+	// 監査ログ（貸出本体と在庫行の変化）
+	return resp, s.recordAssetChange(ctx, tx, audit.Entry{
+		Action:     audit.ActionLend,
+		EntityType: audit.EntityLend,
+		EntityID:   luid,
+		After:      resp,
+	}, audit.ActionLend, p.before)
+}
+
+// checkBorrower: 借受者と返却期限の検証（member は自分名義のみ）
+func checkBorrower(ctx context.Context, borrowerID string, dueOn *string) error {
+	if strings.TrimSpace(borrowerID) == "" {
+		return apperr.Invalid("borrower_id required")
+	}
+	if p, ok := auth.FromContext(ctx); ok && p.Role == auth.RoleMember && p.LoginID != borrowerID {
+		return apperr.Forbidden("members can only create lends for themselves")
+	}
+	if dueOn != nil && strings.TrimSpace(*dueOn) != "" && !ValidDate(*dueOn) {
+		return apperr.Invalid("validation failed").WithField("due_on", "must be a date (YYYY-MM-DD)")
+	}
+	return nil
+}
 
+// withItem: まとめて貸すときに、どの明細で失敗したかをエラーに添える
+func withItem(err error, index int, mng string) error {
+	var ae *apperr.Error
+	if errors.As(err, &ae) {
+		return ae.WithDetail("item_index", index).WithDetail("management_number", mng)
+	}
+	return err
+}
+
+// checkReserved: 他の人が順番待ちしていれば貸さない。取り置き分を除いた在庫で足りるかも見る
+func checkReserved(ctx context.Context, tx *sql.Tx, masterID uint64, qty uint, c reservations.Claim) error {
+	if c.Mine == nil && c.WaitingOthers > 0 {
+		return apperr.Conflict("item is reserved by others").WithDetail("waiting", c.WaitingOthers)
+	}
+	if c.HeldByOthers == 0 {
 		return nil
-	})
-	return resp, err
+	}
+	rows, err := stock.Rows(ctx, tx, masterID)
+	if err != nil {
+		return err
+	}
+	g, err := statuses.LoadGraph(ctx, tx)
+	if err != nil {
+		return err
+	}
+	var free uint
+	if lendable := stock.Total(rows, stock.Lendable(g)); lendable > c.HeldByOthers {
+		free = lendable - c.HeldByOthers
+	}
+	if qty > free {
+		return apperr.Conflict("insufficient stock").
+			WithDetail("available", free).
+			WithDetail("reserved", c.HeldByOthers)
+	}
+	return nil
+}
+
+// allocateLend: 在庫行をロックして貸出数を割り当てる。before は割り当てた行のロック時点の値
+func allocateLend(ctx context.Context, tx *sql.Tx, masterID uint64, assetID *uint64, qty uint) ([]stock.Allocation, map[uint64]stock.Row, error) {
+	var rows []stock.Row
+	if assetID != nil {
+		r, err := stock.LockRow(ctx, tx, masterID, *assetID)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 指定行が貸し出せないステータスなら、遷移表のエラーをそのまま返す
+		if err := statuses.CheckTransition(ctx, tx, r.StatusID, statuses.Lent); err != nil {
+			return nil, nil, err
+		}
+		rows = []stock.Row{r}
+	} else {
+		var err error
+		if rows, err = stock.LockRows(ctx, tx, masterID); err != nil {
+			return nil, nil, err
+		}
+	}
+	g, err := statuses.LoadGraph(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	allocs, err := stock.Allocate(rows, qty, stock.Lendable(g))
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint64]stock.Row, len(rows))
+	for _, r := range rows {
+		byID[r.AssetID] = r
+	}
+	before := make(map[uint64]stock.Row, len(allocs))
+	for _, a := range allocs {
+		before[a.AssetID] = byID[a.AssetID]
+	}
+	return allocs, before, nil
 }
 
 func (s *Service) GetLendByULID(ctx context.Context, lendULID string) (LendResponse, error) {
@@ -172,16 +394,15 @@ func (s *Service) GetLendByULID(ctx context.Context, lendULID string) (LendRespo
 	if err != nil {
 		return LendResponse{}, err
 	}
+	if err := checkBorrowerAccess(ctx, m.BorrowerID); err != nil {
+		return LendResponse{}, err
+	}
 
 	// sum returns
 	sum, err := s.store.SumReturned(ctx, m.LendID)
 	if err != nil {
 		return LendResponse{}, err
 	}
-	outstanding := uint(0)
-	if m.Quantity > sum {
-		outstanding = m.Quantity - sum
-	}
 
 	// management_number join
 	var mng string
@@ -193,8 +414,24 @@ func (s *Service) GetLendByULID(ctx context.Context, lendULID string) (LendRespo
 		}
 	}
 
+	allocs, err := s.store.ListLendAllocations(ctx, m.LendID)
+	if err != nil {
+		return LendResponse{}, err
+	}
+	items := make([]AllocationResponse, 0, len(allocs))
+	var lost uint
+	for _, a := range allocs {
+		items = append(items, AllocationResponse{AssetID: a.AssetID, Quantity: a.Quantity, ReturnedQuantity: a.ReturnedQuantity, LostQuantity: a.LostQuantity})
+		lost += a.LostQuantity
+	}
+	outstanding := uint(0)
+	if m.Quantity > sum+lost {
+		outstanding = m.Quantity - sum - lost
+	}
+
 	return LendResponse{
 		LendULID:            m.LendULID,
+		CheckoutULID:        nullToPtr(m.CheckoutULID),
 		AssetMasterID:       m.AssetMasterID,
 		ManagementNumber:    mng,
 		Quantity:            m.Quantity,
@@ -203,31 +440,38 @@ func (s *Service) GetLendByULID(ctx context.Context, lendULID string) (LendRespo
 		LentByID:            nullToPtr(m.LentByID),
 		LentAt:              m.LentAt,
 		ReturnedQuantity:    sum,
+		LostQuantity:        lost,
 		OutstandingQuantity: outstanding,
 		Note:                nullToPtr(m.Note),
+		Returned:            m.Returned,
+		Overdue:             isOverdue(m.DueOn, outstanding, s.today()),
+		Allocations:         items,
 	}, nil
 }
 
-type ListLendsResult struct {
-	Items      []LendResponse `json:"items"`
-	Total      int64          `json:"total"`
-	NextOffset int            `json:"next_offset"`
-}
-
-func (s *Service) ListLends(ctx context.Context, f LendFilter, p Page) (ListLendsResult, error) {
-	rows, total, err := s.store.ListLends(ctx, f, p)
+func (s *Service) ListLends(ctx context.Context, f LendFilter, p Page) (pagination.Page[LendResponse], error) {
+	// member は自分名義の貸出のみ
+	if pr, ok := auth.FromContext(ctx); ok && pr.Role == auth.RoleMember {
+		f.BorrowerID = &pr.LoginID
+	}
+	f.today = s.today()
+	rows, err := s.store.ListLends(ctx, f, p)
 	if err != nil {
-		return ListLendsResult{}, err
+		return pagination.Page[LendResponse]{}, err
 	}
 
-	items := make([]LendResponse, 0, len(rows))
-	for _, r := range rows {
+	sort, _ := lendOrder(p)
+	page := pagination.Build(p.Request, rows, func(last lendRow) string {
+		return pagination.Encode(sort, last.Lend.LentAt, last.Lend.LendID)
+	})
+	return pagination.Map(page, func(r lendRow) LendResponse {
 		outstanding := uint(0)
-		if r.Lend.Quantity > r.ReturnedSum {
-			outstanding = r.Lend.Quantity - r.ReturnedSum
+		if r.Lend.Quantity > r.ReturnedSum+r.LostSum {
+			outstanding = r.Lend.Quantity - r.ReturnedSum - r.LostSum
 		}
-		items = append(items, LendResponse{
+		return LendResponse{
 			LendULID:            r.Lend.LendULID,
+			CheckoutULID:        nullToPtr(r.Lend.CheckoutULID),
 			AssetMasterID:       r.Lend.AssetMasterID,
 			ManagementNumber:    r.ManagementNumber,
 			Quantity:            r.Lend.Quantity,
@@ -236,141 +480,707 @@ func (s *Service) ListLends(ctx context.Context, f LendFilter, p Page) (ListLend
 			LentByID:            nullToPtr(r.Lend.LentByID),
 			LentAt:              r.Lend.LentAt,
 			ReturnedQuantity:    r.ReturnedSum,
+			LostQuantity:        r.LostSum,
 			OutstandingQuantity: outstanding,
 			Note:                nullToPtr(r.Lend.Note),
 			Returned:            r.Lend.Returned,
-		})
-	}
-
-	next := p.Offset + p.Limit
-	if next >= int(total) {
-		next = 0
-	} // 0=終端
-	return ListLendsResult{Items: items, Total: total, NextOffset: next}, nil
-}
-
-type ListReturnsResult struct {
-	Items      []ReturnResponse `json:"items"`
-	Total      int64            `json:"total"`
-	NextOffset int              `json:"next_offset"`
+			Overdue:             isOverdue(r.Lend.DueOn, outstanding, f.today),
+		}
+	}), nil
 }
 
-func (s *Service) ListReturnsByLend(ctx context.Context, lendULID string, p Page) (ListReturnsResult, error) {
+func (s *Service) ListReturnsByLend(ctx context.Context, lendULID string, p Page) (pagination.Page[ReturnResponse], error) {
 	// resolve lend_id
 	l, err := s.store.GetLendByULID(ctx, lendULID)
 	if err != nil {
-		return ListReturnsResult{}, err
+		return pagination.Page[ReturnResponse]{}, err
+	}
+	if err := checkBorrowerAccess(ctx, l.BorrowerID); err != nil {
+		return pagination.Page[ReturnResponse]{}, err
 	}
 
-	items, total, err := s.store.ListReturnsByLend(ctx, l.LendID, p)
+	items, err := s.store.ListReturnsByLend(ctx, l.LendID, p)
 	if err != nil {
-		return ListReturnsResult{}, err
-	}
-
-	res := make([]ReturnResponse, 0, len(items))
-	for _, it := range items {
-		res = append(res, ReturnResponse{
-			ReturnULID:    it.ReturnULID,
-			LendULID:      lendULID,
-			Quantity:      it.Quantity,
-			ProcessedByID: nullToPtr(it.ProcessedByID),
-			ReturnedAt:    it.ReturnedAt,
-			Note:          nullToPtr(it.Note),
-		})
+		return pagination.Page[ReturnResponse]{}, err
 	}
-	next := p.Offset + p.Limit
-	if next >= int(total) {
-		next = 0
+
+	sort, _ := returnOrder(p)
+	page := pagination.Build(p.Request, items, func(last Return) string {
+		return pagination.Encode(sort, last.ReturnedAt, last.ReturnID)
+	})
+	return pagination.Map(page, func(it Return) ReturnResponse {
+		return toReturnResponse(it, lendULID)
+	}), nil
+}
+
+// GET /returns/:return_ulid（member は自分名義の貸出の分のみ）
+func (s *Service) GetReturn(ctx context.Context, returnULID string) (ReturnResponse, error) {
+	r, l, err := s.store.GetReturnByULID(ctx, returnULID)
+	if err != nil {
+		return ReturnResponse{}, err
 	}
-	return ListReturnsResult{Items: res, Total: total, NextOffset: next}, nil
+	if err := checkBorrowerAccess(ctx, l.BorrowerID); err != nil {
+		return ReturnResponse{}, err
+	}
+	return toReturnResponse(r, l.LendULID), nil
 }
 
 // POST /lends/:lend_ulid/returns
+// 貸し出した在庫行へ戻す。asset_id を指定すればその行の分だけ、省略時は asset_id 順に戻す。
+// condition が damaged の分は在庫に戻さず修理中にする
 func (s *Service) CreateReturn(ctx context.Context, lendULID string, in CreateReturnRequest) (ReturnResponse, error) {
 	if in.Quantity == 0 {
-		return ReturnResponse{}, ErrInvalid("quantity must be > 0")
+		return ReturnResponse{}, apperr.Invalid("quantity must be > 0")
+	}
+	cond := ConditionGood
+	if in.Condition != nil && *in.Condition != "" {
+		cond = *in.Condition
+	}
+	damage := toNullString(in.DamageDescription)
+	if cond == ConditionDamaged && !damage.Valid {
+		return ReturnResponse{}, apperr.Invalid("validation failed").WithField("damage_description", "is required when condition is damaged")
 	}
 	now := s.clock.Now()
 	ruid := s.id.NewULID(now)
+	processedBy := auth.ActorID(ctx)
 
-	var resp ReturnResponse
+	var (
+		resp   ReturnResponse
+		ready  []reservations.Reservation
+		repair []stock.Allocation
+	)
 
 	err := s.withTx(ctx, func(tx *sql.Tx) error {
-		// Get Lend & returned sum
 		l, err := s.store.GetLendByULID(ctx, lendULID)
 		if err != nil {
 			return err
 		}
-		sum, err := s.store.SumReturned(ctx, l.LendID)
+
+		// 割り当てをロックしてから未返却数を見る（同時返却での返却過多を防ぐ）
+		allocs, err := s.store.LockLendAllocations(ctx, tx, l.LendID)
 		if err != nil {
 			return err
 		}
-		outstanding := uint(0)
-		if l.Quantity > sum {
-			outstanding = l.Quantity - sum
-		}
-		if in.Quantity > outstanding {
-			return ErrConflict("over return")
+		credits, err := planReturn(allocs, in.AssetID, in.Quantity)
+		if err != nil {
+			return err
 		}
 
-		// lock asset row and add stock
-		assetID, _, err := s.store.LockAssetRow(ctx, tx, l.AssetMasterID)
+		ids := make([]uint64, 0, len(credits))
+		for _, c := range credits {
+			ids = append(ids, c.AssetID)
+		}
+		locked, err := stock.LockByIDs(ctx, tx, ids)
 		if err != nil {
 			return err
 		}
-		if err := s.store.UpdateAssetQuantity(ctx, tx, assetID, int(in.Quantity)); err != nil {
-			return err
+
+		for _, c := range credits {
+			if err := s.store.AddReturnedQuantity(ctx, tx, l.LendID, c.AssetID, c.Quantity); err != nil {
+				return err
+			}
+			if cond == ConditionDamaged {
+				to, err := s.toRepair(ctx, tx, locked[c.AssetID], c.Quantity)
+				if err != nil {
+					return err
+				}
+				repair = append(repair, stock.Allocation{AssetID: to, Quantity: c.Quantity})
+				continue
+			}
+			if err := stock.Move(ctx, tx, c.AssetID, int(c.Quantity)); err != nil {
+				return err
+			}
+			if locked[c.AssetID].StatusID == statuses.Lent {
+				if err := stock.SetStatus(ctx, tx, locked[c.AssetID], statuses.Available); err != nil {
+					return err
+				}
+			}
 		}
 
 		// insert return
 		r := &Return{
-			ReturnULID:    ruid,
-			LendID:        l.LendID,
-			Quantity:      in.Quantity,
-			ProcessedByID: toNullString(in.ProcessedByID),
-			Note:          toNullString(in.Note),
+			ReturnULID:        ruid,
+			LendID:            l.LendID,
+			Quantity:          in.Quantity,
+			ConditionGrade:    cond,
+			DamageDescription: damage,
+			ProcessedByID:     toNullString(processedBy),
+			Note:              toNullString(in.Note),
 		}
 		if _, err := s.store.InsertReturn(ctx, tx, r); err != nil {
 			return err
 		}
 
+		// 全数戻ったときだけ返却済みにする
+		if outstandingOf(allocs) == in.Quantity {
+			if err := s.store.UpdateLendReturnedStatus(ctx, tx, l.LendULID); err != nil {
+				return err
+			}
+		}
+
+		// 戻った在庫を順番待ちの先頭から取り置きへ回す
+		if ready, err = s.reservations.Promote(ctx, tx, l.AssetMasterID); err != nil {
+			return err
+		}
+
 		resp = ReturnResponse{
-			ReturnULID:    ruid,
-			LendULID:      lendULID,
-			Quantity:      in.Quantity,
-			ProcessedByID: in.ProcessedByID,
-			ReturnedAt:    now,
-			Note:          in.Note,
+			ReturnULID:        ruid,
+			LendULID:          lendULID,
+			Quantity:          in.Quantity,
+			Condition:         cond,
+			DamageDescription: nullToPtr(damage),
+			ProcessedByID:     processedBy,
+			ReturnedAt:        now,
+			Note:              in.Note,
+			Allocations:       credits,
+			RepairAllocations: repair,
 		}
 
-		// updateAssets status
-		// 複数在庫がある場合、１つの管理番号に対して複数の状態が存在することになるのでここでUPDATEかけると
-		// sql: no rows in result setが返ってくるので，更新操作するけどエラーは無視する
-		statusID := 1 // 利用可能
-		if err := s.store.UpdateAssetsStatus(ctx, tx, l.AssetMasterID, statusID); err != nil {
-			log.Printf("failed to update assets.status: %v", err)
-			//return err
+		if err := s.recordAssetChange(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityReturn,
+			EntityID:   ruid,
+			After:      resp,
+		}, audit.ActionReturn, locked); err != nil {
+			return err
+		}
+		// 修理中として分けて作った行は作成として残す
+		for _, a := range repair {
+			if _, ok := locked[a.AssetID]; ok {
+				continue
+			}
+			created, err := stock.Snapshot(ctx, tx, a.AssetID)
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, tx, audit.Entry{
+				Action:     audit.ActionCreate,
+				EntityType: audit.EntityAsset,
+				EntityID:   strconv.FormatUint(a.AssetID, 10),
+				After:      created,
+			}); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return ReturnResponse{}, err
+	}
+	s.reservations.Notify(ctx, ready)
+	return resp, nil
+}
+
+// POST /lends/:lend_ulid/losses
+// 紛失の届出。未返却数を閉じるだけで在庫には戻さない（quantity 省略時は未返却の全数）。
+// 在庫を出し切って貸出中になっている行で、これで未返却がなくなるならその行を紛失にする
+func (s *Service) CreateLoss(ctx context.Context, lendULID string, in CreateLossRequest) (LossResponse, error) {
+	desc := strings.TrimSpace(in.Description)
+	if desc == "" {
+		return LossResponse{}, apperr.Invalid("validation failed").WithField("description", "must not be empty")
+	}
+	if in.Quantity != nil && *in.Quantity == 0 {
+		return LossResponse{}, apperr.Invalid("quantity must be > 0")
+	}
+	now := s.clock.Now()
+	luid := s.id.NewULID(now)
+	reportedBy := auth.ActorID(ctx)
+
+	var resp LossResponse
 
-		// update lend returned status
-		if err := s.store.UpdateLendReturnedStatus(ctx, tx, l.LendULID); err != nil {
-			log.Printf("failed to update lends.returned_status: %v", err)
-			//return err
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		l, err := s.store.GetLendByULID(ctx, lendULID)
+		if err != nil {
+			return err
 		}
 
-		// update location
-		l.BorrowerID = "" // 返却したのでlocationを空にする
-		if err := s.store.UpdateAssetOnLend(ctx, tx, l, assetID); err != nil {
-			log.Printf("failed to update assets.location: %v", err)
+		// 割り当てをロックしてから未返却数を見る（返却との同時実行で数え違えないように）
+		allocs, err := s.store.LockLendAllocations(ctx, tx, l.LendID)
+		if err != nil {
+			return err
+		}
+		var open uint
+		for _, a := range allocs {
+			if in.AssetID == nil || a.AssetID == *in.AssetID {
+				open += a.Quantity - a.ReturnedQuantity - a.LostQuantity
+			}
+		}
+		if open == 0 {
+			return apperr.Conflict("nothing outstanding to declare lost")
+		}
+		qty := open
+		if in.Quantity != nil {
+			qty = *in.Quantity
+		}
+		if qty > open {
+			return apperr.Conflict("quantity exceeds outstanding").WithDetail("outstanding", open)
+		}
+		credits, err := planReturn(allocs, in.AssetID, qty)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		ids := make([]uint64, 0, len(credits))
+		for _, c := range credits {
+			ids = append(ids, c.AssetID)
+		}
+		locked, err := stock.LockByIDs(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		for _, c := range credits {
+			if err := s.store.AddLostQuantity(ctx, tx, l.LendID, c.AssetID, c.Quantity); err != nil {
+				return err
+			}
+			row := locked[c.AssetID]
+			if row.Quantity != 0 || row.StatusID != statuses.Lent {
+				continue
+			}
+			n, err := s.store.OutstandingOnAsset(ctx, tx, row.AssetID)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				if err := stock.SetStatus(ctx, tx, row, statuses.Lost); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.store.InsertLoss(ctx, tx, &Loss{
+			LossULID:     luid,
+			LendID:       l.LendID,
+			Quantity:     qty,
+			Description:  desc,
+			Status:       LossOpen,
+			ReportedByID: toNullString(reportedBy),
+			ReportedAt:   now,
+		}); err != nil {
+			return err
+		}
+
+		// 未返却がなくなったら貸出を閉じる
+		if outstandingOf(allocs) == qty {
+			if err := s.store.UpdateLendReturnedStatus(ctx, tx, l.LendULID); err != nil {
+				return err
+			}
+		}
+
+		m, err := s.store.getLoss(ctx, tx, luid, "")
+		if err != nil {
+			return err
+		}
+		resp = toLossResponse(m)
+		resp.Allocations = credits
+
+		return s.recordAssetChange(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityLoss,
+			EntityID:   luid,
+			After:      resp,
+		}, audit.ActionLose, locked)
+	})
+	if err != nil {
+		return LossResponse{}, err
+	}
+	return resp, nil
+}
+
+// POST /losses/:loss_ulid/close
+// 紛失の届出を対応済みにする（弁償・償却など。在庫は動かさない）
+func (s *Service) CloseLoss(ctx context.Context, lossULID string, in CloseLossRequest) (LossResponse, error) {
+	resolution := strings.TrimSpace(in.Resolution)
+	if resolution == "" {
+		return LossResponse{}, apperr.Invalid("validation failed").WithField("resolution", "must not be empty")
+	}
+	now := s.clock.Now()
+	closedBy := toNullString(auth.ActorID(ctx))
+
+	var resp LossResponse
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		m, err := s.store.LockLossByULID(ctx, tx, lossULID)
+		if err != nil {
+			return err
+		}
+		if m.Status == LossClosed {
+			return apperr.Conflict("loss is already closed")
+		}
+		before := toLossResponse(m)
+		if err := s.store.CloseLoss(ctx, tx, m.LossID, closedBy, now, resolution); err != nil {
+			return err
+		}
+		m.Status = LossClosed
+		m.ClosedByID = closedBy
+		m.ClosedAt = sql.NullTime{Time: now, Valid: true}
+		m.Resolution = sql.NullString{String: resolution, Valid: true}
+		resp = toLossResponse(m)
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityLoss,
+			EntityID:   m.LossULID,
+			Before:     before,
+			After:      resp,
+		})
+	})
+	return resp, err
+}
+
+// GET /losses/:loss_ulid（member は自分名義の貸出の分のみ）
+func (s *Service) GetLoss(ctx context.Context, lossULID string) (LossResponse, error) {
+	m, err := s.store.GetLossByULID(ctx, lossULID)
+	if err != nil {
+		return LossResponse{}, err
+	}
+	if err := checkBorrowerAccess(ctx, m.BorrowerID); err != nil {
+		return LossResponse{}, err
+	}
+	return toLossResponse(m), nil
+}
+
+// GET /losses（member は自分名義の貸出の分のみ）
+func (s *Service) ListLosses(ctx context.Context, f LossFilter, p pagination.Request) (pagination.Page[LossResponse], error) {
+	if pr, ok := auth.FromContext(ctx); ok && pr.Role == auth.RoleMember {
+		f.BorrowerID = &pr.LoginID
+	}
+	return s.listLosses(ctx, nil, f, p)
+}
+
+func (s *Service) ListLossesByLend(ctx context.Context, lendULID string, p pagination.Request) (pagination.Page[LossResponse], error) {
+	l, err := s.store.GetLendByULID(ctx, lendULID)
+	if err != nil {
+		return pagination.Page[LossResponse]{}, err
+	}
+	if err := checkBorrowerAccess(ctx, l.BorrowerID); err != nil {
+		return pagination.Page[LossResponse]{}, err
+	}
+	return s.listLosses(ctx, &l.LendID, LossFilter{}, p)
+}
+
+func (s *Service) listLosses(ctx context.Context, lendID *uint64, f LossFilter, p pagination.Request) (pagination.Page[LossResponse], error) {
+	items, err := s.store.ListLosses(ctx, lendID, f, p)
+	if err != nil {
+		return pagination.Page[LossResponse]{}, err
+	}
+	page := pagination.Build(p, items, func(last Loss) string {
+		return pagination.Encode(lossSort, last.LossID)
+	})
+	return pagination.Map(page, toLossResponse), nil
+}
+
+// POST /lends/:lend_ulid/renewals
+// 返却期限を延ばす。延長回数（設定の max_renewals）、貸出日からの期間（ジャンルの max_loan_days）、
+// 他の人の順番待ちの有無を、貸出をロックしてから確かめる
+func (s *Service) CreateRenewal(ctx context.Context, lendULID string, in CreateRenewalRequest) (RenewalResponse, error) {
+	newDue := strings.TrimSpace(in.DueOn)
+	reason := strings.TrimSpace(in.Reason)
+	verr := apperr.Invalid("validation failed")
+	if !ValidDate(newDue) {
+		verr = verr.WithField("due_on", "must be a date (YYYY-MM-DD)")
+	} else if newDue < s.today() {
+		verr = verr.WithField("due_on", "must not be in the past")
+	}
+	if reason == "" {
+		verr = verr.WithField("reason", "must not be empty")
+	}
+	if len(verr.Fields) > 0 {
+		return RenewalResponse{}, verr
+	}
+
+	now := s.clock.Now()
+	ruid := s.id.NewULID(now)
+	approvedBy := auth.ActorID(ctx)
+
+	var resp RenewalResponse
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		l, err := s.store.LockLendByULID(ctx, tx, lendULID)
+		if err != nil {
+			return err
+		}
+		if l.Returned {
+			return apperr.Conflict("lend is already returned")
+		}
+		if !l.DueOn.Valid {
+			return apperr.Conflict("lend has no due_on to extend")
+		}
+		oldDue := dateOnly(l.DueOn.String)
+		if newDue <= oldDue {
+			return apperr.Invalid("validation failed").
+				WithField("due_on", "must be after the current due_on").
+				WithDetail("current_due_on", oldDue)
+		}
+
+		if s.cfg.MaxRenewals > 0 {
+			n, err := s.store.CountRenewals(ctx, tx, l.LendID)
+			if err != nil {
+				return err
+			}
+			if n >= s.cfg.MaxRenewals {
+				return apperr.Conflict("renewal limit reached").
+					WithDetail("renewal_count", n).
+					WithDetail("max_renewals", s.cfg.MaxRenewals)
+			}
+		}
+
+		maxDays, err := s.store.MaxLoanDays(ctx, tx, l.AssetMasterID)
+		if err != nil {
+			return err
+		}
+		if maxDays.Valid {
+			lentOn := l.LentAt.In(time.Local).Format(time.DateOnly)
+			if days := daysBetween(lentOn, newDue); days > int(maxDays.Int32) {
+				latest, _ := time.Parse(time.DateOnly, lentOn)
+				return apperr.Conflict("loan period exceeds the limit for this genre").
+					WithDetail("max_loan_days", maxDays.Int32).
+					WithDetail("latest_due_on", latest.AddDate(0, 0, int(maxDays.Int32)).Format(time.DateOnly))
+			}
+		}
+
+		waiting, err := s.reservations.HasWaiting(ctx, tx, l.AssetMasterID, l.BorrowerID)
+		if err != nil {
+			return err
+		}
+		if waiting {
+			return apperr.Conflict("cannot renew while others are waiting for this item")
+		}
+
+		if err := s.store.UpdateDueOn(ctx, tx, l.LendID, newDue); err != nil {
+			return err
+		}
+		r := &Renewal{
+			RenewalULID:  ruid,
+			LendID:       l.LendID,
+			OldDueOn:     oldDue,
+			NewDueOn:     newDue,
+			ApprovedByID: toNullString(approvedBy),
+			Reason:       reason,
+			RenewedAt:    now,
+		}
+		if err := s.store.InsertRenewal(ctx, tx, r); err != nil {
+			return err
+		}
+		resp = toRenewalResponse(*r, l.LendULID)
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionRenew,
+			EntityType: audit.EntityLend,
+			EntityID:   l.LendULID,
+			Before:     map[string]string{"due_on": oldDue},
+			After:      resp,
+		})
 	})
 	return resp, err
 }
 
+func (s *Service) ListRenewalsByLend(ctx context.Context, lendULID string, p pagination.Request) (pagination.Page[RenewalResponse], error) {
+	l, err := s.store.GetLendByULID(ctx, lendULID)
+	if err != nil {
+		return pagination.Page[RenewalResponse]{}, err
+	}
+	if err := checkBorrowerAccess(ctx, l.BorrowerID); err != nil {
+		return pagination.Page[RenewalResponse]{}, err
+	}
+	items, err := s.store.ListRenewalsByLend(ctx, l.LendID, p)
+	if err != nil {
+		return pagination.Page[RenewalResponse]{}, err
+	}
+	page := pagination.Build(p, items, func(last Renewal) string {
+		return pagination.Encode(renewalSort, last.RenewalID)
+	})
+	return pagination.Map(page, func(r Renewal) RenewalResponse {
+		return toRenewalResponse(r, l.LendULID)
+	}), nil
+}
+
+// toRepair: 破損で戻った qty 個を修理中にする。在庫を出し切って貸出中になっている行で、これで未返却がなくなるなら
+// その行ごと修理中にし、そうでなければ（在庫や他の未返却が残る）修理中の行を分けて作る。修理中にした行の asset_id を返す。
+// 返却数（lend_allocations）を加算した後に呼ぶ
+func (s *Service) toRepair(ctx context.Context, tx *sql.Tx, r stock.Row, qty uint) (uint64, error) {
+	if r.Quantity == 0 && r.StatusID == statuses.Lent {
+		open, err := s.store.OutstandingOnAsset(ctx, tx, r.AssetID)
+		if err != nil {
+			return 0, err
+		}
+		if open == 0 {
+			if err := stock.Move(ctx, tx, r.AssetID, int(qty)); err != nil {
+				return 0, err
+			}
+			return r.AssetID, stock.SetStatus(ctx, tx, r, statuses.Repair)
+		}
+	}
+	repair := statuses.Repair
+	return stock.Split(ctx, tx, r.AssetID, qty, stock.SplitTo{StatusID: &repair})
+}
+
+// planReturn: 未返却の割り当てから返却数を asset_id 順に割り振る
+func planReturn(allocs []LendAllocation, assetID *uint64, qty uint) ([]stock.Allocation, error) {
+	var (
+		out       []stock.Allocation
+		remaining = qty
+	)
+	for _, a := range allocs {
+		if remaining == 0 {
+			break
+		}
+		if assetID != nil && a.AssetID != *assetID {
+			continue
+		}
+		open := a.Quantity - a.ReturnedQuantity - a.LostQuantity
+		if open == 0 {
+			continue
+		}
+		n := open
+		if n > remaining {
+			n = remaining
+		}
+		out = append(out, stock.Allocation{AssetID: a.AssetID, Quantity: n})
+		remaining -= n
+	}
+	if remaining > 0 {
+		if assetID != nil {
+			return nil, apperr.Conflict("over return for this asset_id")
+		}
+		return nil, apperr.Conflict("over return")
+	}
+	return out, nil
+}
+
+func outstandingOf(allocs []LendAllocation) uint {
+	var n uint
+	for _, a := range allocs {
+		n += a.Quantity - a.ReturnedQuantity - a.LostQuantity
+	}
+	return n
+}
+
+// recordAssetChange: 貸出/返却レコードと、それに伴う在庫行の変化を同じトランザクションで記録する
+func (s *Service) recordAssetChange(ctx context.Context, tx *sql.Tx, e audit.Entry, assetAction string, before map[uint64]stock.Row) error {
+	if err := audit.Record(ctx, tx, e); err != nil {
+		return err
+	}
+	ids := make([]uint64, 0, len(before))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		after, err := stock.Snapshot(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, tx, audit.Entry{
+			Action:     assetAction,
+			EntityType: audit.EntityAsset,
+			EntityID:   strconv.FormatUint(id, 10),
+			Before:     before[id],
+			After:      after,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toAllocationResponses(allocs []stock.Allocation) []AllocationResponse {
+	out := make([]AllocationResponse, 0, len(allocs))
+	for _, a := range allocs {
+		out = append(out, AllocationResponse{AssetID: a.AssetID, Quantity: a.Quantity})
+	}
+	return out
+}
+
 // helpers
 
+// ValidDate: "YYYY-MM-DD" として読めるか
+func ValidDate(v string) bool {
+	_, err := time.Parse(time.DateOnly, v)
+	return err == nil
+}
+
+// today: 返却期限の判定に使う今日の日付（期限は日付で持つのでサーバーのローカル日付で比べる）
+func (s *Service) today() string {
+	return s.clock.Now().In(time.Local).Format(time.DateOnly)
+}
+
+// isOverdue: 未返却分が残っていて、期限日が今日より前
+func isOverdue(due sql.NullString, outstanding uint, today string) bool {
+	if !due.Valid || outstanding == 0 {
+		return false
+	}
+	return dateOnly(due.String) < today
+}
+
+// dateOnly: parseTime の DATE は RFC3339 文字列で入ってくるので日付部分だけにする
+func dateOnly(v string) string {
+	if len(v) > len(time.DateOnly) {
+		return v[:len(time.DateOnly)]
+	}
+	return v
+}
+
+// daysBetween: "YYYY-MM-DD" 同士の日数（to - from）
+func daysBetween(from, to string) int {
+	f, _ := time.Parse(time.DateOnly, from)
+	t, _ := time.Parse(time.DateOnly, to)
+	return int(t.Sub(f).Hours() / 24)
+}
+
+func toRenewalResponse(r Renewal, lendULID string) RenewalResponse {
+	return RenewalResponse{
+		RenewalULID:  r.RenewalULID,
+		LendULID:     lendULID,
+		OldDueOn:     r.OldDueOn,
+		NewDueOn:     r.NewDueOn,
+		ApprovedByID: nullToPtr(r.ApprovedByID),
+		Reason:       r.Reason,
+		RenewedAt:    r.RenewedAt,
+	}
+}
+
+func toReturnResponse(r Return, lendULID string) ReturnResponse {
+	return ReturnResponse{
+		ReturnULID:        r.ReturnULID,
+		LendULID:          lendULID,
+		Quantity:          r.Quantity,
+		Condition:         r.ConditionGrade,
+		DamageDescription: nullToPtr(r.DamageDescription),
+		ProcessedByID:     nullToPtr(r.ProcessedByID),
+		ReturnedAt:        r.ReturnedAt,
+		Note:              nullToPtr(r.Note),
+	}
+}
+
+func toLossResponse(m Loss) LossResponse {
+	out := LossResponse{
+		LossULID:         m.LossULID,
+		LendULID:         m.LendULID,
+		ManagementNumber: m.ManagementNumber,
+		BorrowerID:       m.BorrowerID,
+		Quantity:         m.Quantity,
+		Description:      m.Description,
+		Status:           m.Status,
+		ReportedByID:     nullToPtr(m.ReportedByID),
+		ReportedAt:       m.ReportedAt,
+		ClosedByID:       nullToPtr(m.ClosedByID),
+		Resolution:       nullToPtr(m.Resolution),
+	}
+	if m.ClosedAt.Valid {
+		t := m.ClosedAt.Time
+		out.ClosedAt = &t
+	}
+	return out
+}
+
+// checkBorrowerAccess: member が他人名義の貸出を参照しようとした場合は 403
+func checkBorrowerAccess(ctx context.Context, borrowerID string) error {
+	if p, ok := auth.FromContext(ctx); ok && p.Role == auth.RoleMember && p.LoginID != borrowerID {
+		return apperr.Forbidden("members can only access their own lends")
+	}
+	return nil
+}
+
 func toNullString(s *string) (ns sql.NullString) {
 	if s != nil && strings.TrimSpace(*s) != "" {
 		ns.Valid, ns.String = true, *s
@@ -385,24 +1195,3 @@ func nullToPtr(ns sql.NullString) *string {
 	}
 	return nil
 }
-
-// -------------- Error helpers for handler --------------
-
-func ToHTTPStatus(err error) int {
-	var api *APIError
-	if errors.As(err, &api) {
-		switch api.Code {
-		case CodeInvalidArgument:
-			return 400
-		case CodeNotFound:
-			return 404
-		case CodeConflict:
-			return 409
-		case CodeUnprocessable:
-			return 422
-		default:
-			return 500
-		}
-	}
-	return 500
-}
@@ -3,9 +3,13 @@ package lends
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"strings"
 	"time"
+
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type Store struct {
@@ -20,50 +24,25 @@ func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (u
 	var id uint64
 	if err := s.db.QueryRowContext(ctx, q, managementNumber).Scan(&id); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, ErrNotFound("assets_master not found")
+			return 0, apperr.NotFound("assets_master not found")
 		}
 		return 0, err
 	}
 	return id, nil
 }
 
-// lock inventory row (assets) by master id, return asset_id & quantity
-func (s *Store) LockAssetRow(ctx context.Context, tx *sql.Tx, masterID uint64) (assetID uint64, quantity uint, err error) {
-	const q = `SELECT asset_id, quantity FROM assets WHERE asset_master_id = ? LIMIT 1 FOR UPDATE`
-	row := tx.QueryRowContext(ctx, q, masterID)
-	if err = row.Scan(&assetID, &quantity); err != nil {
-		if err == sql.ErrNoRows {
-			return 0, 0, ErrNotFound("asset row not found")
-		}
-		return 0, 0, err
-	}
-	return assetID, quantity, nil
-}
-
-func (s *Store) UpdateAssetQuantity(ctx context.Context, tx *sql.Tx, assetID uint64, delta int) error {
-	const q = `UPDATE assets SET quantity = quantity + ? WHERE asset_id = ?`
-	res, err := tx.ExecContext(ctx, q, delta, assetID)
-	if err != nil {
-		return err
-	}
-	aff, _ := res.RowsAffected()
-	if aff != 1 {
-		return ErrInternal("failed to update assets.quantity")
-	}
-	return nil
-}
-
 // Lends CRUD / Queries
 
 func (s *Store) InsertLend(ctx context.Context, tx *sql.Tx, m *Lend) (uint64, error) {
 	const q = `
 	INSERT INTO lends
-	(lend_ulid, asset_master_id, management_number, quantity, borrower_id, due_on, lent_by_id, lent_at, note)
+	(lend_ulid, checkout_ulid, asset_master_id, management_number, quantity, borrower_id, due_on, lent_by_id, lent_at, note)
 	VALUES
-	(?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
+	(?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
 
 	res, err := tx.ExecContext(ctx, q,
 		m.LendULID,
+		m.CheckoutULID,
 		m.AssetMasterID,
 		m.ManagementNumber,
 		m.Quantity,
@@ -79,59 +58,26 @@ func (s *Store) InsertLend(ctx context.Context, tx *sql.Tx, m *Lend) (uint64, er
 	return uint64(id), nil
 }
 
-func (s *Store) UpdateAssetsStatus(ctx context.Context, tx *sql.Tx, masterID uint64, statusID int) error {
-	const q = `
-		UPDATE assets
-		SET status_id = ?
-		WHERE asset_master_id = ?
-		AND status_id <> ?`
-
-	res, err := tx.ExecContext(ctx, q, statusID, masterID, statusID)
-	if err != nil {
-		return err
-	}
-	aff, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	// 要件に合わせてここは選択
-	if aff == 0 {
-
-		return sql.ErrNoRows // NotFound
-
-	}
-	return nil
+func (s *Store) GetLendByULID(ctx context.Context, ulid string) (*Lend, error) {
+	return s.getLend(ctx, s.db, ulid, "")
 }
 
-func (s *Store) UpdateAssetOnLend(ctx context.Context, tx *sql.Tx, l *Lend, assetId uint64) error {
-	const q = `
-		UPDATE assets
-		SET location = ?, last_checked_at = ?
-		WHERE asset_id = ?`
-	res, err := tx.ExecContext(ctx, q, l.BorrowerID, time.Now(), assetId)
-	if err != nil {
-		return err
-	}
-	aff, _ := res.RowsAffected()
-	if aff != 1 {
-		return ErrInternal("failed to update assets.location")
-	}
-	return nil
+// LockLendByULID: 期限の変更など貸出本体を書き換える前にロックする
+func (s *Store) LockLendByULID(ctx context.Context, tx *sql.Tx, ulid string) (*Lend, error) {
+	return s.getLend(ctx, tx, ulid, " FOR UPDATE")
 }
 
-func (s *Store) GetLendByULID(ctx context.Context, ulid string) (*Lend, error) {
-	const q = `
-	SELECT lend_id, lend_ulid, asset_master_id, quantity, borrower_id, due_on, lent_by_id, lent_at, note
-	FROM lends WHERE lend_ulid = ?`
+func (s *Store) getLend(ctx context.Context, q db.DBTX, ulid, lock string) (*Lend, error) {
 	var m Lend
-	err := s.db.QueryRowContext(ctx, q, ulid).Scan(
-		&m.LendID, &m.LendULID, &m.AssetMasterID, &m.Quantity, &m.BorrowerID,
-		&m.DueOn, &m.LentByID, &m.LentAt, &m.Note,
+	err := q.QueryRowContext(ctx, `
+	SELECT lend_id, lend_ulid, checkout_ulid, asset_master_id, quantity, borrower_id, due_on, lent_by_id, lent_at, note, returned
+	FROM lends WHERE lend_ulid = ?`+lock, ulid).Scan(
+		&m.LendID, &m.LendULID, &m.CheckoutULID, &m.AssetMasterID, &m.Quantity, &m.BorrowerID,
+		&m.DueOn, &m.LentByID, &m.LentAt, &m.Note, &m.Returned,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, ErrNotFound("lend not found")
+			return nil, apperr.NotFound("lend not found")
 		}
 		return nil, err
 	}
@@ -151,21 +97,34 @@ type lendRow struct {
 	Lend
 	ManagementNumber string
 	ReturnedSum      uint
+	LostSum          uint
+}
+
+// lendOrder: 貸出一覧の並び（lent_at, lend_id）と cursor の識別子
+func lendOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "lent_at:asc"
+	if desc {
+		sort = "lent_at:desc"
+	}
+	return sort, []pagination.Column{{Expr: "l.lent_at", Desc: desc}, {Expr: "l.lend_id", Desc: desc}}
 }
 
-func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow, int64, error) {
-	// Base select with join & aggregated returned sum
+// ListLends: cursor の続きから p.Fetch() 件。返却数・紛失数は行ごとの相関サブクエリ（returns.lend_id / lend_allocations の主キーを使う）
+func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow, error) {
+	const (
+		returnedSum = `(SELECT COALESCE(SUM(r.quantity), 0) FROM returns r WHERE r.lend_id = l.lend_id)`
+		lostSum     = `(SELECT COALESCE(SUM(la.lost_quantity), 0) FROM lend_allocations la WHERE la.lend_id = l.lend_id)`
+	)
 	sb := strings.Builder{}
 	sb.WriteString(`
 	SELECT
-	l.lend_id, l.lend_ulid, l.asset_master_id, l.quantity, l.borrower_id, l.due_on, l.lent_by_id, l.lent_at, l.note, l.returned,
+	l.lend_id, l.lend_ulid, l.checkout_ulid, l.asset_master_id, l.quantity, l.borrower_id, l.due_on, l.lent_by_id, l.lent_at, l.note, l.returned,
 	m.management_number,
-	COALESCE(r.sum_qty,0) AS returned_sum
+	` + returnedSum + ` AS returned_sum,
+	` + lostSum + ` AS lost_sum
 	FROM lends l
 	JOIN assets_master m ON m.asset_master_id = l.asset_master_id
-	LEFT JOIN (
-	SELECT lend_id, SUM(quantity) AS sum_qty FROM returns GROUP BY lend_id
-	) r ON r.lend_id = l.lend_id
 	WHERE 1=1
 `)
 
@@ -175,6 +134,10 @@ func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow,
 		sb.WriteString(` AND m.management_number = ?`)
 		args = append(args, *f.ManagementNumber)
 	}
+	if f.CheckoutULID != nil {
+		sb.WriteString(` AND l.checkout_ulid = ?`)
+		args = append(args, *f.CheckoutULID)
+	}
 	if f.BorrowerID != nil {
 		sb.WriteString(` AND l.borrower_id = ?`)
 		args = append(args, *f.BorrowerID)
@@ -188,32 +151,41 @@ func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow,
 		args = append(args, *f.To)
 	}
 	if f.OnlyOutstanding {
-		// outstanding = l.quantity > returned_sum
-		sb.WriteString(` AND COALESCE(r.sum_qty,0) < l.quantity`)
+		sb.WriteString(` AND ` + returnedSum + ` + ` + lostSum + ` < l.quantity`)
 	}
 	if f.Returned != nil {
 		sb.WriteString(` AND l.returned = ?`)
 		args = append(args, *f.Returned)
 	}
-	order := "DESC"
-	if strings.ToLower(p.Order) == "asc" {
-		order = "ASC"
-	}
-	sb.WriteString(fmt.Sprintf(` ORDER BY l.lent_at %s`, order))
-	if p.Limit <= 0 {
-		p.Limit = 50
+	if f.Overdue {
+		sb.WriteString(` AND l.returned = 0 AND l.due_on < ?`)
+		args = append(args, f.today)
 	}
-	if p.Offset < 0 {
-		p.Offset = 0
+	if f.DueBefore != nil {
+		sb.WriteString(` AND l.due_on < ?`)
+		args = append(args, *f.DueBefore)
 	}
-	sb.WriteString(` LIMIT ? OFFSET ?`)
-	args = append(args, p.Limit, p.Offset)
 
-	q := sb.String()
+	sort, cols := lendOrder(p)
+	var (
+		lastAt time.Time
+		lastID uint64
+	)
+	ok, err := p.After(sort, &lastAt, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastAt, lastID})
+		sb.WriteString(` AND ` + cond)
+		args = append(args, seekArgs...)
+	}
+	sb.WriteString(` ORDER BY ` + pagination.OrderBy(cols) + ` LIMIT ?`)
+	args = append(args, p.Fetch())
 
-	rows, err := s.db.QueryContext(ctx, q, args...)
+	rows, err := s.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -221,51 +193,94 @@ func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow,
 	for rows.Next() {
 		var r lendRow
 		if err := rows.Scan(
-			&r.Lend.LendID, &r.Lend.LendULID, &r.Lend.AssetMasterID, &r.Lend.Quantity, &r.Lend.BorrowerID,
+			&r.Lend.LendID, &r.Lend.LendULID, &r.Lend.CheckoutULID, &r.Lend.AssetMasterID, &r.Lend.Quantity, &r.Lend.BorrowerID,
 			&r.Lend.DueOn, &r.Lend.LentByID, &r.Lend.LentAt, &r.Lend.Note, &r.Lend.Returned,
-			&r.ManagementNumber, &r.ReturnedSum,
+			&r.ManagementNumber, &r.ReturnedSum, &r.LostSum,
 		); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		out = append(out, r)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
+	return out, rows.Err()
+}
 
-	// total
-	cb := strings.Builder{}
-	cb.WriteString(`SELECT COUNT(*) FROM lends l JOIN assets_master m ON m.asset_master_id=l.asset_master_id LEFT JOIN (SELECT lend_id, SUM(quantity) sum_qty FROM returns GROUP BY lend_id) r ON r.lend_id=l.lend_id WHERE 1=1`)
-	argsCnt := []any{}
-	if f.ManagementNumber != nil {
-		cb.WriteString(` AND m.management_number = ?`)
-		argsCnt = append(argsCnt, *f.ManagementNumber)
+// Allocations
+
+func (s *Store) InsertLendAllocations(ctx context.Context, tx *sql.Tx, lendID uint64, allocs []stock.Allocation) error {
+	const q = `INSERT INTO lend_allocations (lend_id, asset_id, quantity, returned_quantity) VALUES (?, ?, ?, 0)`
+	for _, a := range allocs {
+		if _, err := tx.ExecContext(ctx, q, lendID, a.AssetID, a.Quantity); err != nil {
+			return err
+		}
 	}
-	if f.BorrowerID != nil {
-		cb.WriteString(` AND l.borrower_id = ?`)
-		argsCnt = append(argsCnt, *f.BorrowerID)
+	return nil
+}
+
+// LockLendAllocations: 返却処理用に貸出の割り当てを asset_id 順でロック
+func (s *Store) LockLendAllocations(ctx context.Context, tx *sql.Tx, lendID uint64) ([]LendAllocation, error) {
+	return s.listLendAllocations(ctx, tx, lendID, " FOR UPDATE")
+}
+
+func (s *Store) ListLendAllocations(ctx context.Context, lendID uint64) ([]LendAllocation, error) {
+	return s.listLendAllocations(ctx, s.db, lendID, "")
+}
+
+func (s *Store) listLendAllocations(ctx context.Context, q db.DBTX, lendID uint64, lock string) ([]LendAllocation, error) {
+	rows, err := q.QueryContext(ctx, `
+	SELECT lend_id, asset_id, quantity, returned_quantity, lost_quantity
+	FROM lend_allocations WHERE lend_id = ? ORDER BY asset_id`+lock, lendID)
+	if err != nil {
+		return nil, err
 	}
-	if f.From != nil {
-		cb.WriteString(` AND l.lent_at >= ?`)
-		argsCnt = append(argsCnt, *f.From)
+	defer rows.Close()
+
+	var out []LendAllocation
+	for rows.Next() {
+		var a LendAllocation
+		if err := rows.Scan(&a.LendID, &a.AssetID, &a.Quantity, &a.ReturnedQuantity, &a.LostQuantity); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
 	}
-	if f.To != nil {
-		cb.WriteString(` AND l.lent_at < ?`)
-		argsCnt = append(argsCnt, *f.To)
+	return out, rows.Err()
+}
+
+func (s *Store) AddReturnedQuantity(ctx context.Context, tx *sql.Tx, lendID, assetID uint64, n uint) error {
+	const q = `
+	UPDATE lend_allocations SET returned_quantity = returned_quantity + ?
+	WHERE lend_id = ? AND asset_id = ? AND returned_quantity + lost_quantity + ? <= quantity`
+	res, err := tx.ExecContext(ctx, q, n, lendID, assetID, n)
+	if err != nil {
+		return err
 	}
-	if f.OnlyOutstanding {
-		cb.WriteString(` AND COALESCE(r.sum_qty,0) < l.quantity`)
+	if aff, _ := res.RowsAffected(); aff != 1 {
+		return apperr.Conflict("over return")
 	}
-	if f.Returned != nil {
-		cb.WriteString(` AND l.returned = ?`)
-		argsCnt = append(argsCnt, *f.Returned)
+	return nil
+}
+
+func (s *Store) AddLostQuantity(ctx context.Context, tx *sql.Tx, lendID, assetID uint64, n uint) error {
+	const q = `
+	UPDATE lend_allocations SET lost_quantity = lost_quantity + ?
+	WHERE lend_id = ? AND asset_id = ? AND returned_quantity + lost_quantity + ? <= quantity`
+	res, err := tx.ExecContext(ctx, q, n, lendID, assetID, n)
+	if err != nil {
+		return err
 	}
-	var total int64
-	if err := s.db.QueryRowContext(ctx, cb.String(), argsCnt...).Scan(&total); err != nil {
-		return nil, 0, err
+	if aff, _ := res.RowsAffected(); aff != 1 {
+		return apperr.Conflict("quantity exceeds outstanding")
 	}
+	return nil
+}
 
-	return out, total, nil
+// OutstandingOnAsset: 在庫行から貸し出したまま戻っていない数（全貸出の合計）。在庫行をロックしてから呼ぶ
+func (s *Store) OutstandingOnAsset(ctx context.Context, tx *sql.Tx, assetID uint64) (uint, error) {
+	const q = `
+	SELECT COALESCE(SUM(quantity - returned_quantity - lost_quantity), 0)
+	FROM lend_allocations WHERE asset_id = ?`
+	var n uint
+	err := tx.QueryRowContext(ctx, q, assetID).Scan(&n)
+	return n, err
 }
 
 // Returns
@@ -273,11 +288,12 @@ func (s *Store) ListLends(ctx context.Context, f LendFilter, p Page) ([]lendRow,
 func (s *Store) InsertReturn(ctx context.Context, tx *sql.Tx, m *Return) (uint64, error) {
 	const q = `
 	INSERT INTO returns
-	(return_ulid, lend_id, quantity, processed_by_id, returned_at, note)
+	(return_ulid, lend_id, quantity, condition_grade, damage_description, processed_by_id, returned_at, note)
 	VALUES
-	(?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
+	(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
 	res, err := tx.ExecContext(ctx, q,
-		m.ReturnULID, m.LendID, m.Quantity, nullStrOrNil(m.ProcessedByID), nullStrOrNil(m.Note),
+		m.ReturnULID, m.LendID, m.Quantity, m.ConditionGrade, nullStrOrNil(m.DamageDescription),
+		nullStrOrNil(m.ProcessedByID), nullStrOrNil(m.Note),
 	)
 	if err != nil {
 		return 0, err
@@ -293,44 +309,80 @@ func (s *Store) UpdateLendReturnedStatus(ctx context.Context, tx *sql.Tx, lendUL
 	return err
 }
 
-func (s *Store) ListReturnsByLend(ctx context.Context, lendID uint64, p Page) ([]Return, int64, error) {
-	order := "DESC"
-	if strings.ToLower(p.Order) == "asc" {
-		order = "ASC"
+// returnOrder: 返却一覧の並び（returned_at, return_id）と cursor の識別子
+func returnOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "returned_at:asc"
+	if desc {
+		sort = "returned_at:desc"
 	}
-	if p.Limit <= 0 {
-		p.Limit = 50
+	return sort, []pagination.Column{{Expr: "returned_at", Desc: desc}, {Expr: "return_id", Desc: desc}}
+}
+
+func (s *Store) ListReturnsByLend(ctx context.Context, lendID uint64, p Page) ([]Return, error) {
+	q := `
+	SELECT ` + returnColumns + `
+	FROM returns WHERE lend_id = ?`
+	args := []any{lendID}
+
+	sort, cols := returnOrder(p)
+	var (
+		lastAt time.Time
+		lastID uint64
+	)
+	ok, err := p.After(sort, &lastAt, &lastID)
+	if err != nil {
+		return nil, err
 	}
-	if p.Offset < 0 {
-		p.Offset = 0
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastAt, lastID})
+		q += ` AND ` + cond
+		args = append(args, seekArgs...)
 	}
-	q := fmt.Sprintf(`
-	SELECT return_id, return_ulid, lend_id, quantity, processed_by_id, returned_at, note
-	FROM returns WHERE lend_id = ? ORDER BY returned_at %s LIMIT ? OFFSET ?`, order)
+	q += ` ORDER BY ` + pagination.OrderBy(cols) + ` LIMIT ?`
+	args = append(args, p.Fetch())
 
-	rows, err := s.db.QueryContext(ctx, q, lendID, p.Limit, p.Offset)
+	rows, err := s.db.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	var items []Return
 	for rows.Next() {
-		var m Return
-		if err := rows.Scan(&m.ReturnID, &m.ReturnULID, &m.LendID, &m.Quantity, &m.ProcessedByID, &m.ReturnedAt, &m.Note); err != nil {
-			return nil, 0, err
+		m, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
 		}
 		items = append(items, m)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
+	return items, rows.Err()
+}
+
+const returnColumns = `return_id, return_ulid, lend_id, quantity, condition_grade, damage_description, processed_by_id, returned_at, note`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanReturn(sc scanner) (Return, error) {
+	var m Return
+	err := sc.Scan(&m.ReturnID, &m.ReturnULID, &m.LendID, &m.Quantity, &m.ConditionGrade, &m.DamageDescription,
+		&m.ProcessedByID, &m.ReturnedAt, &m.Note)
+	return m, err
+}
 
-	var total int64
-	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM returns WHERE lend_id = ?`, lendID).Scan(&total); err != nil {
-		return nil, 0, err
+// GetReturnByULID: 返却1件と、その貸出の lend_ulid / borrower_id
+func (s *Store) GetReturnByULID(ctx context.Context, ulid string) (Return, *Lend, error) {
+	m, err := scanReturn(s.db.QueryRowContext(ctx, `SELECT `+returnColumns+` FROM returns WHERE return_ulid = ?`, ulid))
+	if err == sql.ErrNoRows {
+		return Return{}, nil, apperr.NotFound("return not found")
+	}
+	if err != nil {
+		return Return{}, nil, err
 	}
-	return items, total, nil
+	var l Lend
+	err = s.db.QueryRowContext(ctx, `SELECT lend_id, lend_ulid, borrower_id FROM lends WHERE lend_id = ?`, m.LendID).
+		Scan(&l.LendID, &l.LendULID, &l.BorrowerID)
+	return m, &l, err
 }
 
 func nullStrOrNil(ns sql.NullString) any {
@@ -339,3 +391,178 @@ func nullStrOrNil(ns sql.NullString) any {
 	}
 	return nil
 }
+
+// Renewals
+
+func (s *Store) CountRenewals(ctx context.Context, tx *sql.Tx, lendID uint64) (int, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM lend_renewals WHERE lend_id = ?`, lendID).Scan(&n)
+	return n, err
+}
+
+// MaxLoanDays: 資産マスタのジャンルに設定された貸出期間の上限（NULL なら上限なし）
+func (s *Store) MaxLoanDays(ctx context.Context, tx *sql.Tx, masterID uint64) (sql.NullInt32, error) {
+	const q = `
+	SELECT g.max_loan_days
+	FROM assets_master m JOIN asset_genres g ON g.genre_id = m.genre_id
+	WHERE m.asset_master_id = ?`
+	var n sql.NullInt32
+	err := tx.QueryRowContext(ctx, q, masterID).Scan(&n)
+	return n, err
+}
+
+func (s *Store) UpdateDueOn(ctx context.Context, tx *sql.Tx, lendID uint64, dueOn string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE lends SET due_on = ? WHERE lend_id = ?`, dueOn, lendID)
+	return err
+}
+
+func (s *Store) InsertRenewal(ctx context.Context, tx *sql.Tx, m *Renewal) error {
+	const q = `
+	INSERT INTO lend_renewals
+	(renewal_ulid, lend_id, old_due_on, new_due_on, approved_by_id, reason, renewed_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, q,
+		m.RenewalULID, m.LendID, m.OldDueOn, m.NewDueOn, nullStrOrNil(m.ApprovedByID), m.Reason, m.RenewedAt,
+	)
+	return err
+}
+
+const renewalSort = "renewal_id:asc"
+
+// ListRenewalsByLend: 古い順。cursor の続きから p.Fetch() 件
+func (s *Store) ListRenewalsByLend(ctx context.Context, lendID uint64, p pagination.Request) ([]Renewal, error) {
+	q := `
+	SELECT renewal_id, renewal_ulid, lend_id, old_due_on, new_due_on, approved_by_id, reason, renewed_at
+	FROM lend_renewals WHERE lend_id = ?`
+	args := []any{lendID}
+	var lastID uint64
+	ok, err := p.After(renewalSort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		q += ` AND renewal_id > ?`
+		args = append(args, lastID)
+	}
+	q += ` ORDER BY renewal_id LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Renewal
+	for rows.Next() {
+		var (
+			m              Renewal
+			oldDue, newDue time.Time
+		)
+		if err := rows.Scan(&m.RenewalID, &m.RenewalULID, &m.LendID, &oldDue, &newDue, &m.ApprovedByID, &m.Reason, &m.RenewedAt); err != nil {
+			return nil, err
+		}
+		m.OldDueOn, m.NewDueOn = oldDue.Format(time.DateOnly), newDue.Format(time.DateOnly)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Losses
+
+func (s *Store) InsertLoss(ctx context.Context, tx *sql.Tx, m *Loss) error {
+	const q = `
+	INSERT INTO lend_losses
+	(loss_ulid, lend_id, quantity, description, status, reported_by_id, reported_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, q,
+		m.LossULID, m.LendID, m.Quantity, m.Description, m.Status, nullStrOrNil(m.ReportedByID), m.ReportedAt,
+	)
+	return err
+}
+
+const selectLoss = `
+	SELECT x.loss_id, x.loss_ulid, x.lend_id, x.quantity, x.description, x.status, x.reported_by_id, x.reported_at,
+		x.closed_by_id, x.closed_at, x.resolution, l.lend_ulid, m.management_number, l.borrower_id
+	FROM lend_losses x
+	JOIN lends l ON l.lend_id = x.lend_id
+	JOIN assets_master m ON m.asset_master_id = l.asset_master_id`
+
+func scanLoss(sc scanner) (Loss, error) {
+	var m Loss
+	err := sc.Scan(&m.LossID, &m.LossULID, &m.LendID, &m.Quantity, &m.Description, &m.Status, &m.ReportedByID, &m.ReportedAt,
+		&m.ClosedByID, &m.ClosedAt, &m.Resolution, &m.LendULID, &m.ManagementNumber, &m.BorrowerID)
+	return m, err
+}
+
+func (s *Store) GetLossByULID(ctx context.Context, ulid string) (Loss, error) {
+	return s.getLoss(ctx, s.db, ulid, "")
+}
+
+// LockLossByULID: 対応済みにするときに届出の行だけロックする
+func (s *Store) LockLossByULID(ctx context.Context, tx *sql.Tx, ulid string) (Loss, error) {
+	return s.getLoss(ctx, tx, ulid, " FOR UPDATE OF x")
+}
+
+func (s *Store) getLoss(ctx context.Context, q db.DBTX, ulid, lock string) (Loss, error) {
+	m, err := scanLoss(q.QueryRowContext(ctx, selectLoss+` WHERE x.loss_ulid = ?`+lock, ulid))
+	if err == sql.ErrNoRows {
+		return Loss{}, apperr.NotFound("loss not found")
+	}
+	return m, err
+}
+
+func (s *Store) CloseLoss(ctx context.Context, tx *sql.Tx, lossID uint64, closedBy sql.NullString, closedAt time.Time, resolution string) error {
+	const q = `
+	UPDATE lend_losses SET status = ?, closed_by_id = ?, closed_at = ?, resolution = ?
+	WHERE loss_id = ?`
+	_, err := tx.ExecContext(ctx, q, LossClosed, nullStrOrNil(closedBy), closedAt, resolution, lossID)
+	return err
+}
+
+const lossSort = "loss_id:desc"
+
+// ListLosses: 新しい順。lendID を指定すればその貸出の分だけ。cursor の続きから p.Fetch() 件
+func (s *Store) ListLosses(ctx context.Context, lendID *uint64, f LossFilter, p pagination.Request) ([]Loss, error) {
+	q := selectLoss + ` WHERE 1=1`
+	args := []any{}
+	if lendID != nil {
+		q += ` AND x.lend_id = ?`
+		args = append(args, *lendID)
+	}
+	if f.Status != nil {
+		q += ` AND x.status = ?`
+		args = append(args, *f.Status)
+	}
+	if f.BorrowerID != nil {
+		q += ` AND l.borrower_id = ?`
+		args = append(args, *f.BorrowerID)
+	}
+	var lastID uint64
+	ok, err := p.After(lossSort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		q += ` AND x.loss_id < ?`
+		args = append(args, lastID)
+	}
+	q += ` ORDER BY x.loss_id DESC LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Loss
+	for rows.Next() {
+		m, err := scanLoss(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,47 @@
+package locations
+
+import "time"
+
+// ---- Requests ----
+
+// location_code は英大文字・数字と "-" "_"。parent_id を省略すると最上位
+type CreateLocationRequest struct {
+	ParentID     *uint  `json:"parent_id,omitempty"`
+	LocationCode string `json:"location_code" binding:"required,max=32"`
+	LocationName string `json:"location_name" binding:"required,max=100"`
+	Kind         string `json:"kind" binding:"required,oneof=building room shelf"`
+}
+
+// parent_id: 0 で最上位へ移す
+type UpdateLocationRequest struct {
+	ParentID     *uint   `json:"parent_id,omitempty"`
+	LocationCode *string `json:"location_code,omitempty" binding:"omitempty,max=32"`
+	LocationName *string `json:"location_name,omitempty" binding:"omitempty,max=100"`
+	Kind         *string `json:"kind,omitempty" binding:"omitempty,oneof=building room shelf"`
+}
+
+// GET /locations の絞り込み。under はその場所と配下すべて
+type ListQuery struct {
+	ParentID *uint
+	TopLevel bool
+	Under    *uint
+	Kind     *string
+}
+
+// ---- Responses ----
+
+type LocationResponse struct {
+	LocationID   uint          `json:"location_id"`
+	ParentID     *uint         `json:"parent_id,omitempty"`
+	LocationCode string        `json:"location_code"`
+	LocationName string        `json:"location_name"`
+	Kind         string        `json:"kind"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Path         []LocationRef `json:"path,omitempty"` // 最上位から自分まで（詳細のみ）
+}
+
+type LocationRef struct {
+	LocationID   uint   `json:"location_id"`
+	LocationCode string `json:"location_code"`
+	LocationName string `json:"location_name"`
+}
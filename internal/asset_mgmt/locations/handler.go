@@ -0,0 +1,129 @@
+package locations
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.GET("/locations", auth.Allow(auth.Members...), h.List)
+	r.GET("/locations/:location_id", auth.Allow(auth.Members...), h.Get)
+	r.POST("/locations", auth.Allow(auth.AdminOnly...), h.Create)
+	r.PUT("/locations/:location_id", auth.Allow(auth.AdminOnly...), h.Update)
+	r.DELETE("/locations/:location_id", auth.Allow(auth.AdminOnly...), h.Delete)
+}
+
+// GET /locations?parent_id=&under=&kind=
+// parent_id=0 で最上位だけ、under はその場所と配下すべて
+func (h *Handler) List(c *gin.Context) {
+	var q ListQuery
+	if v := c.Query("parent_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("parent_id must be a number"))
+			return
+		}
+		if id == 0 {
+			q.TopLevel = true
+		} else {
+			p := uint(id)
+			q.ParentID = &p
+		}
+	}
+	if v := c.Query("under"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("under must be a number"))
+			return
+		}
+		u := uint(id)
+		q.Under = &u
+	}
+	if v := c.Query("kind"); v != "" {
+		q.Kind = &v
+	}
+	items, err := h.svc.List(c.Request.Context(), q)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := locationIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/locations/"+strconv.FormatUint(uint64(res.LocationID), 10))
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := locationIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := locationIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ---- helpers ----
+
+func locationIDParam(c *gin.Context) (uint, bool) {
+	v, err := strconv.ParseUint(c.Param("location_id"), 10, 32)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("location_id must be a number"))
+		return 0, false
+	}
+	return uint(v), true
+}
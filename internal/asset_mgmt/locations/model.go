@@ -0,0 +1,25 @@
+package locations
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 場所の種類。親より下の階層（building → room → shelf）の子しか持てない
+const (
+	KindBuilding = "building"
+	KindRoom     = "room"
+	KindShelf    = "shelf"
+)
+
+var kindRank = map[string]int{KindBuilding: 0, KindRoom: 1, KindShelf: 2}
+
+// DBモデル（locations と1:1）
+type Location struct {
+	LocationID   uint
+	ParentID     sql.NullInt64
+	LocationCode string
+	LocationName string
+	Kind         string
+	CreatedAt    time.Time
+}
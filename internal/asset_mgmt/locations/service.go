@@ -0,0 +1,272 @@
+package locations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{0,31}$`)
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, store: NewStore(db)}
+}
+
+// GET /locations
+func (s *Service) List(ctx context.Context, in ListQuery) ([]LocationResponse, error) {
+	if in.Kind != nil {
+		if _, ok := kindRank[*in.Kind]; !ok {
+			return nil, apperr.Invalid("kind must be building, room or shelf")
+		}
+	}
+	items, err := s.store.List(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]LocationResponse, 0, len(items))
+	for _, m := range items {
+		out = append(out, toResponse(m))
+	}
+	return out, nil
+}
+
+// GET /locations/:location_id（最上位からの経路付き）
+func (s *Service) Get(ctx context.Context, id uint) (LocationResponse, error) {
+	m, err := s.store.Get(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return LocationResponse{}, apperr.NotFound("location not found")
+		}
+		return LocationResponse{}, err
+	}
+	out := toResponse(*m)
+	if out.Path, err = s.store.Path(ctx, id); err != nil {
+		return LocationResponse{}, err
+	}
+	return out, nil
+}
+
+// POST /locations
+func (s *Service) Create(ctx context.Context, in CreateLocationRequest) (LocationResponse, error) {
+	code, name, err := normalize(&in.LocationCode, &in.LocationName)
+	if err != nil {
+		return LocationResponse{}, err
+	}
+
+	var out LocationResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		if in.ParentID != nil {
+			if err := checkParent(ctx, st, *in.ParentID, in.Kind); err != nil {
+				return err
+			}
+		}
+		id, err := st.Insert(ctx, in.ParentID, *code, *name, in.Kind)
+		if err != nil {
+			return mapWriteErr(err)
+		}
+		m, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*m)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityLocation,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return LocationResponse{}, err
+	}
+	return out, nil
+}
+
+// PUT /locations/:location_id
+// 親の付け替えは自分の配下へは不可。種類を変えるときは親・子との上下関係も保つ
+func (s *Service) Update(ctx context.Context, id uint, in UpdateLocationRequest) (LocationResponse, error) {
+	code, name, err := normalize(in.LocationCode, in.LocationName)
+	if err != nil {
+		return LocationResponse{}, err
+	}
+
+	var out LocationResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("location not found")
+			}
+			return err
+		}
+
+		kind := before.Kind
+		if in.Kind != nil {
+			kind = *in.Kind
+		}
+		parent := before.ParentID
+		if in.ParentID != nil {
+			parent = sql.NullInt64{Int64: int64(*in.ParentID), Valid: *in.ParentID != 0}
+		}
+		if parent.Valid {
+			pid := uint(parent.Int64)
+			within, err := st.IsWithin(ctx, id, pid)
+			if err != nil {
+				return err
+			}
+			if within {
+				return apperr.Invalid("validation failed").WithField("parent_id", "must not be the location itself or one of its descendants")
+			}
+			if err := checkParent(ctx, st, pid, kind); err != nil {
+				return err
+			}
+		}
+		if kind != before.Kind {
+			kinds, err := st.ChildKinds(ctx, id)
+			if err != nil {
+				return err
+			}
+			for _, k := range kinds {
+				if kindRank[k] <= kindRank[kind] {
+					return apperr.Invalid("validation failed").WithField("kind", "children of kind "+k+" cannot be placed under "+kind)
+				}
+			}
+		}
+
+		if err := st.Update(ctx, id, in.ParentID != nil, parent, code, name, in.Kind); err != nil {
+			return mapWriteErr(err)
+		}
+		after, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*after)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityLocation,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return LocationResponse{}, err
+	}
+	return out, nil
+}
+
+// DELETE /locations/:location_id（子の場所・在庫行・棚卸しから参照されている間は削除できない）
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		before, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("location not found")
+			}
+			return err
+		}
+		children, assets, stocktakes, err := st.CountReferences(ctx, id)
+		if err != nil {
+			return err
+		}
+		if children+assets+stocktakes > 0 {
+			return apperr.Conflict("location is in use").
+				WithDetail("child_count", children).
+				WithDetail("asset_count", assets).
+				WithDetail("stocktake_count", stocktakes)
+		}
+		if err := st.Delete(ctx, id); err != nil {
+			return mapWriteErr(err)
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityLocation,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     toResponse(*before),
+		})
+	})
+}
+
+// ---- helpers ----
+
+// checkParent: 親が存在し、kind が親より下の階層であること
+func checkParent(ctx context.Context, st *Store, parentID uint, kind string) error {
+	p, err := st.Get(ctx, parentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Invalid("validation failed").WithField("parent_id", "not found")
+		}
+		return err
+	}
+	if kindRank[p.Kind] >= kindRank[kind] {
+		return apperr.Invalid("validation failed").WithField("kind", kind+" cannot be placed under "+p.Kind)
+	}
+	return nil
+}
+
+// normalize: 前後の空白を除き、コードは大文字に寄せてから形式を検証する（nil はそのまま）
+func normalize(code, name *string) (*string, *string, error) {
+	if code != nil {
+		v := strings.ToUpper(strings.TrimSpace(*code))
+		if !codePattern.MatchString(v) {
+			return nil, nil, apperr.Invalid("validation failed").
+				WithField("location_code", "must be 1-32 characters of A-Z, 0-9, '-' and '_'")
+		}
+		code = &v
+	}
+	if name != nil {
+		v := strings.TrimSpace(*name)
+		if v == "" {
+			return nil, nil, apperr.Invalid("validation failed").WithField("location_name", "must not be empty")
+		}
+		name = &v
+	}
+	return code, name, nil
+}
+
+func mapWriteErr(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return apperr.Conflict("location_code already exists")
+		case 1451:
+			return apperr.Conflict("location is in use")
+		case 1452:
+			return apperr.Invalid("validation failed").WithField("parent_id", "not found")
+		}
+	}
+	return err
+}
+
+func toResponse(m Location) LocationResponse {
+	out := LocationResponse{
+		LocationID:   m.LocationID,
+		LocationCode: m.LocationCode,
+		LocationName: m.LocationName,
+		Kind:         m.Kind,
+		CreatedAt:    m.CreatedAt,
+	}
+	if m.ParentID.Valid {
+		v := uint(m.ParentID.Int64)
+		out.ParentID = &v
+	}
+	return out
+}
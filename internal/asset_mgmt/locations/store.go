@@ -0,0 +1,197 @@
+package locations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+// Subtree: cond（locations の列への条件。例 "location_id = ?"）に合う場所と、その配下すべての location_id を返す副問い合わせ。
+// 他のパッケージからも "a.location_id IN " + Subtree("location_id = ?") のように WHERE に埋め込んで使う
+func Subtree(cond string) string {
+	return `(WITH RECURSIVE sub (location_id) AS (
+		SELECT location_id FROM locations WHERE ` + cond + `
+		UNION ALL
+		SELECT l.location_id FROM locations l JOIN sub ON l.parent_id = sub.location_id
+	) SELECT location_id FROM sub)`
+}
+
+const selectLocation = `SELECT location_id, parent_id, location_code, location_name, kind, created_at FROM locations`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanLocation(sc scanner) (Location, error) {
+	var m Location
+	err := sc.Scan(&m.LocationID, &m.ParentID, &m.LocationCode, &m.LocationName, &m.Kind, &m.CreatedAt)
+	return m, err
+}
+
+func (s *Store) List(ctx context.Context, in ListQuery) ([]Location, error) {
+	q := selectLocation + ` WHERE 1=1`
+	args := []any{}
+	if in.TopLevel {
+		q += ` AND parent_id IS NULL`
+	} else if in.ParentID != nil {
+		q += ` AND parent_id = ?`
+		args = append(args, *in.ParentID)
+	}
+	if in.Under != nil {
+		q += ` AND location_id IN ` + Subtree("location_id = ?")
+		args = append(args, *in.Under)
+	}
+	if in.Kind != nil {
+		q += ` AND kind = ?`
+		args = append(args, *in.Kind)
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY location_code`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Location
+	for rows.Next() {
+		m, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Get: 見つからなければ sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uint) (*Location, error) {
+	return s.get(ctx, selectLocation+` WHERE location_id = ?`, id)
+}
+
+func (s *Store) Lock(ctx context.Context, id uint) (*Location, error) {
+	return s.get(ctx, selectLocation+` WHERE location_id = ? FOR UPDATE`, id)
+}
+
+func (s *Store) get(ctx context.Context, q string, id uint) (*Location, error) {
+	m, err := scanLocation(s.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Path: 最上位から id の場所までの並び
+func (s *Store) Path(ctx context.Context, id uint) ([]LocationRef, error) {
+	const q = `
+	WITH RECURSIVE up (location_id, parent_id, location_code, location_name, depth) AS (
+		SELECT location_id, parent_id, location_code, location_name, 0 FROM locations WHERE location_id = ?
+		UNION ALL
+		SELECT l.location_id, l.parent_id, l.location_code, l.location_name, up.depth + 1
+		FROM locations l JOIN up ON l.location_id = up.parent_id
+	)
+	SELECT location_id, location_code, location_name FROM up ORDER BY depth DESC`
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LocationRef
+	for rows.Next() {
+		var r LocationRef
+		if err := rows.Scan(&r.LocationID, &r.LocationCode, &r.LocationName); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// IsWithin: id が root 自身かその配下か（付け替えで循環しないかの確認用）
+func (s *Store) IsWithin(ctx context.Context, root, id uint) (bool, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM locations WHERE location_id = ? AND location_id IN `+Subtree("location_id = ?"), id, root).Scan(&n)
+	return n > 0, err
+}
+
+// ChildKinds: 直下の子の種類（重複なし）
+func (s *Store) ChildKinds(ctx context.Context, id uint) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT kind FROM locations WHERE parent_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Insert(ctx context.Context, parentID *uint, code, name, kind string) (uint, error) {
+	const q = `INSERT INTO locations (parent_id, location_code, location_name, kind, created_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	res, err := s.db.ExecContext(ctx, q, parentID, code, name, kind)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// Update: parent は Valid=false で最上位へ（moveParent が false なら親は触らない）
+func (s *Store) Update(ctx context.Context, id uint, moveParent bool, parent sql.NullInt64, code, name, kind *string) error {
+	sets := []string{}
+	args := []any{}
+	if moveParent {
+		sets = append(sets, "parent_id = ?")
+		args = append(args, parent)
+	}
+	if code != nil {
+		sets = append(sets, "location_code = ?")
+		args = append(args, *code)
+	}
+	if name != nil {
+		sets = append(sets, "location_name = ?")
+		args = append(args, *name)
+	}
+	if kind != nil {
+		sets = append(sets, "kind = ?")
+		args = append(args, *kind)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE locations SET %s WHERE location_id = ?`, strings.Join(sets, ", "))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, id uint) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM locations WHERE location_id = ?`, id)
+	return err
+}
+
+// CountReferences: 子の場所・在庫行（既定の場所／現在の場所）・棚卸しからの参照の件数
+func (s *Store) CountReferences(ctx context.Context, id uint) (children, assets, stocktakes int64, err error) {
+	const q = `
+	SELECT
+		(SELECT COUNT(*) FROM locations WHERE parent_id = ?),
+		(SELECT COUNT(*) FROM assets WHERE default_location_id = ? OR location_id = ?),
+		(SELECT COUNT(*) FROM stocktakes WHERE location_id = ?)`
+	err = s.db.QueryRowContext(ctx, q, id, id, id, id).Scan(&children, &assets, &stocktakes)
+	return
+}
@@ -0,0 +1,287 @@
+// Package numbering: 管理番号（assets_master.management_number）の採番。
+//
+// 形式は config.yaml の numbering.template で決める。使えるトークン:
+//
+//	{genre}     ジャンルコード（asset_genres.genre_code）
+//	{category}  管理区分コード（management_categories.category_code）
+//	{yyyy} {yy} {mm} {dd} {yyyymmdd}  登録日
+//	{fy} {fy2}  年度（numbering.fiscal_year_start_month 始まり。既定は4月）
+//	{seq} {seq:N}  連番（N 桁ゼロ埋め）。必須・1個だけ
+//	{check}     チェックディジット（それより前の数字に対する Luhn mod 10）
+//
+// 連番は「{seq} と {check} 以外を展開した文字列」ごとに振る（management_number_sequences）。
+// テンプレートに {genre} を含めればジャンルごと、{fy} を含めれば年度ごとの連番になり、
+// どの形式でも同じ番号が二度出ることはない。
+package numbering
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+const (
+	DefaultTemplate = "{genre}-{yyyymmdd}-{seq:5}"
+	maxLen          = 64 // assets_master.management_number の桁数
+	maxSeqWidth     = 12
+)
+
+type kind int
+
+const (
+	kindLiteral kind = iota
+	kindGenre
+	kindCategory
+	kindYYYY
+	kindYY
+	kindMM
+	kindDD
+	kindYYYYMMDD
+	kindFY
+	kindFY2
+	kindSeq
+	kindCheck
+)
+
+var tokenKinds = map[string]kind{
+	"genre":    kindGenre,
+	"category": kindCategory,
+	"yyyy":     kindYYYY,
+	"yy":       kindYY,
+	"mm":       kindMM,
+	"dd":       kindDD,
+	"yyyymmdd": kindYYYYMMDD,
+	"fy":       kindFY,
+	"fy2":      kindFY2,
+	"seq":      kindSeq,
+	"check":    kindCheck,
+}
+
+// URL のパスにそのまま載せるので、固定文字は英数字と - _ . のみ
+var literalPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type part struct {
+	kind  kind
+	lit   string
+	width int // kindSeq のゼロ埋め桁数
+}
+
+// Scheme: 解析済みのテンプレート
+type Scheme struct {
+	template string
+	parts    []part
+	fyStart  time.Month
+}
+
+// Input: 採番に使う値（コードは呼び出し側が引いておく）
+type Input struct {
+	GenreCode    string
+	CategoryCode string
+	At           time.Time
+}
+
+// New: 設定からテンプレートを解析する。起動時に呼び、エラーなら起動させない
+func New(cfg db.NumberingConfig) (*Scheme, error) {
+	tmpl := cfg.Template
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	start := time.April
+	if cfg.FiscalYearStartMonth != 0 {
+		if cfg.FiscalYearStartMonth < 1 || cfg.FiscalYearStartMonth > 12 {
+			return nil, fmt.Errorf("numbering: fiscal_year_start_month は 1〜12: %d", cfg.FiscalYearStartMonth)
+		}
+		start = time.Month(cfg.FiscalYearStartMonth)
+	}
+	parts, err := parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheme{template: tmpl, parts: parts, fyStart: start}, nil
+}
+
+func parse(tmpl string) ([]part, error) {
+	var (
+		parts []part
+		seqs  int
+		check bool
+	)
+	rest := tmpl
+	for rest != "" {
+		i := strings.IndexByte(rest, '{')
+		if i != 0 {
+			lit := rest
+			if i > 0 {
+				lit = rest[:i]
+			}
+			if !literalPattern.MatchString(lit) {
+				return nil, fmt.Errorf("numbering: テンプレートに使えない文字があります: %q", lit)
+			}
+			parts = append(parts, part{kind: kindLiteral, lit: lit})
+			rest = rest[len(lit):]
+			continue
+		}
+		j := strings.IndexByte(rest, '}')
+		if j < 0 {
+			return nil, fmt.Errorf("numbering: '}' がありません: %q", tmpl)
+		}
+		name, arg, _ := strings.Cut(rest[1:j], ":")
+		k, ok := tokenKinds[name]
+		if !ok {
+			return nil, fmt.Errorf("numbering: 不明なトークン {%s}", rest[1:j])
+		}
+		p := part{kind: k}
+		switch {
+		case k == kindSeq:
+			seqs++
+			p.width = 1
+			if arg != "" {
+				w, err := strconv.Atoi(arg)
+				if err != nil || w < 1 || w > maxSeqWidth {
+					return nil, fmt.Errorf("numbering: {seq:N} の N は 1〜%d: %q", maxSeqWidth, arg)
+				}
+				p.width = w
+			}
+		case arg != "":
+			return nil, fmt.Errorf("numbering: {%s} は引数を取りません", name)
+		case k == kindCheck:
+			if check || seqs == 0 {
+				return nil, fmt.Errorf("numbering: {check} は {seq} より後に1個だけ置けます")
+			}
+			check = true
+		}
+		parts = append(parts, p)
+		rest = rest[j+1:]
+	}
+	if seqs != 1 {
+		return nil, fmt.Errorf("numbering: テンプレートには {seq} が1個だけ必要です: %q", tmpl)
+	}
+	return parts, nil
+}
+
+func (s *Scheme) Template() string { return s.template }
+
+// Uses: テンプレートが genre / category のコードを必要とするか
+func (s *Scheme) Uses() (genre, category bool) {
+	for _, p := range s.parts {
+		genre = genre || p.kind == kindGenre
+		category = category || p.kind == kindCategory
+	}
+	return genre, category
+}
+
+// Next: 連番を1つ進めて管理番号を返す。呼び出し元の Tx 上で実行し、
+// 連番の行ロックはその Tx のコミットまで保持される（同時登録でも番号は重複しない）
+func (s *Scheme) Next(ctx context.Context, tx db.DBTX, in Input) (string, error) {
+	scope := s.render(in, 0, true)
+	const up = `
+	INSERT INTO management_number_sequences (scope, last_value) VALUES (?, 1)
+	ON DUPLICATE KEY UPDATE last_value = last_value + 1`
+	if _, err := tx.ExecContext(ctx, up, scope); err != nil {
+		return "", err
+	}
+	var seq uint64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT last_value FROM management_number_sequences WHERE scope = ? FOR UPDATE`, scope).Scan(&seq); err != nil {
+		return "", err
+	}
+	return s.finish(in, seq)
+}
+
+// Preview: 次に振られる番号を返す（連番は進めないので、実際の登録時には変わりうる）
+func (s *Scheme) Preview(ctx context.Context, q db.DBTX, in Input) (string, error) {
+	scope := s.render(in, 0, true)
+	var last uint64
+	err := q.QueryRowContext(ctx,
+		`SELECT last_value FROM management_number_sequences WHERE scope = ?`, scope).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return s.finish(in, last+1)
+}
+
+func (s *Scheme) finish(in Input, seq uint64) (string, error) {
+	out := s.render(in, seq, false)
+	if len(out) > maxLen {
+		return "", fmt.Errorf("numbering: 管理番号が %d 文字を超えます: %q", maxLen, out)
+	}
+	return out, nil
+}
+
+// render: scope=true のときは連番・チェックディジットを伏せた「連番の単位」を返す
+func (s *Scheme) render(in Input, seq uint64, scope bool) string {
+	var b strings.Builder
+	t := in.At
+	for _, p := range s.parts {
+		switch p.kind {
+		case kindLiteral:
+			b.WriteString(p.lit)
+		case kindGenre:
+			b.WriteString(in.GenreCode)
+		case kindCategory:
+			b.WriteString(in.CategoryCode)
+		case kindYYYY:
+			b.WriteString(t.Format("2006"))
+		case kindYY:
+			b.WriteString(t.Format("06"))
+		case kindMM:
+			b.WriteString(t.Format("01"))
+		case kindDD:
+			b.WriteString(t.Format("02"))
+		case kindYYYYMMDD:
+			b.WriteString(t.Format("20060102"))
+		case kindFY:
+			fmt.Fprintf(&b, "%04d", s.fiscalYear(t))
+		case kindFY2:
+			fmt.Fprintf(&b, "%02d", s.fiscalYear(t)%100)
+		case kindSeq:
+			if scope {
+				b.WriteString("{seq}")
+			} else {
+				fmt.Fprintf(&b, "%0*d", p.width, seq)
+			}
+		case kindCheck:
+			if scope {
+				b.WriteString("{check}")
+			} else {
+				b.WriteByte(luhn(b.String()))
+			}
+		}
+	}
+	return b.String()
+}
+
+func (s *Scheme) fiscalYear(t time.Time) int {
+	if t.Month() < s.fyStart {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// luhn: s に含まれる数字（英字は無視）に対する Luhn のチェックディジット
+func luhn(s string) byte {
+	sum := 0
+	double := true // チェックディジットを末尾に足す前提で、右端の数字から2倍する
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
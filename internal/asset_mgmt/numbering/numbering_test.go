@@ -0,0 +1,139 @@
+package numbering
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  db.NumberingConfig
+		want string // エラーメッセージに含まれる文字列。空ならエラーなし
+	}{
+		{name: "default template", cfg: db.NumberingConfig{}},
+		{name: "all tokens", cfg: db.NumberingConfig{Template: "{category}-{genre}-{fy2}{mm}{dd}-{seq:4}{check}"}},
+		{name: "fiscal year start month", cfg: db.NumberingConfig{FiscalYearStartMonth: 13}, want: "fiscal_year_start_month"},
+		{name: "no seq", cfg: db.NumberingConfig{Template: "{genre}-{yyyy}"}, want: "{seq} が1個だけ"},
+		{name: "two seqs", cfg: db.NumberingConfig{Template: "{seq}-{seq}"}, want: "{seq} が1個だけ"},
+		{name: "unknown token", cfg: db.NumberingConfig{Template: "{foo}-{seq}"}, want: "不明なトークン"},
+		{name: "unclosed brace", cfg: db.NumberingConfig{Template: "{genre}-{seq"}, want: "'}' がありません"},
+		{name: "bad literal", cfg: db.NumberingConfig{Template: "A/B-{seq}"}, want: "使えない文字"},
+		{name: "seq width zero", cfg: db.NumberingConfig{Template: "{seq:0}"}, want: "{seq:N}"},
+		{name: "seq width too large", cfg: db.NumberingConfig{Template: "{seq:13}"}, want: "{seq:N}"},
+		{name: "seq width not a number", cfg: db.NumberingConfig{Template: "{seq:x}"}, want: "{seq:N}"},
+		{name: "argument on other token", cfg: db.NumberingConfig{Template: "{genre:2}-{seq}"}, want: "引数を取りません"},
+		{name: "check before seq", cfg: db.NumberingConfig{Template: "{check}{seq}"}, want: "{check}"},
+		{name: "two checks", cfg: db.NumberingConfig{Template: "{seq}{check}{check}"}, want: "{check}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("New() error = %v", err)
+			case tt.want != "" && err == nil:
+				t.Fatalf("New() error = nil, want %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Fatalf("New() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		in   string
+		want byte
+	}{
+		{"7992739871", '3'},
+		{"PC-7992739871-", '3'}, // 英字・記号は無視
+		{"0", '0'},
+		{"", '0'},
+		{"PC0012", '5'},
+		{"123456789", '7'},
+	}
+	for _, tt := range tests {
+		if got := luhn(tt.in); got != tt.want {
+			t.Errorf("luhn(%q) = %c, want %c", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	at := time.Date(2025, time.March, 9, 10, 0, 0, 0, time.UTC)
+	in := Input{GenreCode: "PC", CategoryCode: "A", At: at}
+	tests := []struct {
+		name      string
+		cfg       db.NumberingConfig
+		seq       uint64
+		wantScope string
+		want      string
+	}{
+		{
+			name:      "default GENRE-YYYYMMDD-{seq}",
+			seq:       7,
+			wantScope: "PC-20250309-{seq}",
+			want:      "PC-20250309-00007",
+		},
+		{
+			name:      "check digit",
+			cfg:       db.NumberingConfig{Template: "{genre}{seq:4}{check}"},
+			seq:       12,
+			wantScope: "PC{seq}{check}",
+			want:      "PC00125",
+		},
+		{
+			name:      "seq wider than width",
+			cfg:       db.NumberingConfig{Template: "{genre}-{seq:2}"},
+			seq:       123,
+			wantScope: "PC-{seq}",
+			want:      "PC-123",
+		},
+		{
+			name:      "fiscal year starts in April",
+			cfg:       db.NumberingConfig{Template: "{category}{fy}-{fy2}-{seq:3}"},
+			seq:       1,
+			wantScope: "A2024-24-{seq}",
+			want:      "A2024-24-001",
+		},
+		{
+			name:      "fiscal year starts in January",
+			cfg:       db.NumberingConfig{Template: "{fy}-{yy}{mm}{dd}-{seq}", FiscalYearStartMonth: 1},
+			seq:       5,
+			wantScope: "2025-250309-{seq}",
+			want:      "2025-250309-5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.render(in, 0, true); got != tt.wantScope {
+				t.Errorf("scope = %q, want %q", got, tt.wantScope)
+			}
+			got, err := s.finish(in, tt.seq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("finish() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinishTooLong(t *testing.T) {
+	s, err := New(db.NumberingConfig{Template: "{genre}-{seq}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.finish(Input{GenreCode: strings.Repeat("X", maxLen)}, 1); err == nil {
+		t.Fatal("finish() error = nil, want too long")
+	}
+}
@@ -1,5 +1,9 @@
 package printLabels
 
+import (
+	"IRIS-backend/internal/platform/apperr"
+)
+
 // ===== Requests =====
 type PrintRequest struct {
 	Config PrintConfig `json:"config" binding:"required"`
@@ -23,8 +27,8 @@ type LabelData struct {
 }
 
 type PrintResponse struct {
-	Success bool      `json:"success"`
-	Error   *APIError `json:"error,omitempty"`
+	Success bool          `json:"success"`
+	Error   *apperr.Error `json:"error,omitempty"`
 }
 
 // リクエスト例
@@ -1,44 +1,32 @@
 package printLabels
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
 )
 
 type Handler struct{ svc *Service }
 
 func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
-	r.POST("/assets/print", h.PrintLabels)
+	r.POST("/assets/print", auth.Allow(auth.StaffOnly...), h.PrintLabels)
 }
 
 func (h *Handler) PrintLabels(c *gin.Context) {
 	var req PrintRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid json")))
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	res, err := h.svc.PrintLabels(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, res)
 }
-
-// ===== helpers =====
-type errDTO struct {
-	Error *APIError `json:"error"`
-}
-
-func newErrDTO(err error) errDTO {
-	var apiErr *APIError
-	if errors.As(err, &apiErr) {
-		return errDTO{Error: apiErr}
-	}
-
-	return errDTO{Error: ErrInternal(err.Error())}
-}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"log"
+
+	"IRIS-backend/internal/platform/apperr"
 )
 
 type Service struct {
@@ -32,29 +34,29 @@ func (s *Service) PrintLabels(ctx context.Context, input PrintRequest) (*PrintRe
 		// store.goから返る各エラーをここでハンドリングする
 		if errors.Is(err, ErrTapeSizeNotMatched) {
 			// テープ幅の不一致は「クライアントからの要求とサーバーの状態の競合」として409 Conflictを返す
-			log.Println("[WARN]", ErrConflict(err.Error()))
-			return nil, ErrConflict(err.Error())
+			log.Println("[WARN]", apperr.Conflict(err.Error()))
+			return nil, apperr.Conflict(err.Error())
 		}
 		if errors.Is(err, ErrTemplateNotFound) {
 			// テンプレートが見つからないのは404 Not Found
-			log.Println("[WARN]", ErrNotFound(err.Error()))
-			return nil, ErrNotFound(err.Error())
+			log.Println("[WARN]", apperr.NotFound(err.Error()))
+			return nil, apperr.NotFound(err.Error())
 		}
 		if errors.Is(err, ErrNoPrintableSelected) {
 			// 印刷対象が選択されていないのは「クライアントのリクエストが不正」として400 Bad Request
-			log.Println("[WARN]", ErrInvalid(err.Error()))
-			return nil, ErrInvalid(err.Error())
+			log.Println("[WARN]", apperr.Invalid(err.Error()))
+			return nil, apperr.Invalid(err.Error())
 		}
 		if errors.Is(err, ErrSPC10NotFound) {
 			// SPC10.exeが見つからないのはサーバー内部の問題として500 Internal
 			// ただし、メッセージは具体的で分かりやすいものにする
-			log.Println("[ERROR]", ErrInternal(err.Error()))
-			return nil, ErrInternal(err.Error())
+			log.Println("[ERROR]", apperr.Internal(err.Error()))
+			return nil, apperr.Internal(err.Error())
 		}
 
 		// その他の予期せぬエラーも500 Internal
 		log.Printf("[ERROR] %v\n", err)
-		return nil, ErrInternal(err.Error())
+		return nil, apperr.Internal(err.Error())
 	}
 
 	// 成功時は空のレスポンスとnil errorを返す
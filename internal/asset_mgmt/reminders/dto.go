@@ -0,0 +1,21 @@
+package reminders
+
+import "time"
+
+// ---- Responses ----
+
+// RunResponse: 1回の実行結果
+type RunResponse struct {
+	Checked int       `json:"checked"` // 期限が近いか過ぎた未返却の貸出
+	Sent    int       `json:"sent"`
+	Failed  int       `json:"failed"`  // 間隔を空けて再送する
+	Skipped int       `json:"skipped"` // 送信済み・再通知や再試行の間隔内・宛先なしなど
+	RanAt   time.Time `json:"ran_at"`
+}
+
+type ReminderResponse struct {
+	Kind      string    `json:"kind"`
+	Recipient *string   `json:"recipient,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+	Error     *string   `json:"error,omitempty"`
+}
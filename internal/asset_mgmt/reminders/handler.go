@@ -0,0 +1,45 @@
+package reminders
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	// 手動実行（定期実行と同じ処理。送信済みの分は送らない）
+	r.POST("/lends/reminders/run", auth.Allow(auth.AdminOnly...), h.Run)
+	// 貸出ごとの送信履歴
+	r.GET("/lends/:lend_ulid/reminders", auth.Allow(auth.StaffOnly...), h.ListByLend)
+}
+
+func (h *Handler) Run(c *gin.Context) {
+	res, err := h.svc.Run(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) ListByLend(c *gin.Context) {
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	res, err := h.svc.ListByLend(c.Request.Context(), c.Param("lend_ulid"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
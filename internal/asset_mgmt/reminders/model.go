@@ -0,0 +1,37 @@
+package reminders
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 通知の種類（lend_reminders.kind）
+const (
+	KindDueSoon = "due_soon" // 期限が近い（予告）
+	KindOverdue = "overdue"  // 延滞
+)
+
+// DBモデル（lend_reminders と1:1）
+type Reminder struct {
+	ReminderID uint64
+	LendID     uint64
+	Kind       string
+	Recipient  sql.NullString
+	SentAt     time.Time
+	Error      sql.NullString
+}
+
+// candidate: 通知対象になりうる未返却の貸出（期限が近いか過ぎたもの）
+type candidate struct {
+	LendID           uint64
+	LendULID         string
+	ManagementNumber string
+	Name             string
+	BorrowerID       string
+	DisplayName      sql.NullString // users に居なければ NULL
+	Email            sql.NullString
+	DueOn            time.Time
+	Outstanding      int64
+	LastDueSoon      sql.NullTime // 送信に成功した最後の予告
+	LastOverdue      sql.NullTime // 送信に成功した最後の延滞通知
+}
@@ -0,0 +1,275 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/id"
+	"IRIS-backend/internal/platform/notify"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Service struct {
+	db       *sql.DB
+	store    *Store
+	notifier notify.Notifier
+	cfg      db.ReminderConfig
+	clock    id.Clock
+}
+
+func NewService(db *sql.DB, notifier notify.Notifier, cfg db.ReminderConfig) *Service {
+	return &Service{
+		db:       db,
+		store:    NewStore(db),
+		notifier: notifier,
+		cfg:      cfg,
+		clock:    id.RealClock{},
+	}
+}
+
+// 送信に失敗した通知の再試行の間隔。失敗のたびに倍にし、maxRetryBackoff で頭打ち
+const (
+	retryBackoff    = 15 * time.Minute
+	maxRetryBackoff = 24 * time.Hour
+)
+
+// Start: 設定の間隔で Run を繰り返す（0 なら何もしない）。ctx が終わったら止まる
+func (s *Service) Start(ctx context.Context) {
+	if s.cfg.IntervalMinutes <= 0 {
+		return
+	}
+	interval := time.Duration(s.cfg.IntervalMinutes) * time.Minute
+	log.Printf("[INFO] lend reminders every %s", interval)
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			res, err := s.Run(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				log.Printf("[WARN] lend reminders: %v", err)
+			case err == nil && (res.Sent > 0 || res.Failed > 0):
+				log.Printf("[INFO] lend reminders: sent=%d failed=%d", res.Sent, res.Failed)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Run: 期限が近い・過ぎた未返却の貸出に通知を送り、結果を lend_reminders に残す。
+// 予告は1回、延滞は初回と repeat_days ごと。失敗した分は成功扱いにしないので、間隔を空けて再送される
+// （max_attempts 回失敗したら諦める）
+func (s *Service) Run(ctx context.Context) (RunResponse, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return RunResponse{}, err
+	}
+	defer conn.Close()
+	ok, err := tryLock(ctx, conn)
+	if err != nil {
+		return RunResponse{}, err
+	}
+	if !ok {
+		return RunResponse{}, apperr.Conflict("reminder run already in progress")
+	}
+	defer unlock(conn)
+
+	now := s.clock.Now()
+	today := localDate(now)
+	horizon := today.AddDate(0, 0, max(s.cfg.DaysBefore, 0))
+
+	cands, err := s.store.Candidates(ctx, horizon.Format(time.DateOnly))
+	if err != nil {
+		return RunResponse{}, err
+	}
+	res := RunResponse{Checked: len(cands), RanAt: now}
+	for _, c := range cands {
+		kind := s.due(c, today)
+		if kind == "" {
+			res.Skipped++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		retry, err := s.retryDue(ctx, c.LendID, kind, now)
+		if err != nil {
+			return res, err
+		}
+		if !retry {
+			res.Skipped++
+			continue
+		}
+		r := &Reminder{LendID: c.LendID, Kind: kind, SentAt: s.clock.Now()}
+		msg := buildMessage(c, kind, today)
+		if c.Email.Valid && c.Email.String != "" {
+			msg.To = []string{c.Email.String}
+			r.Recipient = c.Email
+		}
+		switch err := s.notifier.Send(ctx, msg); {
+		case errors.Is(err, notify.ErrSkipped):
+			// 宛先がなく誰にも送っていない。送信済みにはせず、失敗と同じく間隔を空けて再試行する
+			r.Error = sql.NullString{String: "skipped: no recipient", Valid: true}
+			res.Skipped++
+		case err != nil:
+			log.Printf("[WARN] lend reminder %s %s: %v", c.LendULID, kind, err)
+			r.Error = sql.NullString{String: truncate(err.Error(), 500), Valid: true}
+			res.Failed++
+		default:
+			res.Sent++
+		}
+		if err := s.store.Insert(ctx, r); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// due: 今回送るべき通知の種類（送らないなら ""）
+func (s *Service) due(c candidate, today time.Time) string {
+	if c.Outstanding <= 0 {
+		return ""
+	}
+	dueOn := dateOf(c.DueOn)
+	if dueOn.Before(today) {
+		if !c.LastOverdue.Valid {
+			return KindOverdue
+		}
+		if s.cfg.RepeatDays > 0 && !localDate(c.LastOverdue.Time).AddDate(0, 0, s.cfg.RepeatDays).After(today) {
+			return KindOverdue
+		}
+		return ""
+	}
+	if s.cfg.DaysBefore > 0 && !c.LastDueSoon.Valid {
+		return KindDueSoon
+	}
+	return ""
+}
+
+// retryDue: 前回までの失敗を見て今回送ってよいか。上限に達したか、前回の失敗から間隔が空いていなければ送らない
+func (s *Service) retryDue(ctx context.Context, lendID uint64, kind string, now time.Time) (bool, error) {
+	n, last, err := s.store.Failures(ctx, lendID, kind)
+	if err != nil || n == 0 {
+		return err == nil, err
+	}
+	if s.cfg.MaxAttempts > 0 && n >= s.cfg.MaxAttempts {
+		return false, nil
+	}
+	return !now.Before(last.Time.Add(retryDelay(n))), nil
+}
+
+// retryDelay: n 回失敗した後の待ち時間
+func retryDelay(n int) time.Duration {
+	d := retryBackoff
+	for i := 1; i < n && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// ListByLend: GET /lends/:lend_ulid/reminders
+func (s *Service) ListByLend(ctx context.Context, lendULID string, p pagination.Request) (pagination.Page[ReminderResponse], error) {
+	lendID, err := s.store.LendID(ctx, lendULID)
+	if err == sql.ErrNoRows {
+		return pagination.Page[ReminderResponse]{}, apperr.NotFound("lend not found")
+	}
+	if err != nil {
+		return pagination.Page[ReminderResponse]{}, err
+	}
+	rows, err := s.store.ListByLend(ctx, lendID, p)
+	if err != nil {
+		return pagination.Page[ReminderResponse]{}, err
+	}
+	page := pagination.Build(p, rows, func(last Reminder) string {
+		return pagination.Encode(listSort, last.ReminderID)
+	})
+	return pagination.Map(page, toResponse), nil
+}
+
+// ---- helpers ----
+
+// buildMessage: 件名・本文は日本語。Webhook 向けに同じ内容を Data にも載せる
+func buildMessage(c candidate, kind string, today time.Time) notify.Message {
+	dueOn := dateOf(c.DueOn)
+	name := c.BorrowerID
+	if c.DisplayName.Valid && c.DisplayName.String != "" {
+		name = c.DisplayName.String
+	}
+	item := fmt.Sprintf("%s（%s）", c.Name, c.ManagementNumber)
+
+	var subject string
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s さん\n\n", name)
+	if kind == KindOverdue {
+		days := int(today.Sub(dueOn).Round(24*time.Hour) / (24 * time.Hour))
+		subject = fmt.Sprintf("[IRIS] 返却期限を過ぎています: %s", item)
+		fmt.Fprintf(&body, "貸出中の備品の返却期限を %d 日過ぎています。速やかに返却してください。\n\n", days)
+	} else {
+		subject = fmt.Sprintf("[IRIS] 返却期限が近づいています: %s", item)
+		body.WriteString("貸出中の備品の返却期限が近づいています。\n\n")
+	}
+	fmt.Fprintf(&body, "備品: %s\n数量: %d\n返却期限: %s\n貸出番号: %s\n", item, c.Outstanding, dueOn.Format(time.DateOnly), c.LendULID)
+
+	return notify.Message{
+		Event:   "lend." + kind,
+		Subject: subject,
+		Body:    body.String(),
+		Data: map[string]any{
+			"lend_ulid":            c.LendULID,
+			"management_number":    c.ManagementNumber,
+			"name":                 c.Name,
+			"borrower_id":          c.BorrowerID,
+			"due_on":               dueOn.Format(time.DateOnly),
+			"outstanding_quantity": c.Outstanding,
+		},
+	}
+}
+
+// localDate: 時刻をサーバーのローカル日付（0時）にする
+func localDate(t time.Time) time.Time {
+	y, m, d := t.In(time.Local).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// dateOf: DATE 列（UTC の 0 時で読まれる）をローカル日付にする
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func truncate(s string, n int) string {
+	for len(s) > n {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return s
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
+
+func toResponse(m Reminder) ReminderResponse {
+	return ReminderResponse{
+		Kind:      m.Kind,
+		Recipient: nullToPtr(m.Recipient),
+		SentAt:    m.SentAt,
+		Error:     nullToPtr(m.Error),
+	}
+}
@@ -0,0 +1,135 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+// Candidates: due_on が horizon（"YYYY-MM-DD"）以前の未返却の貸出。借受者のメールは users.login_id で引く。
+// 期限を延長した貸出は、延長より前に送った通知を数えない（新しい期限で改めて予告・延滞通知する）
+func (s *Store) Candidates(ctx context.Context, horizon string) ([]candidate, error) {
+	const lastSent = `(SELECT MAX(lr.sent_at) FROM lend_reminders lr WHERE lr.lend_id = l.lend_id AND lr.kind = ? AND lr.error IS NULL
+	  AND lr.sent_at >= COALESCE((SELECT MAX(rn.renewed_at) FROM lend_renewals rn WHERE rn.lend_id = l.lend_id), l.lent_at))`
+	q := `
+	SELECT l.lend_id, l.lend_ulid, m.management_number, m.name, l.borrower_id, u.display_name, u.email, l.due_on,
+	CAST(l.quantity - (SELECT COALESCE(SUM(r.quantity), 0) FROM returns r WHERE r.lend_id = l.lend_id)
+	  - (SELECT COALESCE(SUM(la.lost_quantity), 0) FROM lend_allocations la WHERE la.lend_id = l.lend_id) AS SIGNED),
+	` + lastSent + `,
+	` + lastSent + `
+	FROM lends l
+	JOIN assets_master m ON m.asset_master_id = l.asset_master_id
+	LEFT JOIN users u ON u.login_id = l.borrower_id
+	WHERE l.returned = 0 AND l.due_on IS NOT NULL AND l.due_on <= ?
+	ORDER BY l.due_on, l.lend_id`
+	rows, err := s.db.QueryContext(ctx, q, KindDueSoon, KindOverdue, horizon)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(
+			&c.LendID, &c.LendULID, &c.ManagementNumber, &c.Name, &c.BorrowerID, &c.DisplayName, &c.Email, &c.DueOn,
+			&c.Outstanding, &c.LastDueSoon, &c.LastOverdue,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Failures: kind の通知について、最後に成功した送信（延長後）より後の失敗の回数と最後の失敗時刻
+func (s *Store) Failures(ctx context.Context, lendID uint64, kind string) (int, sql.NullTime, error) {
+	const q = `
+	SELECT COUNT(*), MAX(lr.sent_at)
+	FROM lend_reminders lr
+	JOIN lends l ON l.lend_id = lr.lend_id
+	WHERE lr.lend_id = ? AND lr.kind = ? AND lr.error IS NOT NULL
+	  AND lr.sent_at >= COALESCE((SELECT MAX(rn.renewed_at) FROM lend_renewals rn WHERE rn.lend_id = l.lend_id), l.lent_at)
+	  AND lr.sent_at > COALESCE((SELECT MAX(ok.sent_at) FROM lend_reminders ok
+	    WHERE ok.lend_id = lr.lend_id AND ok.kind = lr.kind AND ok.error IS NULL), l.lent_at)`
+	var (
+		n    int
+		last sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, q, lendID, kind).Scan(&n, &last)
+	return n, last, err
+}
+
+func (s *Store) Insert(ctx context.Context, m *Reminder) error {
+	const q = `
+	INSERT INTO lend_reminders (lend_id, kind, recipient, sent_at, error)
+	VALUES (?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, q, m.LendID, m.Kind, m.Recipient, m.SentAt, m.Error)
+	return err
+}
+
+// LendID: 見つからなければ sql.ErrNoRows
+func (s *Store) LendID(ctx context.Context, lendULID string) (uint64, error) {
+	var id uint64
+	err := s.db.QueryRowContext(ctx, `SELECT lend_id FROM lends WHERE lend_ulid = ?`, lendULID).Scan(&id)
+	return id, err
+}
+
+const listSort = "reminder_id:desc"
+
+// ListByLend: 新しい順。cursor の続きから p.Fetch() 件
+func (s *Store) ListByLend(ctx context.Context, lendID uint64, p pagination.Request) ([]Reminder, error) {
+	q := `
+	SELECT reminder_id, lend_id, kind, recipient, sent_at, error
+	FROM lend_reminders WHERE lend_id = ?`
+	args := []any{lendID}
+	var lastID uint64
+	ok, err := p.After(listSort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		q += ` AND reminder_id < ?`
+		args = append(args, lastID)
+	}
+	q += ` ORDER BY reminder_id DESC LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Reminder
+	for rows.Next() {
+		var m Reminder
+		if err := rows.Scan(&m.ReminderID, &m.LendID, &m.Kind, &m.Recipient, &m.SentAt, &m.Error); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// tryLock: 複数台・手動実行との重複を MySQL の名前付きロックで防ぐ（接続に紐づくので専用の接続で取る）
+func tryLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK('iris.lend_reminders', 0)`).Scan(&got); err != nil {
+		return false, err
+	}
+	return got.Valid && got.Int64 == 1, nil
+}
+
+func unlock(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = conn.ExecContext(ctx, `SELECT RELEASE_LOCK('iris.lend_reminders')`)
+}
@@ -0,0 +1,54 @@
+package reservations
+
+import (
+	"time"
+
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// ---- Requests ----
+
+// POST /assets/:management_number/reservations（member は自分名義のみ）
+type CreateReservationRequest struct {
+	BorrowerID string  `json:"borrower_id" binding:"required"`
+	Quantity   uint    `json:"quantity" binding:"required"`
+	Note       *string `json:"note,omitempty"`
+}
+
+// ---- Responses ----
+
+type ReservationResponse struct {
+	ReservationULID  string     `json:"reservation_ulid"`
+	AssetMasterID    uint64     `json:"asset_master_id"`
+	ManagementNumber string     `json:"management_number"`
+	BorrowerID       string     `json:"borrower_id"`
+	Quantity         uint       `json:"quantity"`
+	Status           string     `json:"status"`
+	QueuePosition    *int       `json:"queue_position,omitempty"` // 順番待ちのとき何番目か（1 始まり。詳細のみ）
+	Note             *string    `json:"note,omitempty"`
+	CreatedByID      *string    `json:"created_by_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ReadyAt          *time.Time `json:"ready_at,omitempty"`
+	PickupUntil      *time.Time `json:"pickup_until,omitempty"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
+	LendULID         *string    `json:"lend_ulid,omitempty"`
+}
+
+// SweepResponse: 期限切れ処理の結果
+type SweepResponse struct {
+	Expired  int `json:"expired"`
+	Promoted int `json:"promoted"` // 繰り上がりで取り置きになった件数
+}
+
+// ---- List payload ----
+
+type Page struct {
+	pagination.Request
+	Order string // "asc" or "desc"
+}
+
+type ReservationFilter struct {
+	ManagementNumber *string
+	BorrowerID       *string
+	Status           *string
+}
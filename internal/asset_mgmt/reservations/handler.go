@@ -0,0 +1,102 @@
+package reservations
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/idempotency"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	// 予約（管理番号単位）… member は自分名義のみ（Service で判定）
+	r.POST("/assets/:management_number/reservations", auth.Allow(auth.Members...), idempotency.Middleware(svc.db), h.Create)
+
+	r.GET("/reservations", auth.Allow(auth.Members...), h.List)
+	r.GET("/reservations/:reservation_ulid", auth.Allow(auth.Members...), h.Get)
+	r.POST("/reservations/:reservation_ulid/cancel", auth.Allow(auth.Members...), h.Cancel)
+
+	// 期限切れの手動処理（定期処理と同じ）
+	r.POST("/reservations/sweep", auth.Allow(auth.AdminOnly...), h.Sweep)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Create(c.Request.Context(), c.Param("management_number"), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/reservations/"+res.ReservationULID)
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	res, err := h.svc.Get(c.Request.Context(), c.Param("reservation_ulid"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /reservations?management_number=&borrower_id=&status=&order=asc
+func (h *Handler) List(c *gin.Context) {
+	f := ReservationFilter{}
+	if v := c.Query("management_number"); v != "" {
+		f.ManagementNumber = &v
+	}
+	if v := c.Query("borrower_id"); v != "" {
+		f.BorrowerID = &v
+	}
+	if v := c.Query("status"); v != "" {
+		switch v {
+		case StatusWaiting, StatusReady, StatusFulfilled, StatusCancelled, StatusExpired:
+			f.Status = &v
+		default:
+			apperr.Abort(c, apperr.Invalid("status must be one of waiting, ready, fulfilled, cancelled, expired"))
+			return
+		}
+	}
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", "asc")}
+	res, err := h.svc.List(c.Request.Context(), f, p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Cancel(c *gin.Context) {
+	res, err := h.svc.Cancel(c.Request.Context(), c.Param("reservation_ulid"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Sweep(c *gin.Context) {
+	res, err := h.svc.Sweep(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
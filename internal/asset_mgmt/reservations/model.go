@@ -0,0 +1,41 @@
+package reservations
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 予約の状態（reservations.status）
+const (
+	StatusWaiting   = "waiting"   // 順番待ち
+	StatusReady     = "ready"     // 取り置き中（pickup_until まで）
+	StatusFulfilled = "fulfilled" // 貸出済み
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired" // 受け取り期限・待ち期限切れ
+)
+
+// DBモデル（reservations と1:1）
+type Reservation struct {
+	ReservationID    uint64
+	ReservationULID  string
+	AssetMasterID    uint64
+	ManagementNumber string // assets_master から
+	BorrowerID       string
+	Quantity         uint
+	Status           string
+	Note             sql.NullString
+	CreatedByID      sql.NullString
+	CreatedAt        time.Time
+	ReadyAt          sql.NullTime
+	PickupUntil      sql.NullTime
+	ClosedAt         sql.NullTime
+	LendID           sql.NullInt64
+	LendULID         sql.NullString // lends から
+}
+
+// Claim: 貸出時に見る、その資産マスタの予約の状況（lends から使う）
+type Claim struct {
+	Mine          *Reservation // 借受者本人の取り置き（ready）
+	HeldByOthers  uint         // 他の人の取り置き数
+	WaitingOthers int          // 他の人の順番待ちの件数
+}
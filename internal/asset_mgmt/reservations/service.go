@@ -0,0 +1,558 @@
+package reservations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/id"
+	"IRIS-backend/internal/platform/notify"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Service struct {
+	db       *sql.DB
+	store    *Store
+	notifier notify.Notifier
+	cfg      db.ReservationConfig
+	ids      id.Generator
+	clock    id.Clock
+}
+
+func NewService(db *sql.DB, notifier notify.Notifier, cfg db.ReservationConfig) *Service {
+	return &Service{
+		db:       db,
+		store:    NewStore(db),
+		notifier: notifier,
+		cfg:      cfg,
+		ids:      id.NewULIDGen(),
+		clock:    id.RealClock{},
+	}
+}
+
+// POST /assets/:management_number/reservations
+// 待ち行列の最後に並べる。在庫が空いていればその場で取り置きになる
+func (s *Service) Create(ctx context.Context, mng string, in CreateReservationRequest) (ReservationResponse, error) {
+	borrower := strings.TrimSpace(in.BorrowerID)
+	if in.Quantity == 0 {
+		return ReservationResponse{}, apperr.Invalid("quantity must be > 0")
+	}
+	if borrower == "" {
+		return ReservationResponse{}, apperr.Invalid("borrower_id required")
+	}
+	if p, ok := auth.FromContext(ctx); ok && p.Role == auth.RoleMember && p.LoginID != borrower {
+		return ReservationResponse{}, apperr.Forbidden("members can only create reservations for themselves")
+	}
+
+	ul := s.ids.New()
+	var ready []Reservation
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		masterID, err := st.ResolveMaster(ctx, mng)
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("assets_master not found")
+		}
+		if err != nil {
+			return err
+		}
+		rows, err := lockStock(ctx, tx, masterID)
+		if err != nil {
+			return err
+		}
+		queue, err := st.LockQueue(ctx, masterID)
+		if err != nil {
+			return err
+		}
+		// 在庫を全部戻しても満たせない数は受け付けない（先頭に居座って後ろの予約・貸出を止めてしまう）
+		total, err := capacity(ctx, tx, masterID, rows)
+		if err != nil {
+			return err
+		}
+		if in.Quantity > total {
+			return apperr.Unprocessable("quantity exceeds the total stock of this item").
+				WithDetail("total", total)
+		}
+		for _, q := range queue {
+			if q.BorrowerID == borrower {
+				return apperr.Conflict("borrower already has an active reservation for this item").
+					WithDetail("reservation_ulid", q.ReservationULID)
+			}
+		}
+
+		now := s.clock.Now().Truncate(time.Second)
+		r := Reservation{
+			ReservationULID:  ul,
+			AssetMasterID:    masterID,
+			ManagementNumber: mng,
+			BorrowerID:       borrower,
+			Quantity:         in.Quantity,
+			Status:           StatusWaiting,
+			Note:             toNullString(in.Note),
+			CreatedByID:      toNullString(auth.ActorID(ctx)),
+			CreatedAt:        now,
+		}
+		if r.ReservationID, err = st.Insert(ctx, &r); err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityReservation,
+			EntityID:   ul,
+			After:      toResponse(r),
+		}); err != nil {
+			return err
+		}
+		ready, err = s.advance(ctx, tx, rows, append(queue, r), now)
+		return err
+	})
+	if err != nil {
+		return ReservationResponse{}, err
+	}
+	s.Notify(ctx, ready)
+	return s.Get(ctx, ul)
+}
+
+// POST /reservations/:reservation_ulid/cancel
+// 取り置き中の取り消しなら、空いた分を次の人へ回す
+func (s *Service) Cancel(ctx context.Context, ul string) (ReservationResponse, error) {
+	var ready []Reservation
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		cur, err := st.GetByULID(ctx, ul)
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("reservation not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkBorrowerAccess(ctx, cur.BorrowerID); err != nil {
+			return err
+		}
+		// 在庫行 → 待ち行列の順にロックしてから状態を見る（取り置き・失効と競合させない）
+		rows, err := lockStock(ctx, tx, cur.AssetMasterID)
+		if err != nil {
+			return err
+		}
+		queue, err := st.LockQueue(ctx, cur.AssetMasterID)
+		if err != nil {
+			return err
+		}
+		idx := -1
+		for i, q := range queue {
+			if q.ReservationID == cur.ReservationID {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return apperr.Conflict("reservation is already " + cur.Status)
+		}
+
+		now := s.clock.Now().Truncate(time.Second)
+		before := queue[idx]
+		r := before
+		r.Status = StatusCancelled
+		r.ClosedAt = sql.NullTime{Time: now, Valid: true}
+		if err := s.save(ctx, tx, before, r); err != nil {
+			return err
+		}
+		rest := append(append([]Reservation{}, queue[:idx]...), queue[idx+1:]...)
+		ready, err = s.advance(ctx, tx, rows, rest, now)
+		return err
+	})
+	if err != nil {
+		return ReservationResponse{}, err
+	}
+	s.Notify(ctx, ready)
+	return s.Get(ctx, ul)
+}
+
+func (s *Service) Get(ctx context.Context, ul string) (ReservationResponse, error) {
+	r, err := s.store.GetByULID(ctx, ul)
+	if err == sql.ErrNoRows {
+		return ReservationResponse{}, apperr.NotFound("reservation not found")
+	}
+	if err != nil {
+		return ReservationResponse{}, err
+	}
+	if err := checkBorrowerAccess(ctx, r.BorrowerID); err != nil {
+		return ReservationResponse{}, err
+	}
+	out := toResponse(*r)
+	if r.Status == StatusWaiting {
+		pos, err := s.store.QueuePosition(ctx, *r)
+		if err != nil {
+			return ReservationResponse{}, err
+		}
+		out.QueuePosition = &pos
+	}
+	return out, nil
+}
+
+// GET /reservations（member は自分名義のみ）
+func (s *Service) List(ctx context.Context, f ReservationFilter, p Page) (pagination.Page[ReservationResponse], error) {
+	if pr, ok := auth.FromContext(ctx); ok && pr.Role == auth.RoleMember {
+		f.BorrowerID = &pr.LoginID
+	}
+	rows, err := s.store.List(ctx, f, p)
+	if err != nil {
+		return pagination.Page[ReservationResponse]{}, err
+	}
+	sort, _ := listOrder(p)
+	page := pagination.Build(p.Request, rows, func(last Reservation) string {
+		return pagination.Encode(sort, last.ReservationID)
+	})
+	return pagination.Map(page, toResponse), nil
+}
+
+// ---- 貸出・返却から Tx 内で使う ----
+
+// Promote: 在庫行をロックした後に呼ぶ。期限切れを落とし、空いている在庫を先着順に取り置きへ回す。
+// 取り置きになった予約を返すので、コミット後に Notify に渡す
+func (s *Service) Promote(ctx context.Context, tx db.DBTX, masterID uint64) ([]Reservation, error) {
+	rows, err := stock.Rows(ctx, tx, masterID)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := NewStore(tx).LockQueue(ctx, masterID)
+	if err != nil {
+		return nil, err
+	}
+	return s.advance(ctx, tx, rows, queue, s.clock.Now().Truncate(time.Second))
+}
+
+// Claim: Promote の後、同じ Tx で貸出前に呼ぶ。借受者本人の取り置きと、他の人が押さえている数
+func (s *Service) Claim(ctx context.Context, tx db.DBTX, masterID uint64, borrowerID string) (Claim, error) {
+	queue, err := NewStore(tx).LockQueue(ctx, masterID)
+	if err != nil {
+		return Claim{}, err
+	}
+	var c Claim
+	for i, r := range queue {
+		switch {
+		case r.BorrowerID == borrowerID:
+			if r.Status == StatusReady {
+				c.Mine = &queue[i]
+			}
+		case r.Status == StatusReady:
+			c.HeldByOthers += r.Quantity
+		default:
+			c.WaitingOthers++
+		}
+	}
+	return c, nil
+}
+
+// Fulfill: 取り置きを貸出に結びつけて閉じる
+func (s *Service) Fulfill(ctx context.Context, tx db.DBTX, r Reservation, lendID uint64, lendULID string) error {
+	after := r
+	after.Status = StatusFulfilled
+	after.ClosedAt = sql.NullTime{Time: s.clock.Now().Truncate(time.Second), Valid: true}
+	after.LendID = sql.NullInt64{Int64: int64(lendID), Valid: true}
+	after.LendULID = sql.NullString{String: lendULID, Valid: true}
+	return s.save(ctx, tx, r, after)
+}
+
+// HasWaiting: borrowerID 以外の、期限の切れていない予約があるか（返却期限の延長の可否）
+func (s *Service) HasWaiting(ctx context.Context, tx db.DBTX, masterID uint64, borrowerID string) (bool, error) {
+	queue, err := NewStore(tx).LockQueue(ctx, masterID)
+	if err != nil {
+		return false, err
+	}
+	now := s.clock.Now()
+	for _, r := range queue {
+		if r.BorrowerID != borrowerID && !s.expired(r, now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ---- 期限切れ ----
+
+// Start: 設定の間隔で Sweep を繰り返す（0 なら何もしない。期限切れは予約・貸出・返却のたびにも処理される）
+func (s *Service) Start(ctx context.Context) {
+	if s.cfg.IntervalMinutes <= 0 {
+		return
+	}
+	interval := time.Duration(s.cfg.IntervalMinutes) * time.Minute
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			res, err := s.Sweep(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				log.Printf("[WARN] reservation sweep: %v", err)
+			case err == nil && (res.Expired > 0 || res.Promoted > 0):
+				log.Printf("[INFO] reservation sweep: expired=%d promoted=%d", res.Expired, res.Promoted)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Sweep: 期限切れの予約を抱えている資産マスタごとに、失効と繰り上げを行う
+func (s *Service) Sweep(ctx context.Context) (SweepResponse, error) {
+	now := s.clock.Now().Truncate(time.Second)
+	waitingBefore := now.AddDate(0, 0, -s.maxWaitDays())
+	masters, err := s.store.DueMasters(ctx, now, &waitingBefore)
+	if err != nil {
+		return SweepResponse{}, err
+	}
+
+	var res SweepResponse
+	for _, masterID := range masters {
+		var (
+			ready   []Reservation
+			expired int
+		)
+		err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+			rows, err := lockStock(ctx, tx, masterID)
+			if err != nil {
+				return err
+			}
+			queue, err := NewStore(tx).LockQueue(ctx, masterID)
+			if err != nil {
+				return err
+			}
+			for _, r := range queue {
+				if s.expired(r, now) {
+					expired++
+				}
+			}
+			ready, err = s.advance(ctx, tx, rows, queue, now)
+			return err
+		})
+		if err != nil {
+			return res, err
+		}
+		res.Expired += expired
+		res.Promoted += len(ready)
+		s.Notify(ctx, ready)
+	}
+	return res, nil
+}
+
+// ---- helpers ----
+
+// advance: ロック済みの待ち行列（先着順）から期限切れを失効させ、空いた在庫の分だけ先頭から取り置きにする。
+// rows は同じ Tx で待ち行列より先にロックした在庫行。
+// 先頭が足りなければ後ろは飛ばさない（先着順を守る）。ただし廃棄などで在庫を全部戻しても満たせなくなった
+// 順番待ちは、先頭を塞ぎ続けないよう失効させる。取り置きにした予約を返す
+func (s *Service) advance(ctx context.Context, tx db.DBTX, rows []stock.Row, queue []Reservation, now time.Time) ([]Reservation, error) {
+	if len(queue) == 0 {
+		return nil, nil
+	}
+	g, err := statuses.LoadGraph(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	lendable := stock.Total(rows, stock.Lendable(g))
+	onLoan, err := NewStore(tx).OnLoan(ctx, queue[0].AssetMasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make([]Reservation, 0, len(queue))
+	var held uint
+	for _, r := range queue {
+		unfillable := r.Status == StatusWaiting && r.Quantity > lendable+onLoan
+		if !s.expired(r, now) && !unfillable {
+			live = append(live, r)
+			if r.Status == StatusReady {
+				held += r.Quantity
+			}
+			continue
+		}
+		after := r
+		after.Status = StatusExpired
+		after.ClosedAt = sql.NullTime{Time: now, Valid: true}
+		if err := s.save(ctx, tx, r, after); err != nil {
+			return nil, err
+		}
+	}
+
+	var free uint
+	if lendable > held {
+		free = lendable - held
+	}
+
+	var ready []Reservation
+	for _, r := range live {
+		if r.Status != StatusWaiting {
+			continue
+		}
+		if r.Quantity > free {
+			break
+		}
+		after := r
+		after.Status = StatusReady
+		after.ReadyAt = sql.NullTime{Time: now, Valid: true}
+		after.PickupUntil = sql.NullTime{Time: now.Add(s.pickupWindow()), Valid: true}
+		if err := s.save(ctx, tx, r, after); err != nil {
+			return nil, err
+		}
+		free -= r.Quantity
+		ready = append(ready, after)
+	}
+	return ready, nil
+}
+
+// capacity: 在庫を全部戻したときに貸出に回せる数（貸出に回せる行の在庫 + 貸出中で未返却の数）
+func capacity(ctx context.Context, tx db.DBTX, masterID uint64, rows []stock.Row) (uint, error) {
+	g, err := statuses.LoadGraph(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	onLoan, err := NewStore(tx).OnLoan(ctx, masterID)
+	if err != nil {
+		return 0, err
+	}
+	return stock.Total(rows, stock.Lendable(g)) + onLoan, nil
+}
+
+// lockStock: 待ち行列より先に在庫行をロックする（貸出・返却と同じ順。逆順だとデッドロックする）。
+// 在庫行が1つもない資産マスタでも、予約の失効は進められるように空で返す
+func lockStock(ctx context.Context, tx db.DBTX, masterID uint64) ([]stock.Row, error) {
+	rows, err := stock.LockRows(ctx, tx, masterID)
+	if apperr.HasCode(err, apperr.CodeNotFound) {
+		return nil, nil
+	}
+	return rows, err
+}
+
+// expired: 取り置きの受け取り期限か、順番待ちの期限を過ぎたか
+func (s *Service) expired(r Reservation, now time.Time) bool {
+	switch r.Status {
+	case StatusReady:
+		return r.PickupUntil.Valid && r.PickupUntil.Time.Before(now)
+	case StatusWaiting:
+		return r.CreatedAt.Before(now.AddDate(0, 0, -s.maxWaitDays()))
+	}
+	return false
+}
+
+func (s *Service) maxWaitDays() int {
+	if s.cfg.MaxWaitDays <= 0 {
+		return 30
+	}
+	return s.cfg.MaxWaitDays
+}
+
+func (s *Service) pickupWindow() time.Duration {
+	if s.cfg.PickupHours <= 0 {
+		return 48 * time.Hour
+	}
+	return time.Duration(s.cfg.PickupHours) * time.Hour
+}
+
+// save: 状態の変化を書き戻して監査ログに残す
+func (s *Service) save(ctx context.Context, tx db.DBTX, before, after Reservation) error {
+	if err := NewStore(tx).Update(ctx, &after); err != nil {
+		return err
+	}
+	return audit.Record(ctx, tx, audit.Entry{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityReservation,
+		EntityID:   after.ReservationULID,
+		Before:     toResponse(before),
+		After:      toResponse(after),
+	})
+}
+
+// Notify: 取り置きになった予約の借受者へ知らせる（コミット後に呼ぶ。失敗はログだけ）
+func (s *Service) Notify(ctx context.Context, ready []Reservation) {
+	for _, r := range ready {
+		name, displayName, email, err := s.store.Recipient(ctx, r.AssetMasterID, r.BorrowerID)
+		if err != nil {
+			log.Printf("[WARN] reservation notify %s: %v", r.ReservationULID, err)
+			continue
+		}
+		who := r.BorrowerID
+		if displayName.Valid && displayName.String != "" {
+			who = displayName.String
+		}
+		item := fmt.Sprintf("%s（%s）", name, r.ManagementNumber)
+		until := r.PickupUntil.Time.In(time.Local).Format("2006-01-02 15:04")
+
+		msg := notify.Message{
+			Event:   "reservation.ready",
+			Subject: "[IRIS] 予約した備品を取り置きました: " + item,
+			Body: fmt.Sprintf("%s さん\n\n予約していた備品の在庫が戻ったので取り置きました。受け取り期限までに貸出の手続きをしてください。\n\n"+
+				"備品: %s\n数量: %d\n受け取り期限: %s\n予約番号: %s\n", who, item, r.Quantity, until, r.ReservationULID),
+			Data: toResponse(r),
+		}
+		if email.Valid && email.String != "" {
+			msg.To = []string{email.String}
+		}
+		switch err := s.notifier.Send(ctx, msg); {
+		case errors.Is(err, notify.ErrSkipped):
+			log.Printf("[INFO] reservation notify %s: no recipient", r.ReservationULID)
+		case err != nil:
+			log.Printf("[WARN] reservation notify %s: %v", r.ReservationULID, err)
+		}
+	}
+}
+
+// checkBorrowerAccess: member が他人名義の予約を扱おうとした場合は 403
+func checkBorrowerAccess(ctx context.Context, borrowerID string) error {
+	if p, ok := auth.FromContext(ctx); ok && p.Role == auth.RoleMember && p.LoginID != borrowerID {
+		return apperr.Forbidden("members can only access their own reservations")
+	}
+	return nil
+}
+
+func toNullString(s *string) (ns sql.NullString) {
+	if s != nil && strings.TrimSpace(*s) != "" {
+		ns.Valid, ns.String = true, *s
+	}
+	return
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
+
+func nullTimeToPtr(nt sql.NullTime) *time.Time {
+	if nt.Valid {
+		v := nt.Time
+		return &v
+	}
+	return nil
+}
+
+func toResponse(r Reservation) ReservationResponse {
+	return ReservationResponse{
+		ReservationULID:  r.ReservationULID,
+		AssetMasterID:    r.AssetMasterID,
+		ManagementNumber: r.ManagementNumber,
+		BorrowerID:       r.BorrowerID,
+		Quantity:         r.Quantity,
+		Status:           r.Status,
+		Note:             nullToPtr(r.Note),
+		CreatedByID:      nullToPtr(r.CreatedByID),
+		CreatedAt:        r.CreatedAt,
+		ReadyAt:          nullTimeToPtr(r.ReadyAt),
+		PickupUntil:      nullTimeToPtr(r.PickupUntil),
+		ClosedAt:         nullTimeToPtr(r.ClosedAt),
+		LendULID:         nullToPtr(r.LendULID),
+	}
+}
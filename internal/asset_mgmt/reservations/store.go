@@ -0,0 +1,213 @@
+package reservations
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+const selectReservation = `
+	SELECT r.reservation_id, r.reservation_ulid, r.asset_master_id, m.management_number, r.borrower_id, r.quantity, r.status,
+	r.note, r.created_by_id, r.created_at, r.ready_at, r.pickup_until, r.closed_at, r.lend_id, l.lend_ulid
+	FROM reservations r
+	JOIN assets_master m ON m.asset_master_id = r.asset_master_id
+	LEFT JOIN lends l ON l.lend_id = r.lend_id`
+
+func scanReservation(sc interface{ Scan(dest ...any) error }) (Reservation, error) {
+	var r Reservation
+	err := sc.Scan(
+		&r.ReservationID, &r.ReservationULID, &r.AssetMasterID, &r.ManagementNumber, &r.BorrowerID, &r.Quantity, &r.Status,
+		&r.Note, &r.CreatedByID, &r.CreatedAt, &r.ReadyAt, &r.PickupUntil, &r.ClosedAt, &r.LendID, &r.LendULID,
+	)
+	return r, err
+}
+
+// ResolveMaster: management_number -> asset_master_id（見つからなければ sql.ErrNoRows）
+func (s *Store) ResolveMaster(ctx context.Context, mng string) (uint64, error) {
+	var id uint64
+	err := s.db.QueryRowContext(ctx, `SELECT asset_master_id FROM assets_master WHERE management_number = ?`, mng).Scan(&id)
+	return id, err
+}
+
+// GetByULID: 見つからなければ sql.ErrNoRows
+func (s *Store) GetByULID(ctx context.Context, ul string) (*Reservation, error) {
+	r, err := scanReservation(s.db.QueryRowContext(ctx, selectReservation+` WHERE r.reservation_ulid = ?`, ul))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// LockQueue: 資産マスタの待ち行列（waiting / ready）を先着順にロックする。
+// 引き当て・取り置き・失効はすべてこのロックの下で行う（在庫行のロックより後に取る）
+func (s *Store) LockQueue(ctx context.Context, masterID uint64) ([]Reservation, error) {
+	rows, err := s.db.QueryContext(ctx, selectReservation+`
+	WHERE r.asset_master_id = ? AND r.status IN ('waiting', 'ready')
+	ORDER BY r.reservation_id
+	FOR UPDATE OF r`, masterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// QueuePosition: 同じ資産マスタの順番待ちのうち、この予約より前にあるもの + 1
+func (s *Store) QueuePosition(ctx context.Context, r Reservation) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+	SELECT COUNT(*) FROM reservations
+	WHERE asset_master_id = ? AND status = 'waiting' AND reservation_id < ?`, r.AssetMasterID, r.ReservationID).Scan(&n)
+	return n + 1, err
+}
+
+func (s *Store) Insert(ctx context.Context, r *Reservation) (uint64, error) {
+	const q = `
+	INSERT INTO reservations
+	(reservation_ulid, asset_master_id, borrower_id, quantity, status, note, created_by_id, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, q,
+		r.ReservationULID, r.AssetMasterID, r.BorrowerID, r.Quantity, r.Status, r.Note, r.CreatedByID, r.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return uint64(id), err
+}
+
+// Update: 状態と時刻・貸出の紐づけを書き戻す
+func (s *Store) Update(ctx context.Context, r *Reservation) error {
+	const q = `
+	UPDATE reservations
+	SET status = ?, ready_at = ?, pickup_until = ?, closed_at = ?, lend_id = ?
+	WHERE reservation_id = ?`
+	_, err := s.db.ExecContext(ctx, q, r.Status, r.ReadyAt, r.PickupUntil, r.ClosedAt, r.LendID, r.ReservationID)
+	return err
+}
+
+// OnLoan: master 配下の在庫行から貸し出されていて、まだ戻っていない数（紛失分は除く）
+func (s *Store) OnLoan(ctx context.Context, masterID uint64) (uint, error) {
+	const q = `
+	SELECT COALESCE(SUM(la.quantity - la.returned_quantity - la.lost_quantity), 0)
+	FROM lend_allocations la
+	JOIN assets a ON a.asset_id = la.asset_id
+	WHERE a.asset_master_id = ?`
+	var n uint
+	err := s.db.QueryRowContext(ctx, q, masterID).Scan(&n)
+	return n, err
+}
+
+// DueMasters: 期限切れの予約を抱えている資産マスタ（取り置きの受け取り期限切れか、待ち期限切れ）
+func (s *Store) DueMasters(ctx context.Context, now time.Time, waitingBefore *time.Time) ([]uint64, error) {
+	q := `
+	SELECT DISTINCT asset_master_id FROM reservations
+	WHERE (status = 'ready' AND pickup_until < ?)`
+	args := []any{now}
+	if waitingBefore != nil {
+		q += ` OR (status = 'waiting' AND created_at < ?)`
+		args = append(args, *waitingBefore)
+	}
+	q += ` ORDER BY asset_master_id`
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// Recipient: 通知に使う資産名と借受者の表示名・メール（users に居なければ NULL）
+func (s *Store) Recipient(ctx context.Context, masterID uint64, borrowerID string) (name string, displayName, email sql.NullString, err error) {
+	const q = `
+	SELECT m.name, u.display_name, u.email
+	FROM assets_master m LEFT JOIN users u ON u.login_id = ?
+	WHERE m.asset_master_id = ?`
+	err = s.db.QueryRowContext(ctx, q, borrowerID, masterID).Scan(&name, &displayName, &email)
+	return
+}
+
+// listOrder: 一覧の並び（reservation_id）と cursor の識別子
+func listOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) == "desc"
+	sort := "reservation_id:asc"
+	if desc {
+		sort = "reservation_id:desc"
+	}
+	return sort, []pagination.Column{{Expr: "r.reservation_id", Desc: desc}}
+}
+
+// List: cursor の続きから p.Fetch() 件。既定は古い順（待ち行列の順）
+func (s *Store) List(ctx context.Context, f ReservationFilter, p Page) ([]Reservation, error) {
+	sb := strings.Builder{}
+	sb.WriteString(selectReservation + ` WHERE 1=1`)
+	args := []any{}
+	if f.ManagementNumber != nil {
+		sb.WriteString(` AND m.management_number = ?`)
+		args = append(args, *f.ManagementNumber)
+	}
+	if f.BorrowerID != nil {
+		sb.WriteString(` AND r.borrower_id = ?`)
+		args = append(args, *f.BorrowerID)
+	}
+	if f.Status != nil {
+		sb.WriteString(` AND r.status = ?`)
+		args = append(args, *f.Status)
+	}
+
+	sort, cols := listOrder(p)
+	var lastID uint64
+	ok, err := p.After(sort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastID})
+		sb.WriteString(` AND ` + cond)
+		args = append(args, seekArgs...)
+	}
+	sb.WriteString(` ORDER BY ` + pagination.OrderBy(cols) + ` LIMIT ?`)
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
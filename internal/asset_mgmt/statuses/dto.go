@@ -0,0 +1,33 @@
+package statuses
+
+// ---- Requests ----
+
+// status_id は他テーブルから外部キーで参照されるため採番せず、作成時に指定する
+type CreateStatusRequest struct {
+	StatusID      uint   `json:"status_id" binding:"required,gt=0"`
+	StatusCode    string `json:"status_code" binding:"required,max=32"`
+	StatusName    string `json:"status_name" binding:"required,max=50"`
+	NextStatusIDs []uint `json:"next_status_ids,omitempty"` // このステータスから遷移できる先
+}
+
+// next_status_ids を指定した場合は遷移先を丸ごと置き換える（[] で遷移先なし）
+type UpdateStatusRequest struct {
+	StatusCode    *string `json:"status_code,omitempty" binding:"omitempty,max=32"`
+	StatusName    *string `json:"status_name,omitempty" binding:"omitempty,max=50"`
+	NextStatusIDs *[]uint `json:"next_status_ids,omitempty"`
+}
+
+// ---- Responses ----
+
+type StatusResponse struct {
+	StatusID      uint   `json:"status_id"`
+	StatusCode    string `json:"status_code"`
+	StatusName    string `json:"status_name"`
+	IsSystem      bool   `json:"is_system"`
+	NextStatusIDs []uint `json:"next_status_ids"`
+}
+
+type TransitionResponse struct {
+	FromStatusID uint `json:"from_status_id"`
+	ToStatusID   uint `json:"to_status_id"`
+}
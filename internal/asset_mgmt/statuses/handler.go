@@ -0,0 +1,111 @@
+package statuses
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.GET("/statuses", auth.Allow(auth.Members...), h.List)
+	r.GET("/statuses/transitions", auth.Allow(auth.Members...), h.ListTransitions)
+	r.GET("/statuses/:status_id", auth.Allow(auth.Members...), h.Get)
+	r.POST("/statuses", auth.Allow(auth.AdminOnly...), h.Create)
+	r.PUT("/statuses/:status_id", auth.Allow(auth.AdminOnly...), h.Update)
+	r.DELETE("/statuses/:status_id", auth.Allow(auth.AdminOnly...), h.Delete)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	items, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *Handler) ListTransitions(c *gin.Context) {
+	items, err := h.svc.ListTransitions(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := statusIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/statuses/"+strconv.FormatUint(uint64(res.StatusID), 10))
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := statusIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := statusIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ---- helpers ----
+
+func statusIDParam(c *gin.Context) (uint, bool) {
+	v, err := strconv.ParseUint(c.Param("status_id"), 10, 32)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("status_id must be a number"))
+		return 0, false
+	}
+	return uint(v), true
+}
@@ -0,0 +1,40 @@
+package statuses
+
+import "sort"
+
+// 固定ステータスID（asset_statuses の is_system = 1 の行）。
+// 在庫の出し入れ（lends / disposals / stock）はこの値で遷移させる。
+const (
+	Available uint = 1 // 利用可能
+	InUse     uint = 2 // 使用中
+	Repair    uint = 3 // 修理中
+	Lent      uint = 4 // 貸出中
+	Disposed  uint = 5 // 廃棄済
+	Lost      uint = 6 // 紛失
+)
+
+// DBモデル（asset_statuses と1:1）
+type Status struct {
+	StatusID   uint
+	StatusCode string
+	StatusName string
+	IsSystem   bool
+}
+
+// Graph: 許可されている遷移（from → to の集合）
+type Graph map[uint]map[uint]bool
+
+// Allows: from → to が許可されているか（同じステータスのままは常に可）
+func (g Graph) Allows(from, to uint) bool {
+	return from == to || g[from][to]
+}
+
+// Next: from から遷移できるステータスID（昇順）
+func (g Graph) Next(from uint) []uint {
+	out := make([]uint, 0, len(g[from]))
+	for to := range g[from] {
+		out = append(out, to)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
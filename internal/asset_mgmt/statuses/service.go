@@ -0,0 +1,312 @@
+package statuses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+var codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, store: NewStore(db)}
+}
+
+// ===== 他パッケージから使う遷移チェック =====
+
+// LoadGraph: 遷移表をまとめて読む（1リクエストで何行も判定するとき）
+func LoadGraph(ctx context.Context, q db.DBTX) (Graph, error) {
+	return NewStore(q).Graph(ctx)
+}
+
+// CheckTransition: from → to が遷移表で許可されているか。
+// 許可されていなければ CONFLICT（遷移可能な先を details に付ける）、to が存在しなければ INVALID_ARGUMENT。
+func CheckTransition(ctx context.Context, q db.DBTX, from, to uint) error {
+	if from == to {
+		return nil
+	}
+	st := NewStore(q)
+	ok, err := st.Allows(ctx, from, to)
+	if err != nil || ok {
+		return err
+	}
+	return errTransition(ctx, st, from, to)
+}
+
+func errTransition(ctx context.Context, st *Store, from, to uint) error {
+	target, err := st.Get(ctx, to)
+	if err == sql.ErrNoRows {
+		return apperr.Invalid("unknown status_id").WithDetail("status_id", to)
+	}
+	if err != nil {
+		return err
+	}
+	fromCode := strconv.FormatUint(uint64(from), 10)
+	if cur, err := st.Get(ctx, from); err == nil {
+		fromCode = cur.StatusCode
+	}
+	g, err := st.Graph(ctx)
+	if err != nil {
+		return err
+	}
+	return apperr.Conflict(fmt.Sprintf("status transition '%s' -> '%s' is not allowed", fromCode, target.StatusCode)).
+		WithDetail("from_status_id", from).
+		WithDetail("to_status_id", to).
+		WithDetail("allowed_status_ids", g.Next(from))
+}
+
+// ===== CRUD =====
+
+// GET /statuses
+func (s *Service) List(ctx context.Context) ([]StatusResponse, error) {
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.store.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StatusResponse, 0, len(items))
+	for _, st := range items {
+		out = append(out, toResponse(st, g))
+	}
+	return out, nil
+}
+
+// GET /statuses/transitions
+func (s *Service) ListTransitions(ctx context.Context) ([]TransitionResponse, error) {
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.store.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []TransitionResponse{}
+	for _, st := range items {
+		for _, to := range g.Next(st.StatusID) {
+			out = append(out, TransitionResponse{FromStatusID: st.StatusID, ToStatusID: to})
+		}
+	}
+	return out, nil
+}
+
+// GET /statuses/:status_id
+func (s *Service) Get(ctx context.Context, id uint) (StatusResponse, error) {
+	st, err := s.store.Get(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return StatusResponse{}, apperr.NotFound("status not found")
+		}
+		return StatusResponse{}, err
+	}
+	g, err := s.store.Graph(ctx)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+	return toResponse(*st, g), nil
+}
+
+// POST /statuses
+func (s *Service) Create(ctx context.Context, in CreateStatusRequest) (StatusResponse, error) {
+	code := strings.TrimSpace(in.StatusCode)
+	name := strings.TrimSpace(in.StatusName)
+	if !codePattern.MatchString(code) {
+		return StatusResponse{}, apperr.Invalid("status_code must match ^[a-z][a-z0-9_]*$")
+	}
+	if name == "" {
+		return StatusResponse{}, apperr.Invalid("status_name must not be empty")
+	}
+	next, err := normalizeNext(in.StatusID, in.NextStatusIDs)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+
+	var out StatusResponse
+	err = db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		m := Status{StatusID: in.StatusID, StatusCode: code, StatusName: name}
+		if err := st.Insert(ctx, m); err != nil {
+			return mapWriteErr(err)
+		}
+		if err := st.ReplaceTransitions(ctx, in.StatusID, next); err != nil {
+			return mapWriteErr(err)
+		}
+		out = StatusResponse{StatusID: m.StatusID, StatusCode: code, StatusName: name, NextStatusIDs: next}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityAssetStatus,
+			EntityID:   strconv.FormatUint(uint64(in.StatusID), 10),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return StatusResponse{}, err
+	}
+	return out, nil
+}
+
+// PUT /statuses/:status_id
+func (s *Service) Update(ctx context.Context, id uint, in UpdateStatusRequest) (StatusResponse, error) {
+	if in.StatusCode != nil && !codePattern.MatchString(strings.TrimSpace(*in.StatusCode)) {
+		return StatusResponse{}, apperr.Invalid("status_code must match ^[a-z][a-z0-9_]*$")
+	}
+	if in.StatusName != nil && strings.TrimSpace(*in.StatusName) == "" {
+		return StatusResponse{}, apperr.Invalid("status_name must not be empty")
+	}
+	var next []uint
+	if in.NextStatusIDs != nil {
+		var err error
+		if next, err = normalizeNext(id, *in.NextStatusIDs); err != nil {
+			return StatusResponse{}, err
+		}
+	}
+
+	var out StatusResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		cur, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("status not found")
+			}
+			return err
+		}
+		if cur.IsSystem && in.StatusCode != nil && strings.TrimSpace(*in.StatusCode) != cur.StatusCode {
+			return apperr.Conflict("status_code of a system status cannot be changed")
+		}
+		g, err := st.Graph(ctx)
+		if err != nil {
+			return err
+		}
+		before := toResponse(*cur, g)
+
+		if err := st.Update(ctx, id, in); err != nil {
+			return mapWriteErr(err)
+		}
+		if in.NextStatusIDs != nil {
+			if err := st.ReplaceTransitions(ctx, id, next); err != nil {
+				return mapWriteErr(err)
+			}
+		}
+
+		after, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if g, err = st.Graph(ctx); err != nil {
+			return err
+		}
+		out = toResponse(*after, g)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityAssetStatus,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     before,
+			After:      out,
+		})
+	})
+	if err != nil {
+		return StatusResponse{}, err
+	}
+	return out, nil
+}
+
+// DELETE /statuses/:status_id（固定ステータスと、在庫行が使っているステータスは消せない）
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		cur, err := st.Lock(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperr.NotFound("status not found")
+			}
+			return err
+		}
+		if cur.IsSystem {
+			return apperr.Conflict("system status cannot be deleted")
+		}
+		n, err := st.CountAssets(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return apperr.Conflict("status is in use by assets").WithDetail("asset_count", n)
+		}
+		g, err := st.Graph(ctx)
+		if err != nil {
+			return err
+		}
+		before := toResponse(*cur, g)
+		if err := st.Delete(ctx, id); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityAssetStatus,
+			EntityID:   strconv.FormatUint(uint64(id), 10),
+			Before:     before,
+		})
+	})
+}
+
+// ---- helpers ----
+
+// normalizeNext: 重複を除いて昇順に。自分自身への遷移は不要（同じステータスのままは常に可）
+func normalizeNext(self uint, ids []uint) ([]uint, error) {
+	seen := map[uint]bool{}
+	out := []uint{}
+	for _, id := range ids {
+		if id == self {
+			return nil, apperr.Invalid("next_status_ids must not contain the status itself")
+		}
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+func mapWriteErr(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return apperr.Conflict("status_id or status_code already exists")
+		case 1452:
+			return apperr.Invalid("next_status_ids contains an unknown status_id")
+		}
+	}
+	return err
+}
+
+func toResponse(st Status, g Graph) StatusResponse {
+	return StatusResponse{
+		StatusID:      st.StatusID,
+		StatusCode:    st.StatusCode,
+		StatusName:    st.StatusName,
+		IsSystem:      st.IsSystem,
+		NextStatusIDs: g.Next(st.StatusID),
+	}
+}
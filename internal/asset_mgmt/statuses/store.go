@@ -0,0 +1,137 @@
+package statuses
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// Store: *sql.DB でも *sql.Tx でも動く（遷移チェックは呼び出し元の Tx 上で行う）
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+const selectStatus = `SELECT status_id, status_code, status_name, is_system FROM asset_statuses`
+
+func (s *Store) List(ctx context.Context) ([]Status, error) {
+	rows, err := s.db.QueryContext(ctx, selectStatus+` ORDER BY status_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Status
+	for rows.Next() {
+		var st Status
+		if err := rows.Scan(&st.StatusID, &st.StatusCode, &st.StatusName, &st.IsSystem); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// Get: 見つからなければ sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uint) (*Status, error) {
+	return s.get(ctx, selectStatus+` WHERE status_id = ?`, id)
+}
+
+func (s *Store) Lock(ctx context.Context, id uint) (*Status, error) {
+	return s.get(ctx, selectStatus+` WHERE status_id = ? FOR UPDATE`, id)
+}
+
+func (s *Store) get(ctx context.Context, q string, id uint) (*Status, error) {
+	var st Status
+	if err := s.db.QueryRowContext(ctx, q, id).Scan(&st.StatusID, &st.StatusCode, &st.StatusName, &st.IsSystem); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *Store) Insert(ctx context.Context, st Status) error {
+	const q = `INSERT INTO asset_statuses (status_id, status_code, status_name, is_system) VALUES (?, ?, ?, 0)`
+	_, err := s.db.ExecContext(ctx, q, st.StatusID, st.StatusCode, st.StatusName)
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, id uint, in UpdateStatusRequest) error {
+	sets := []string{}
+	args := []any{}
+	if in.StatusCode != nil {
+		sets = append(sets, "status_code = ?")
+		args = append(args, strings.TrimSpace(*in.StatusCode))
+	}
+	if in.StatusName != nil {
+		sets = append(sets, "status_name = ?")
+		args = append(args, strings.TrimSpace(*in.StatusName))
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE asset_statuses SET %s WHERE status_id = ?`, strings.Join(sets, ", "))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, id uint) error {
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM asset_status_transitions WHERE from_status_id = ? OR to_status_id = ?`, id, id); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM asset_statuses WHERE status_id = ?`, id)
+	return err
+}
+
+// CountAssets: そのステータスの在庫行数（使用中のステータスは削除させない）
+func (s *Store) CountAssets(ctx context.Context, id uint) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM assets WHERE status_id = ?`, id).Scan(&n)
+	return n, err
+}
+
+// ===== 遷移 =====
+
+func (s *Store) Graph(ctx context.Context) (Graph, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT from_status_id, to_status_id FROM asset_status_transitions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	g := Graph{}
+	for rows.Next() {
+		var from, to uint
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, err
+		}
+		if g[from] == nil {
+			g[from] = map[uint]bool{}
+		}
+		g[from][to] = true
+	}
+	return g, rows.Err()
+}
+
+func (s *Store) Allows(ctx context.Context, from, to uint) (bool, error) {
+	const q = `SELECT EXISTS(SELECT 1 FROM asset_status_transitions WHERE from_status_id = ? AND to_status_id = ?)`
+	var ok bool
+	err := s.db.QueryRowContext(ctx, q, from, to).Scan(&ok)
+	return ok, err
+}
+
+// ReplaceTransitions: from からの遷移先を to で置き換える
+func (s *Store) ReplaceTransitions(ctx context.Context, from uint, to []uint) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM asset_status_transitions WHERE from_status_id = ?`, from); err != nil {
+		return err
+	}
+	const q = `INSERT INTO asset_status_transitions (from_status_id, to_status_id) VALUES (?, ?)`
+	for _, t := range to {
+		if _, err := s.db.ExecContext(ctx, q, from, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
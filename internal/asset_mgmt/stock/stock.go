@@ -0,0 +1,218 @@
+// Package stock: 管理番号（assets_master）配下の在庫行（assets）をまたいだ数量の出し入れ。
+// lends / disposals から Tx 内で使う。
+//
+// ロックは必ず asset_id 昇順で取る（複数行を動かす操作同士のデッドロックを避けるため）。
+// 割り当ても asset_id 昇順で先頭の行から詰める。
+package stock
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+)
+
+// Row: 在庫行の現在値（監査ログの before/after にもそのまま使う）
+type Row struct {
+	AssetID    uint64 `json:"asset_id"`
+	Quantity   uint   `json:"quantity"`
+	StatusID   uint   `json:"status_id"`
+	LocationID *uint  `json:"location_id,omitempty"`
+}
+
+// Allocation: 在庫行ごとの割り当て数
+type Allocation struct {
+	AssetID  uint64 `json:"asset_id"`
+	Quantity uint   `json:"quantity"`
+}
+
+const selectRow = `SELECT asset_id, quantity, status_id, location_id FROM assets`
+
+// LockRows: master 配下の全在庫行を asset_id 昇順でロックして返す
+func LockRows(ctx context.Context, tx db.DBTX, masterID uint64) ([]Row, error) {
+	out, err := listRows(ctx, tx, masterID, " FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, apperr.NotFound("asset row not found")
+	}
+	return out, nil
+}
+
+// Rows: master 配下の全在庫行（ロックしない。予約の引き当てなど、数量を動かさずに見るとき）
+func Rows(ctx context.Context, tx db.DBTX, masterID uint64) ([]Row, error) {
+	return listRows(ctx, tx, masterID, "")
+}
+
+func listRows(ctx context.Context, tx db.DBTX, masterID uint64, lock string) ([]Row, error) {
+	rows, err := tx.QueryContext(ctx, selectRow+` WHERE asset_master_id = ? ORDER BY asset_id`+lock, masterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Row
+	for rows.Next() {
+		r, err := scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// LockRow: 指定の在庫行をロック。master が違う行は指定できない
+func LockRow(ctx context.Context, tx db.DBTX, masterID, assetID uint64) (Row, error) {
+	r, err := scanRow(tx.QueryRowContext(ctx,
+		selectRow+` WHERE asset_id = ? AND asset_master_id = ? FOR UPDATE`, assetID, masterID))
+	if err == sql.ErrNoRows {
+		return Row{}, apperr.NotFound("asset_id not found under this management_number")
+	}
+	return r, err
+}
+
+// LockByIDs: 指定の在庫行を asset_id 昇順でロック（返却など、割り当て済みの行を動かすとき）
+func LockByIDs(ctx context.Context, tx db.DBTX, assetIDs []uint64) (map[uint64]Row, error) {
+	ids := append([]uint64{}, assetIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	out := make(map[uint64]Row, len(ids))
+	for _, id := range ids {
+		if _, ok := out[id]; ok {
+			continue
+		}
+		r, err := scanRow(tx.QueryRowContext(ctx, selectRow+` WHERE asset_id = ? FOR UPDATE`, id))
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("asset row not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[id] = r
+	}
+	return out, nil
+}
+
+// Snapshot: ロック済みの行の現在値を読み直す
+func Snapshot(ctx context.Context, tx db.DBTX, assetID uint64) (Row, error) {
+	return scanRow(tx.QueryRowContext(ctx, selectRow+` WHERE asset_id = ?`, assetID))
+}
+
+// Allocate: eligible な行から qty を asset_id 順に割り当てる。足りなければ CONFLICT
+func Allocate(rows []Row, qty uint, eligible func(Row) bool) ([]Allocation, error) {
+	var (
+		out       []Allocation
+		remaining = qty
+		available uint
+	)
+	for _, r := range rows {
+		if remaining == 0 {
+			break
+		}
+		if r.Quantity == 0 || (eligible != nil && !eligible(r)) {
+			continue
+		}
+		n := r.Quantity
+		if n > remaining {
+			n = remaining
+		}
+		out = append(out, Allocation{AssetID: r.AssetID, Quantity: n})
+		remaining -= n
+		available += n
+	}
+	if remaining > 0 {
+		return nil, apperr.Conflict("insufficient stock").WithDetail("available", available)
+	}
+	return out, nil
+}
+
+// Total: eligible な行の数量の合計
+func Total(rows []Row, eligible func(Row) bool) uint {
+	var n uint
+	for _, r := range rows {
+		if eligible == nil || eligible(r) {
+			n += r.Quantity
+		}
+	}
+	return n
+}
+
+// Lendable: 貸出に回せる行か。既に貸出中の行か、遷移表で「貸出中」へ遷移できる行だけ（修理中・廃棄済・紛失は除かれる）
+func Lendable(g statuses.Graph) func(Row) bool {
+	return func(r Row) bool {
+		return g.Allows(r.StatusID, statuses.Lent)
+	}
+}
+
+// Move: 在庫数を delta だけ増減する（バージョンも進める）
+func Move(ctx context.Context, tx db.DBTX, assetID uint64, delta int) error {
+	const q = `UPDATE assets SET quantity = quantity + ?, version = version + 1 WHERE asset_id = ?`
+	res, err := tx.ExecContext(ctx, q, delta, assetID)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff != 1 {
+		return apperr.Internal("failed to update assets.quantity")
+	}
+	return nil
+}
+
+// SetStatus: ロック済みの行 r のステータスを遷移表に従って変える（同値なら何もしない）
+func SetStatus(ctx context.Context, tx db.DBTX, r Row, statusID uint) error {
+	if r.StatusID == statusID {
+		return nil
+	}
+	if err := statuses.CheckTransition(ctx, tx, r.StatusID, statusID); err != nil {
+		return err
+	}
+	const q = `UPDATE assets SET status_id = ?, version = version + 1 WHERE asset_id = ?`
+	_, err := tx.ExecContext(ctx, q, statusID, r.AssetID)
+	return err
+}
+
+// SplitTo: Split で作る行の所有者・現在の場所・ステータス。nil の項目は元の行のまま
+type SplitTo struct {
+	Owner      *string
+	LocationID *uint
+	StatusID   *uint
+}
+
+// Split: ロック済みの行 assetID を写して qty 個の新しい行を作る（一部だけ移動する・破損で戻った分を修理中に分けるなど）。
+// 元の行の数量は変えない（移動なら呼び出し側で減らす）
+func Split(ctx context.Context, tx db.DBTX, assetID uint64, qty uint, to SplitTo) (uint64, error) {
+	const q = `
+	INSERT INTO assets
+	  (asset_master_id, serial, quantity, purchased_at, status_id, owner, default_location_id,
+	   location_id, last_checked_at, last_checked_by, notes)
+	SELECT asset_master_id, serial, ?, purchased_at, COALESCE(?, status_id), COALESCE(?, owner), default_location_id,
+	   COALESCE(?, location_id), last_checked_at, last_checked_by, notes
+	FROM assets WHERE asset_id = ?`
+	res, err := tx.ExecContext(ctx, q, qty, to.StatusID, to.Owner, to.LocationID, assetID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanRow(sc scanner) (Row, error) {
+	var r Row
+	var loc sql.NullInt64
+	if err := sc.Scan(&r.AssetID, &r.Quantity, &r.StatusID, &loc); err != nil {
+		return Row{}, err
+	}
+	if loc.Valid {
+		v := uint(loc.Int64)
+		r.LocationID = &v
+	}
+	return r, nil
+}
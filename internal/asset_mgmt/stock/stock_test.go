@@ -0,0 +1,93 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/platform/apperr"
+)
+
+func TestAllocate(t *testing.T) {
+	rows := []Row{
+		{AssetID: 1, Quantity: 2, StatusID: statuses.Available},
+		{AssetID: 2, Quantity: 0, StatusID: statuses.Lent},
+		{AssetID: 3, Quantity: 5, StatusID: statuses.Repair},
+		{AssetID: 4, Quantity: 3, StatusID: statuses.InUse},
+	}
+	notRepair := func(r Row) bool { return r.StatusID != statuses.Repair }
+
+	tests := []struct {
+		name          string
+		qty           uint
+		eligible      func(Row) bool
+		want          []Allocation
+		wantAvailable uint // 足りないときの details.available
+	}{
+		{
+			name: "fits in the first row",
+			qty:  1,
+			want: []Allocation{{AssetID: 1, Quantity: 1}},
+		},
+		{
+			name: "spills over in asset_id order and skips empty rows",
+			qty:  4,
+			want: []Allocation{{AssetID: 1, Quantity: 2}, {AssetID: 3, Quantity: 2}},
+		},
+		{
+			name: "all rows",
+			qty:  10,
+			want: []Allocation{{AssetID: 1, Quantity: 2}, {AssetID: 3, Quantity: 5}, {AssetID: 4, Quantity: 3}},
+		},
+		{
+			name:     "ineligible rows are skipped",
+			qty:      4,
+			eligible: notRepair,
+			want:     []Allocation{{AssetID: 1, Quantity: 2}, {AssetID: 4, Quantity: 2}},
+		},
+		{
+			name:          "insufficient",
+			qty:           11,
+			wantAvailable: 10,
+		},
+		{
+			name:          "insufficient among eligible rows",
+			qty:           6,
+			eligible:      notRepair,
+			wantAvailable: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(rows, tt.qty, tt.eligible)
+			if tt.want == nil {
+				if !apperr.HasCode(err, apperr.CodeConflict) {
+					t.Fatalf("Allocate() error = %v, want CONFLICT", err)
+				}
+				if e := apperr.From(err); e.Details["available"] != tt.wantAvailable {
+					t.Errorf("details.available = %v, want %d", e.Details["available"], tt.wantAvailable)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	rows := []Row{
+		{AssetID: 1, Quantity: 2, StatusID: statuses.Available},
+		{AssetID: 2, Quantity: 5, StatusID: statuses.Repair},
+	}
+	if got := Total(rows, nil); got != 7 {
+		t.Errorf("Total(nil) = %d, want 7", got)
+	}
+	if got := Total(rows, func(r Row) bool { return r.StatusID == statuses.Repair }); got != 5 {
+		t.Errorf("Total(repair) = %d, want 5", got)
+	}
+}
@@ -0,0 +1,75 @@
+package stocktakes
+
+import "time"
+
+// ---- Requests ----
+
+// location_id（配下の場所も含む）/ genre_id のどちらも省略すると全在庫が対象
+type CreateStocktakeRequest struct {
+	LocationID *uint   `json:"location_id,omitempty"`
+	GenreID    *uint   `json:"genre_id,omitempty"`
+	Note       *string `json:"note,omitempty"`
+}
+
+// 読み取り1回分。同じ在庫行を何度読んでも quantity を足していく（replace=true なら上書き）。
+// 管理番号の下に在庫行が複数あるときは asset_id で指定する
+type ScanRequest struct {
+	ManagementNumber string  `json:"management_number" binding:"required"`
+	AssetID          *uint64 `json:"asset_id,omitempty"`
+	Quantity         *uint   `json:"quantity,omitempty"` // 省略時 1
+	Replace          bool    `json:"replace"`
+}
+
+// apply=true なら数えた行の差異を assets.quantity に反映し、last_checked_at / last_checked_by を更新する。
+// 数えていない行は動かさない。zero_uncounted=true（apply と併用）のときだけ範囲内の未計数の行を 0 個にする
+type CloseStocktakeRequest struct {
+	Apply         bool `json:"apply"`
+	ZeroUncounted bool `json:"zero_uncounted"`
+}
+
+// ---- Responses ----
+
+type StocktakeResponse struct {
+	StocktakeID uint64     `json:"stocktake_id"`
+	LocationID  *uint      `json:"location_id,omitempty"`
+	GenreID     *uint      `json:"genre_id,omitempty"`
+	Note        *string    `json:"note,omitempty"`
+	Status      string     `json:"status"`
+	OpenedByID  *string    `json:"opened_by_id,omitempty"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	ClosedByID  *string    `json:"closed_by_id,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	Applied     bool       `json:"applied"`
+	Summary     *Summary   `json:"summary,omitempty"`
+}
+
+type Summary struct {
+	Rows          int `json:"rows"`          // 範囲内の在庫行
+	CountedRows   int `json:"counted_rows"`  // 数えた在庫行（範囲外を含む）
+	Uncounted     int `json:"uncounted"`     // 範囲内で数えていない在庫行（差異には数えない）
+	Discrepancies int `json:"discrepancies"` // 数えた行のうち差異のある行
+}
+
+type LineResponse struct {
+	AssetID          uint64 `json:"asset_id"`
+	ManagementNumber string `json:"management_number"`
+	Name             string `json:"name"`
+	LocationCode     string `json:"location_code"` // 現在の場所
+	ExpectedQuantity uint   `json:"expected_quantity"`
+	LentQuantity     uint   `json:"lent_quantity"`
+	CountedQuantity  *uint  `json:"counted_quantity"` // null = 未計数
+	Difference       int    `json:"difference"`       // counted - expected（未計数は 0）
+	InScope          bool   `json:"in_scope"`
+}
+
+type ScanResponse struct {
+	AssetID          uint64 `json:"asset_id"`
+	ManagementNumber string `json:"management_number"`
+	CountedQuantity  uint   `json:"counted_quantity"`
+	InScope          bool   `json:"in_scope"`
+}
+
+type CloseStocktakeResponse struct {
+	Stocktake     StocktakeResponse `json:"stocktake"`
+	Discrepancies []LineResponse    `json:"discrepancies"`
+}
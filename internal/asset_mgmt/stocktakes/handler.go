@@ -0,0 +1,135 @@
+package stocktakes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.POST("/stocktakes", auth.Allow(auth.StaffOnly...), h.Create)
+	r.GET("/stocktakes", auth.Allow(auth.StaffOnly...), h.List)
+	r.GET("/stocktakes/:stocktake_id", auth.Allow(auth.StaffOnly...), h.Get)
+	r.GET("/stocktakes/:stocktake_id/discrepancies", auth.Allow(auth.StaffOnly...), h.Discrepancies)
+	r.POST("/stocktakes/:stocktake_id/scans", auth.Allow(auth.StaffOnly...), h.Scan)
+	r.POST("/stocktakes/:stocktake_id/close", auth.Allow(auth.StaffOnly...), h.Close)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateStocktakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/stocktakes/"+idString(res.StocktakeID))
+	c.JSON(http.StatusCreated, res)
+}
+
+// GET /stocktakes?status=open&limit=&cursor=
+func (h *Handler) List(c *gin.Context) {
+	p, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	var status *string
+	if v := c.Query("status"); v != "" {
+		status = &v
+	}
+	res, err := h.svc.List(c.Request.Context(), status, p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := stocktakeIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Discrepancies(c *gin.Context) {
+	id, ok := stocktakeIDParam(c)
+	if !ok {
+		return
+	}
+	all, _ := strconv.ParseBool(c.Query("all"))
+	items, err := h.svc.Discrepancies(c.Request.Context(), id, all)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *Handler) Scan(c *gin.Context) {
+	id, ok := stocktakeIDParam(c)
+	if !ok {
+		return
+	}
+	var req ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Scan(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Close(c *gin.Context) {
+	id, ok := stocktakeIDParam(c)
+	if !ok {
+		return
+	}
+	var req CloseStocktakeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperr.Abort(c, apperr.Bind(err))
+			return
+		}
+	}
+	res, err := h.svc.Close(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ---- helpers ----
+
+func stocktakeIDParam(c *gin.Context) (uint64, bool) {
+	v, err := strconv.ParseUint(c.Param("stocktake_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("stocktake_id must be a number"))
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,58 @@
+package stocktakes
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// DBモデル（stocktakes と1:1）
+type Stocktake struct {
+	StocktakeID uint64
+	LocationID  sql.NullInt64
+	GenreID     sql.NullInt64
+	Note        sql.NullString
+	Status      string
+	OpenedByID  sql.NullString
+	OpenedAt    time.Time
+	ClosedByID  sql.NullString
+	ClosedAt    sql.NullTime
+	Applied     bool
+}
+
+// Line: 突き合わせの1行（範囲内の在庫行と、範囲外でも数えた在庫行）
+type Line struct {
+	AssetID          uint64
+	ManagementNumber string
+	Name             string
+	LocationCode     string
+	Quantity         uint // 手元にあるはずの数（貸出中の分は貸出時に差し引き済み）
+	LentQuantity     uint
+	Counted          sql.NullInt64
+	InScope          bool
+}
+
+// Difference: 数えた数 - 帳簿の数。数えていない行は差異なし（0）として扱う
+func (l Line) Difference() int {
+	return l.Adjustment(false)
+}
+
+// Adjustment: 締めで在庫数を動かす量。zeroUncounted なら、範囲内で数えていない行を 0 個にする
+func (l Line) Adjustment(zeroUncounted bool) int {
+	switch {
+	case l.Counted.Valid:
+		return int(l.Counted.Int64) - int(l.Quantity)
+	case zeroUncounted && l.InScope:
+		return -int(l.Quantity)
+	}
+	return 0
+}
+
+// Uncounted: 範囲内で一度も数えていない行
+func (l Line) Uncounted() bool {
+	return l.InScope && !l.Counted.Valid
+}
@@ -0,0 +1,352 @@
+package stocktakes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, store: NewStore(db)}
+}
+
+// POST /stocktakes
+func (s *Service) Create(ctx context.Context, in CreateStocktakeRequest) (StocktakeResponse, error) {
+	var out StocktakeResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		id, err := st.Insert(ctx, in, auth.ActorID(ctx))
+		if err != nil {
+			var me *mysql.MySQLError
+			if errors.As(err, &me) && me.Number == 1452 {
+				return apperr.Invalid("invalid genre_id or location_id")
+			}
+			return err
+		}
+		m, err := st.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		out = toResponse(*m)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityStocktake,
+			EntityID:   idString(id),
+			After:      out,
+		})
+	})
+	if err != nil {
+		return StocktakeResponse{}, err
+	}
+	return out, nil
+}
+
+// GET /stocktakes
+func (s *Service) List(ctx context.Context, status *string, p pagination.Request) (pagination.Page[StocktakeResponse], error) {
+	if status != nil && *status != StatusOpen && *status != StatusClosed {
+		return pagination.Page[StocktakeResponse]{}, apperr.Invalid("status must be open or closed")
+	}
+	rows, err := s.store.List(ctx, status, p)
+	if err != nil {
+		return pagination.Page[StocktakeResponse]{}, err
+	}
+	page := pagination.Build(p, rows, func(last Stocktake) string {
+		return pagination.Encode(listSort, last.StocktakeID)
+	})
+	return pagination.Map(page, toResponse), nil
+}
+
+// GET /stocktakes/:stocktake_id（集計付き）
+func (s *Service) Get(ctx context.Context, id uint64) (StocktakeResponse, error) {
+	m, err := s.get(ctx, s.store, id, false)
+	if err != nil {
+		return StocktakeResponse{}, err
+	}
+	lines, err := s.store.Lines(ctx, *m, false)
+	if err != nil {
+		return StocktakeResponse{}, err
+	}
+	out := toResponse(*m)
+	out.Summary = summarize(lines)
+	return out, nil
+}
+
+// GET /stocktakes/:stocktake_id/discrepancies（all=true なら差異のない行も）
+func (s *Service) Discrepancies(ctx context.Context, id uint64, all bool) ([]LineResponse, error) {
+	m, err := s.get(ctx, s.store, id, false)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.store.Lines(ctx, *m, false)
+	if err != nil {
+		return nil, err
+	}
+	out := []LineResponse{}
+	for _, l := range lines {
+		if all || l.Difference() != 0 {
+			out = append(out, toLineResponse(l))
+		}
+	}
+	return out, nil
+}
+
+// POST /stocktakes/:stocktake_id/scans
+func (s *Service) Scan(ctx context.Context, id uint64, in ScanRequest) (ScanResponse, error) {
+	qty := uint(1)
+	if in.Quantity != nil {
+		qty = *in.Quantity
+	}
+	if qty == 0 && !in.Replace {
+		return ScanResponse{}, apperr.Invalid("quantity must be > 0 (use replace=true to record 0)")
+	}
+
+	var out ScanResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		m, err := s.get(ctx, st, id, true)
+		if err != nil {
+			return err
+		}
+		if m.Status != StatusOpen {
+			return apperr.Conflict("stocktake is closed")
+		}
+
+		target, err := pickTarget(ctx, st, *m, in)
+		if err != nil {
+			return err
+		}
+		n, err := st.AddCount(ctx, id, target.AssetID, qty, in.Replace, auth.ActorID(ctx))
+		if err != nil {
+			return err
+		}
+		out = ScanResponse{
+			AssetID:          target.AssetID,
+			ManagementNumber: in.ManagementNumber,
+			CountedQuantity:  n,
+			InScope:          target.InScope,
+		}
+		return nil
+	})
+	return out, err
+}
+
+// pickTarget: asset_id 指定があればその行。なければ範囲内の行が1つ（なければ管理番号配下の行が1つ）に決まるときだけ自動で選ぶ
+func pickTarget(ctx context.Context, st *Store, m Stocktake, in ScanRequest) (ScanTarget, error) {
+	targets, err := st.ScanTargets(ctx, m, in.ManagementNumber)
+	if err != nil {
+		return ScanTarget{}, err
+	}
+	if len(targets) == 0 {
+		return ScanTarget{}, apperr.NotFound("no countable asset row for management_number")
+	}
+	if in.AssetID != nil {
+		for _, t := range targets {
+			if t.AssetID == *in.AssetID {
+				return t, nil
+			}
+		}
+		return ScanTarget{}, apperr.Invalid("asset_id does not belong to management_number").WithField("asset_id", "not found under management_number")
+	}
+
+	var inScope []ScanTarget
+	for _, t := range targets {
+		if t.InScope {
+			inScope = append(inScope, t)
+		}
+	}
+	switch {
+	case len(inScope) == 1:
+		return inScope[0], nil
+	case len(inScope) == 0 && len(targets) == 1:
+		return targets[0], nil
+	}
+	ids := make([]uint64, 0, len(targets))
+	for _, t := range targets {
+		ids = append(ids, t.AssetID)
+	}
+	return ScanTarget{}, apperr.Invalid("asset_id is required: management_number has several asset rows").
+		WithField("asset_id", "required").WithDetail("asset_ids", ids)
+}
+
+// POST /stocktakes/:stocktake_id/close
+// apply なら数えた行の差異の分だけ在庫数を動かし（監査ログは在庫行ごと）、数えた行に棚卸し日時・担当者を付ける。
+// 数えていない行は zero_uncounted のときだけ 0 個にする（1点だけ読んで締めると全在庫が 0 になるのを防ぐ）
+func (s *Service) Close(ctx context.Context, id uint64, in CloseStocktakeRequest) (CloseStocktakeResponse, error) {
+	if in.ZeroUncounted && !in.Apply {
+		return CloseStocktakeResponse{}, apperr.Invalid("validation failed").
+			WithField("zero_uncounted", "requires apply=true")
+	}
+	var out CloseStocktakeResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		m, err := s.get(ctx, st, id, true)
+		if err != nil {
+			return err
+		}
+		if m.Status != StatusOpen {
+			return apperr.Conflict("stocktake is already closed")
+		}
+		before := toResponse(*m)
+
+		lines, err := st.Lines(ctx, *m, in.Apply)
+		if err != nil {
+			return err
+		}
+		out.Discrepancies = []LineResponse{}
+		for _, l := range lines {
+			diff := l.Adjustment(in.ZeroUncounted)
+			if diff == 0 {
+				continue
+			}
+			line := toLineResponse(l)
+			line.Difference = diff
+			out.Discrepancies = append(out.Discrepancies, line)
+			if !in.Apply {
+				continue
+			}
+			prev, err := stock.Snapshot(ctx, tx, l.AssetID)
+			if err != nil {
+				return err
+			}
+			if err := stock.Move(ctx, tx, l.AssetID, diff); err != nil {
+				return err
+			}
+			after, err := stock.Snapshot(ctx, tx, l.AssetID)
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, tx, audit.Entry{
+				Action:     audit.ActionAdjust,
+				EntityType: audit.EntityAsset,
+				EntityID:   idString(l.AssetID),
+				Before:     prev,
+				After:      after,
+			}); err != nil {
+				return err
+			}
+		}
+		if in.Apply {
+			if err := st.StampChecked(ctx, id, auth.ActorID(ctx)); err != nil {
+				return err
+			}
+		}
+
+		if err := st.Close(ctx, id, auth.ActorID(ctx), in.Apply); err != nil {
+			return err
+		}
+		if m, err = st.Get(ctx, id); err != nil {
+			return err
+		}
+		out.Stocktake = toResponse(*m)
+		out.Stocktake.Summary = summarize(lines)
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityStocktake,
+			EntityID:   idString(id),
+			Before:     before,
+			After:      out,
+		})
+	})
+	if err != nil {
+		return CloseStocktakeResponse{}, err
+	}
+	return out, nil
+}
+
+// ---- helpers ----
+
+func (s *Service) get(ctx context.Context, st *Store, id uint64, lock bool) (*Stocktake, error) {
+	get := st.Get
+	if lock {
+		get = st.Lock
+	}
+	m, err := get(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("stocktake not found")
+	}
+	return m, err
+}
+
+func summarize(lines []Line) *Summary {
+	var sum Summary
+	for _, l := range lines {
+		if l.InScope {
+			sum.Rows++
+		}
+		if l.Counted.Valid {
+			sum.CountedRows++
+		}
+		if l.Uncounted() {
+			sum.Uncounted++
+		}
+		if l.Difference() != 0 {
+			sum.Discrepancies++
+		}
+	}
+	return &sum
+}
+
+func toResponse(m Stocktake) StocktakeResponse {
+	out := StocktakeResponse{
+		StocktakeID: m.StocktakeID,
+		Note:        nullToPtr(m.Note),
+		Status:      m.Status,
+		OpenedByID:  nullToPtr(m.OpenedByID),
+		OpenedAt:    m.OpenedAt,
+		ClosedByID:  nullToPtr(m.ClosedByID),
+		Applied:     m.Applied,
+	}
+	if m.LocationID.Valid {
+		v := uint(m.LocationID.Int64)
+		out.LocationID = &v
+	}
+	if m.GenreID.Valid {
+		v := uint(m.GenreID.Int64)
+		out.GenreID = &v
+	}
+	if m.ClosedAt.Valid {
+		v := m.ClosedAt.Time
+		out.ClosedAt = &v
+	}
+	return out
+}
+
+func toLineResponse(l Line) LineResponse {
+	out := LineResponse{
+		AssetID:          l.AssetID,
+		ManagementNumber: l.ManagementNumber,
+		Name:             l.Name,
+		LocationCode:     l.LocationCode,
+		ExpectedQuantity: l.Quantity,
+		LentQuantity:     l.LentQuantity,
+		Difference:       l.Difference(),
+		InScope:          l.InScope,
+	}
+	if l.Counted.Valid {
+		v := uint(l.Counted.Int64)
+		out.CountedQuantity = &v
+	}
+	return out
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package stocktakes
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/locations"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+const selectStocktake = `
+	SELECT stocktake_id, location_id, genre_id, note, status, opened_by_id, opened_at, closed_by_id, closed_at, applied
+	FROM stocktakes`
+
+func (s *Store) Insert(ctx context.Context, in CreateStocktakeRequest, openedBy *string) (uint64, error) {
+	const q = `
+	INSERT INTO stocktakes (location_id, genre_id, note, status, opened_by_id, opened_at)
+	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	res, err := s.db.ExecContext(ctx, q, in.LocationID, in.GenreID, in.Note, StatusOpen, openedBy)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// Get: 見つからなければ sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uint64) (*Stocktake, error) {
+	return s.get(ctx, selectStocktake+` WHERE stocktake_id = ?`, id)
+}
+
+// Lock: 読み取り・締めを同じセッションで直列にする
+func (s *Store) Lock(ctx context.Context, id uint64) (*Stocktake, error) {
+	return s.get(ctx, selectStocktake+` WHERE stocktake_id = ? FOR UPDATE`, id)
+}
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanStocktake(sc scanner) (Stocktake, error) {
+	var m Stocktake
+	err := sc.Scan(&m.StocktakeID, &m.LocationID, &m.GenreID, &m.Note, &m.Status,
+		&m.OpenedByID, &m.OpenedAt, &m.ClosedByID, &m.ClosedAt, &m.Applied)
+	return m, err
+}
+
+func (s *Store) get(ctx context.Context, q string, id uint64) (*Stocktake, error) {
+	m, err := scanStocktake(s.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// List: stocktake_id の新しい順。cursor の続きから p.Fetch() 件
+func (s *Store) List(ctx context.Context, status *string, p pagination.Request) ([]Stocktake, error) {
+	q := selectStocktake + ` WHERE 1=1`
+	args := []any{}
+	if status != nil {
+		q += ` AND status = ?`
+		args = append(args, *status)
+	}
+	var lastID uint64
+	ok, err := p.After(listSort, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		q += ` AND stocktake_id < ?`
+		args = append(args, lastID)
+	}
+	q += ` ORDER BY stocktake_id DESC LIMIT ?`
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Stocktake
+	for rows.Next() {
+		m, err := scanStocktake(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+const listSort = "stocktake_id:desc"
+
+func (s *Store) Close(ctx context.Context, id uint64, closedBy *string, applied bool) error {
+	const q = `
+	UPDATE stocktakes SET status = ?, closed_by_id = ?, closed_at = CURRENT_TIMESTAMP, applied = ?
+	WHERE stocktake_id = ?`
+	_, err := s.db.ExecContext(ctx, q, StatusClosed, closedBy, applied, id)
+	return err
+}
+
+// ---- 計数 ----
+
+// scope: セッションの範囲の条件（assets a JOIN assets_master m 前提）。場所は配下も含め、在庫行の現在の場所で判定する。
+// 範囲指定なしなら "1=1"
+func scope(st Stocktake) (string, []any) {
+	conds := []string{}
+	args := []any{}
+	if st.LocationID.Valid {
+		conds = append(conds, "COALESCE(a.location_id, a.default_location_id) IN "+locations.Subtree("location_id = ?"))
+		args = append(args, st.LocationID.Int64)
+	}
+	if st.GenreID.Valid {
+		conds = append(conds, "m.genre_id = ?")
+		args = append(args, st.GenreID.Int64)
+	}
+	if len(conds) == 0 {
+		return "1=1", args
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ScanTarget: 管理番号配下で読み取りの対象にできる在庫行（廃棄済を除く）と、それぞれが範囲内か
+type ScanTarget struct {
+	AssetID uint64
+	InScope bool
+}
+
+func (s *Store) ScanTargets(ctx context.Context, st Stocktake, mng string) ([]ScanTarget, error) {
+	cond, args := scope(st)
+	q := `
+	SELECT a.asset_id, (` + cond + `) AS in_scope
+	FROM assets a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	WHERE m.management_number = ? AND a.status_id <> ?
+	ORDER BY a.asset_id`
+	rows, err := s.db.QueryContext(ctx, q, append(args, mng, statuses.Disposed)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ScanTarget
+	for rows.Next() {
+		var t ScanTarget
+		if err := rows.Scan(&t.AssetID, &t.InScope); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// AddCount: 数を足す（replace なら上書き）。戻り値は更新後の計数
+func (s *Store) AddCount(ctx context.Context, stocktakeID, assetID uint64, qty uint, replace bool, by *string) (uint, error) {
+	update := `counted_quantity = counted_quantity + VALUES(counted_quantity)`
+	if replace {
+		update = `counted_quantity = VALUES(counted_quantity)`
+	}
+	q := `
+	INSERT INTO stocktake_counts (stocktake_id, asset_id, counted_quantity, counted_by_id, counted_at)
+	VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	ON DUPLICATE KEY UPDATE ` + update + `, counted_by_id = VALUES(counted_by_id), counted_at = VALUES(counted_at)`
+	if _, err := s.db.ExecContext(ctx, q, stocktakeID, assetID, qty, by); err != nil {
+		return 0, err
+	}
+	var n uint
+	err := s.db.QueryRowContext(ctx,
+		`SELECT counted_quantity FROM stocktake_counts WHERE stocktake_id = ? AND asset_id = ?`, stocktakeID, assetID).Scan(&n)
+	return n, err
+}
+
+// Lines: 範囲内の在庫行（廃棄済を除く）と、範囲外でも数えた在庫行を asset_id 順に。
+// lock なら在庫行（assets）だけを FOR UPDATE で押さえる（締めで数量を直すとき）
+func (s *Store) Lines(ctx context.Context, st Stocktake, lock bool) ([]Line, error) {
+	cond, condArgs := scope(st)
+	q := `
+	SELECT a.asset_id, m.management_number, m.name, loc.location_code, a.quantity,
+		(SELECT COALESCE(SUM(la.quantity - la.returned_quantity - la.lost_quantity), 0) FROM lend_allocations la WHERE la.asset_id = a.asset_id) AS lent_quantity,
+		c.counted_quantity, (` + cond + `) AS in_scope
+	FROM assets a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	JOIN locations loc ON loc.location_id = COALESCE(a.location_id, a.default_location_id)
+	LEFT JOIN stocktake_counts c ON c.asset_id = a.asset_id AND c.stocktake_id = ?
+	WHERE ((` + cond + `) AND a.status_id <> ?) OR c.asset_id IS NOT NULL
+	ORDER BY a.asset_id`
+	if lock {
+		q += ` FOR UPDATE OF a`
+	}
+	args := append([]any{}, condArgs...)
+	args = append(args, st.StocktakeID)
+	args = append(args, condArgs...)
+	args = append(args, statuses.Disposed)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Line
+	for rows.Next() {
+		var l Line
+		if err := rows.Scan(&l.AssetID, &l.ManagementNumber, &l.Name, &l.LocationCode, &l.Quantity,
+			&l.LentQuantity, &l.Counted, &l.InScope); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// StampChecked: このセッションで数えた在庫行に棚卸し日時・担当者を付ける
+func (s *Store) StampChecked(ctx context.Context, stocktakeID uint64, by *string) error {
+	const q = `
+	UPDATE assets a
+	JOIN stocktake_counts c ON c.asset_id = a.asset_id
+	SET a.last_checked_at = UTC_TIMESTAMP(), a.last_checked_by = ?, a.version = a.version + 1
+	WHERE c.stocktake_id = ?`
+	_, err := s.db.ExecContext(ctx, q, by, stocktakeID)
+	return err
+}
+
+func idString(id uint64) string { return strconv.FormatUint(id, 10) }
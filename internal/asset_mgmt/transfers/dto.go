@@ -0,0 +1,58 @@
+package transfers
+
+import (
+	"time"
+
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// ---- Requests ----
+
+// to_location_id / to_owner の少なくとも一方が必要。from_* を渡すと現在の値と一致するか確かめる（違えば 409）。
+// quantity を省略すると行の全量。一部なら在庫行を分割して、移した分を新しい行にする
+type CreateTransferRequest struct {
+	Quantity       *uint   `json:"quantity,omitempty"`
+	FromLocationID *uint   `json:"from_location_id,omitempty"`
+	ToLocationID   *uint   `json:"to_location_id,omitempty"`
+	FromOwner      *string `json:"from_owner,omitempty"`
+	ToOwner        *string `json:"to_owner,omitempty" binding:"omitempty,max=100"`
+	Reason         *string `json:"reason,omitempty"`
+	// transferred_by_id は認証済みの呼び出し元で埋める
+}
+
+// ---- Responses ----
+
+type TransferResponse struct {
+	TransferULID     string    `json:"transfer_ulid"`
+	AssetID          uint64    `json:"asset_id"`
+	ToAssetID        uint64    `json:"to_asset_id"`
+	Split            bool      `json:"split"` // 在庫行を分割したか
+	ManagementNumber string    `json:"management_number"`
+	Quantity         uint      `json:"quantity"`
+	FromLocationID   uint      `json:"from_location_id"`
+	FromLocationCode string    `json:"from_location_code"`
+	ToLocationID     uint      `json:"to_location_id"`
+	ToLocationCode   string    `json:"to_location_code"`
+	FromOwner        string    `json:"from_owner"`
+	ToOwner          string    `json:"to_owner"`
+	Reason           *string   `json:"reason,omitempty"`
+	TransferredByID  *string   `json:"transferred_by_id,omitempty"`
+	TransferredAt    time.Time `json:"transferred_at"`
+}
+
+// ---- List payload ----
+
+type Page struct {
+	pagination.Request
+	Order string // "asc" or "desc"
+}
+
+type TransferFilter struct {
+	AssetID          *uint64 // 移動元・移動先のどちらかがこの在庫行
+	ManagementNumber *string
+	LocationID       *uint   // 移動元・移動先のどちらかがこの場所（配下を含む）
+	Owner            *string // 移動元・移動先のどちらかがこの所有者
+	TransferredByID  *string
+	From             *time.Time
+	To               *time.Time
+}
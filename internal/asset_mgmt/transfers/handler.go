@@ -0,0 +1,119 @@
+package transfers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/idempotency"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	// 登録（在庫行単位）。POST /assets/:x/... は貸出・廃棄とワイルドカード名を揃える必要があるので
+	// :management_number の位置に asset_id を受ける
+	r.POST("/assets/:management_number/transfers", auth.Allow(auth.StaffOnly...), idempotency.Middleware(svc.db), h.CreateTransfer)
+
+	// 参照
+	r.GET("/transfers", auth.Allow(auth.Members...), h.ListTransfers)
+	r.GET("/transfers/:transfer_ulid", auth.Allow(auth.Members...), h.GetTransfer)
+	r.GET("/assets/:asset_id/transfers", auth.Allow(auth.Members...), h.ListAssetTransfers)
+}
+
+func (h *Handler) CreateTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("management_number"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("asset_id must be a number"))
+		return
+	}
+	var req CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CreateTransfer(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/transfers/"+res.TransferULID)
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) GetTransfer(c *gin.Context) {
+	res, err := h.svc.GetTransferByULID(c.Request.Context(), c.Param("transfer_ulid"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /transfers?asset_id=&management_number=&location_id=&owner=&transferred_by_id=&from=&to=
+func (h *Handler) ListTransfers(c *gin.Context) {
+	f := TransferFilter{}
+	if v := c.Query("asset_id"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			f.AssetID = &n
+		}
+	}
+	if v := c.Query("management_number"); v != "" {
+		f.ManagementNumber = &v
+	}
+	if v := c.Query("location_id"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			u := uint(n)
+			f.LocationID = &u
+		}
+	}
+	if v := c.Query("owner"); v != "" {
+		f.Owner = &v
+	}
+	if v := c.Query("transferred_by_id"); v != "" {
+		f.TransferredByID = &v
+	}
+	if v := c.Query("from"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			f.From = &t
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			f.To = &t
+		}
+	}
+	h.list(c, f, "desc")
+}
+
+// GET /assets/:asset_id/transfers: この在庫行の移動履歴（分割で生まれた行ならその分割も含む）。既定は古い順
+func (h *Handler) ListAssetTransfers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("asset_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("asset_id must be a number"))
+		return
+	}
+	h.list(c, TransferFilter{AssetID: &id}, "asc")
+}
+
+func (h *Handler) list(c *gin.Context, f TransferFilter, order string) {
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", order)}
+	res, err := h.svc.ListTransfers(c.Request.Context(), f, p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
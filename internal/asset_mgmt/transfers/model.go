@@ -0,0 +1,43 @@
+package transfers
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DBモデル（transfers と1:1。management_number と場所コードは参照用に結合）
+type Transfer struct {
+	TransferID       uint64
+	TransferULID     string
+	AssetID          uint64 // 移動元の在庫行
+	ToAssetID        uint64 // 移した分を持つ在庫行（全量なら AssetID と同じ）
+	ManagementNumber string
+	Quantity         uint
+	FromLocationID   uint
+	FromLocationCode string
+	ToLocationID     uint
+	ToLocationCode   string
+	FromOwner        string
+	ToOwner          string
+	Reason           sql.NullString
+	TransferredByID  sql.NullString
+	TransferredAt    time.Time
+}
+
+// AssetState: 移動に関わる在庫行の値（監査ログの before/after にもそのまま使う）
+type AssetState struct {
+	AssetID           uint64 `json:"asset_id"`
+	Quantity          uint   `json:"quantity"`
+	StatusID          uint   `json:"status_id"`
+	Owner             string `json:"owner"`
+	DefaultLocationID uint   `json:"default_location_id"`
+	LocationID        *uint  `json:"location_id,omitempty"`
+}
+
+// CurrentLocationID: いまある場所（location_id が空なら既定の場所）
+func (a AssetState) CurrentLocationID() uint {
+	if a.LocationID != nil {
+		return *a.LocationID
+	}
+	return a.DefaultLocationID
+}
@@ -0,0 +1,257 @@
+package transfers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	ulid "github.com/oklog/ulid/v2"
+
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/asset_mgmt/stock"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// ---- Clock & ID ----
+type Clock interface{ Now() time.Time }
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now().UTC() }
+
+type IDGen interface{ NewULID(t time.Time) string }
+type ulidGen struct{}
+
+func (ulidGen) NewULID(t time.Time) string {
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	return ulid.MustNew(ulid.Timestamp(t), entropy).String()
+}
+
+// ---- Service ----
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+	clock Clock
+	id    IDGen
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:    db,
+		store: NewStore(db),
+		clock: realClock{},
+		id:    ulidGen{},
+	}
+}
+
+// POST /assets/:asset_id/transfers
+func (s *Service) CreateTransfer(ctx context.Context, assetID uint64, in CreateTransferRequest) (TransferResponse, error) {
+	toOwner := trimmed(in.ToOwner)
+	if in.ToLocationID == nil && toOwner == nil {
+		return TransferResponse{}, apperr.Invalid("to_location_id or to_owner is required")
+	}
+	if in.Quantity != nil && *in.Quantity == 0 {
+		return TransferResponse{}, apperr.Invalid("quantity must be > 0")
+	}
+	now := s.clock.Now().Truncate(time.Second)
+	tuid := s.id.NewULID(now)
+	by := auth.ActorID(ctx)
+
+	var resp TransferResponse
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		src, err := st.LockAsset(ctx, assetID)
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("asset not found")
+		}
+		if err != nil {
+			return err
+		}
+		if src.StatusID == statuses.Disposed {
+			return apperr.Conflict("disposed asset row cannot be transferred")
+		}
+
+		// 移動元は在庫行の現在値。指定があれば食い違いを検出する
+		fromLoc := src.CurrentLocationID()
+		if in.FromLocationID != nil && *in.FromLocationID != fromLoc {
+			return apperr.Conflict("asset row is not at from_location_id").WithDetail("location_id", fromLoc)
+		}
+		if v := trimmed(in.FromOwner); v != nil && *v != src.Owner {
+			return apperr.Conflict("asset row is not owned by from_owner").WithDetail("owner", src.Owner)
+		}
+
+		toLoc, owner := fromLoc, src.Owner
+		if in.ToLocationID != nil {
+			if err := st.LocationExists(ctx, *in.ToLocationID); err == sql.ErrNoRows {
+				return apperr.Invalid("validation failed").WithField("to_location_id", "not found")
+			} else if err != nil {
+				return err
+			}
+			toLoc = *in.ToLocationID
+		}
+		if toOwner != nil {
+			owner = *toOwner
+		}
+		if toLoc == fromLoc && owner == src.Owner {
+			return apperr.Invalid("destination is the same as the current location and owner")
+		}
+
+		qty := src.Quantity
+		if in.Quantity != nil {
+			qty = *in.Quantity
+		}
+		if qty == 0 || qty > src.Quantity {
+			return apperr.Conflict("insufficient stock").WithDetail("available", src.Quantity)
+		}
+
+		// 全量なら行ごと、一部なら分割して移した分を新しい行にする
+		toAssetID := assetID
+		if qty < src.Quantity {
+			if err := stock.Move(ctx, tx, assetID, -int(qty)); err != nil {
+				return err
+			}
+			if toAssetID, err = stock.Split(ctx, tx, assetID, qty, stock.SplitTo{Owner: &owner, LocationID: &toLoc}); err != nil {
+				return err
+			}
+		} else if err := st.MoveAsset(ctx, assetID, owner, toLoc); err != nil {
+			return err
+		}
+
+		if _, err := st.Insert(ctx, &Transfer{
+			TransferULID:    tuid,
+			AssetID:         assetID,
+			ToAssetID:       toAssetID,
+			Quantity:        qty,
+			FromLocationID:  fromLoc,
+			ToLocationID:    toLoc,
+			FromOwner:       src.Owner,
+			ToOwner:         owner,
+			Reason:          toNullString(in.Reason),
+			TransferredByID: toNullString(by),
+			TransferredAt:   now,
+		}); err != nil {
+			return err
+		}
+		m, err := st.GetByULID(ctx, tuid)
+		if err != nil {
+			return err
+		}
+		resp = toResponse(*m)
+
+		// 監査ログ（移動本体と在庫行の変化。分割した新しい行は作成として残す）
+		if err := audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityTransfer,
+			EntityID:   tuid,
+			After:      resp,
+		}); err != nil {
+			return err
+		}
+		after, err := st.Asset(ctx, assetID)
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionTransfer,
+			EntityType: audit.EntityAsset,
+			EntityID:   strconv.FormatUint(assetID, 10),
+			Before:     src,
+			After:      after,
+		}); err != nil {
+			return err
+		}
+		if resp.Split {
+			created, err := st.Asset(ctx, toAssetID)
+			if err != nil {
+				return err
+			}
+			return audit.Record(ctx, tx, audit.Entry{
+				Action:     audit.ActionCreate,
+				EntityType: audit.EntityAsset,
+				EntityID:   strconv.FormatUint(toAssetID, 10),
+				After:      created,
+			})
+		}
+		return nil
+	})
+	return resp, err
+}
+
+func (s *Service) GetTransferByULID(ctx context.Context, ul string) (TransferResponse, error) {
+	m, err := s.store.GetByULID(ctx, ul)
+	if err == sql.ErrNoRows {
+		return TransferResponse{}, apperr.NotFound("transfer not found")
+	}
+	if err != nil {
+		return TransferResponse{}, err
+	}
+	return toResponse(*m), nil
+}
+
+// GET /transfers, GET /assets/:asset_id/transfers
+func (s *Service) ListTransfers(ctx context.Context, f TransferFilter, p Page) (pagination.Page[TransferResponse], error) {
+	rows, err := s.store.List(ctx, f, p)
+	if err != nil {
+		return pagination.Page[TransferResponse]{}, err
+	}
+	sort, _ := listOrder(p)
+	page := pagination.Build(p.Request, rows, func(last Transfer) string {
+		return pagination.Encode(sort, last.TransferredAt, last.TransferID)
+	})
+	return pagination.Map(page, toResponse), nil
+}
+
+// ---- helpers ----
+
+func toResponse(m Transfer) TransferResponse {
+	return TransferResponse{
+		TransferULID:     m.TransferULID,
+		AssetID:          m.AssetID,
+		ToAssetID:        m.ToAssetID,
+		Split:            m.ToAssetID != m.AssetID,
+		ManagementNumber: m.ManagementNumber,
+		Quantity:         m.Quantity,
+		FromLocationID:   m.FromLocationID,
+		FromLocationCode: m.FromLocationCode,
+		ToLocationID:     m.ToLocationID,
+		ToLocationCode:   m.ToLocationCode,
+		FromOwner:        m.FromOwner,
+		ToOwner:          m.ToOwner,
+		Reason:           nullToPtr(m.Reason),
+		TransferredByID:  nullToPtr(m.TransferredByID),
+		TransferredAt:    m.TransferredAt,
+	}
+}
+
+func trimmed(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func toNullString(s *string) (ns sql.NullString) {
+	if s != nil && strings.TrimSpace(*s) != "" {
+		ns.Valid, ns.String = true, *s
+	}
+	return
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package transfers
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/locations"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+// --- assets ---
+
+// LockAsset: 移動元の在庫行をロックして返す（見つからなければ sql.ErrNoRows）
+func (s *Store) LockAsset(ctx context.Context, id uint64) (AssetState, error) {
+	return s.asset(ctx, id, " FOR UPDATE")
+}
+
+// Asset: ロック済みの行の現在値を読み直す
+func (s *Store) Asset(ctx context.Context, id uint64) (AssetState, error) {
+	return s.asset(ctx, id, "")
+}
+
+func (s *Store) asset(ctx context.Context, id uint64, lock string) (AssetState, error) {
+	var a AssetState
+	var loc sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+	SELECT asset_id, quantity, status_id, owner, default_location_id, location_id
+	FROM assets WHERE asset_id = ?`+lock, id).Scan(
+		&a.AssetID, &a.Quantity, &a.StatusID, &a.Owner, &a.DefaultLocationID, &loc)
+	if err != nil {
+		return AssetState{}, err
+	}
+	if loc.Valid {
+		v := uint(loc.Int64)
+		a.LocationID = &v
+	}
+	return a, nil
+}
+
+func (s *Store) LocationExists(ctx context.Context, id uint) error {
+	return s.db.QueryRowContext(ctx, `SELECT location_id FROM locations WHERE location_id = ?`, id).Scan(&id)
+}
+
+// MoveAsset: 行ごと移す（所有者・現在の場所を書き換える）
+func (s *Store) MoveAsset(ctx context.Context, id uint64, owner string, locationID uint) error {
+	const q = `UPDATE assets SET owner = ?, location_id = ?, version = version + 1 WHERE asset_id = ?`
+	_, err := s.db.ExecContext(ctx, q, owner, locationID, id)
+	return err
+}
+
+// --- transfers ---
+
+func (s *Store) Insert(ctx context.Context, m *Transfer) (uint64, error) {
+	const q = `
+	INSERT INTO transfers
+	  (transfer_ulid, asset_id, to_asset_id, quantity, from_location_id, to_location_id,
+	   from_owner, to_owner, reason, transferred_by_id, transferred_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, q,
+		m.TransferULID, m.AssetID, m.ToAssetID, m.Quantity, m.FromLocationID, m.ToLocationID,
+		m.FromOwner, m.ToOwner, m.Reason, m.TransferredByID, m.TransferredAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+const selectTransfer = `
+	SELECT t.transfer_id, t.transfer_ulid, t.asset_id, t.to_asset_id, m.management_number, t.quantity,
+		t.from_location_id, fl.location_code, t.to_location_id, tl.location_code,
+		t.from_owner, t.to_owner, t.reason, t.transferred_by_id, t.transferred_at
+	FROM transfers t
+	JOIN assets a ON a.asset_id = t.asset_id
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	JOIN locations fl ON fl.location_id = t.from_location_id
+	JOIN locations tl ON tl.location_id = t.to_location_id`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanTransfer(sc scanner) (Transfer, error) {
+	var m Transfer
+	err := sc.Scan(&m.TransferID, &m.TransferULID, &m.AssetID, &m.ToAssetID, &m.ManagementNumber, &m.Quantity,
+		&m.FromLocationID, &m.FromLocationCode, &m.ToLocationID, &m.ToLocationCode,
+		&m.FromOwner, &m.ToOwner, &m.Reason, &m.TransferredByID, &m.TransferredAt)
+	return m, err
+}
+
+// GetByULID: 見つからなければ sql.ErrNoRows
+func (s *Store) GetByULID(ctx context.Context, ul string) (*Transfer, error) {
+	m, err := scanTransfer(s.db.QueryRowContext(ctx, selectTransfer+` WHERE t.transfer_ulid = ?`, ul))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// listOrder: 一覧の並び（transferred_at, transfer_id）と cursor の識別子
+func listOrder(p Page) (string, []pagination.Column) {
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "transferred_at:asc"
+	if desc {
+		sort = "transferred_at:desc"
+	}
+	return sort, []pagination.Column{{Expr: "t.transferred_at", Desc: desc}, {Expr: "t.transfer_id", Desc: desc}}
+}
+
+// List: cursor の続きから p.Fetch() 件
+func (s *Store) List(ctx context.Context, f TransferFilter, p Page) ([]Transfer, error) {
+	sb := strings.Builder{}
+	sb.WriteString(selectTransfer + ` WHERE 1=1`)
+
+	args := []any{}
+	if f.AssetID != nil {
+		sb.WriteString(` AND (t.asset_id = ? OR t.to_asset_id = ?)`)
+		args = append(args, *f.AssetID, *f.AssetID)
+	}
+	if f.ManagementNumber != nil {
+		sb.WriteString(` AND m.management_number = ?`)
+		args = append(args, *f.ManagementNumber)
+	}
+	if f.LocationID != nil {
+		sub := locations.Subtree("location_id = ?")
+		sb.WriteString(` AND (t.from_location_id IN ` + sub + ` OR t.to_location_id IN ` + sub + `)`)
+		args = append(args, *f.LocationID, *f.LocationID)
+	}
+	if f.Owner != nil {
+		sb.WriteString(` AND (t.from_owner = ? OR t.to_owner = ?)`)
+		args = append(args, *f.Owner, *f.Owner)
+	}
+	if f.TransferredByID != nil {
+		sb.WriteString(` AND t.transferred_by_id = ?`)
+		args = append(args, *f.TransferredByID)
+	}
+	if f.From != nil {
+		sb.WriteString(` AND t.transferred_at >= ?`)
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		sb.WriteString(` AND t.transferred_at < ?`)
+		args = append(args, *f.To)
+	}
+
+	sort, cols := listOrder(p)
+	var (
+		lastAt time.Time
+		lastID uint64
+	)
+	ok, err := p.After(sort, &lastAt, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cond, seekArgs := pagination.Seek(cols, []any{lastAt, lastID})
+		sb.WriteString(` AND ` + cond)
+		args = append(args, seekArgs...)
+	}
+	sb.WriteString(` ORDER BY ` + pagination.OrderBy(cols) + ` LIMIT ?`)
+	args = append(args, p.Fetch())
+
+	rows, err := s.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Transfer
+	for rows.Next() {
+		m, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
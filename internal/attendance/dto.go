@@ -1,14 +1,16 @@
 package attendance
 
-import "time"
+import (
+	"time"
+
+	"IRIS-backend/internal/platform/pagination"
+)
 
 const (
 	SortClockedAtDesc  = "clocked_at_desc"
 	SortClockedAtAsc   = "clocked_at_asc"
 	SortAttendedOnDesc = "attended_on_desc"
 	SortAttendedOnAsc  = "attended_on_asc"
-	DefaultPageLimit   = 50
-	MaxPageLimit       = 200
 	DefaultSort        = SortClockedAtDesc
 	DefaultTZ          = "Asia/Tokyo"
 	DateLayout         = "2006-01-02"
@@ -33,8 +35,7 @@ type ListQuery struct {
 	On            *string
 	From          *string
 	To            *string
-	Limit         int
-	Offset        int
+	Page          pagination.Request
 	Sort          string
 	ExistsOnly    bool
 	TZ            string
@@ -5,13 +5,18 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 func RegisterRoutes(r gin.IRoutes, svc *Service) {
 
-	r.POST("/attendances", handleCreateAttendance(svc))
-	r.GET("/attendances", handleListAttendances(svc))
-	r.GET("/attendances/stats", handleStats(svc))
+	// 打刻は端末（kiosk）からも可、閲覧・集計はスタッフ以上
+	r.POST("/attendances", auth.Allow(auth.Everyone...), handleCreateAttendance(svc))
+	r.GET("/attendances", auth.Allow(auth.StaffOnly...), handleListAttendances(svc))
+	r.GET("/attendances/stats", auth.Allow(auth.StaffOnly...), handleStats(svc))
 
 	//なぜかHEADがうまく動かないのでv2.0ではコメントアウト
 	// g.HEAD("/attendances", handleHeadAttendance(svc))
@@ -22,12 +27,12 @@ func handleCreateAttendance(svc *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateAttendanceRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			writeErr(c, ErrInvalid("invalid json: "+err.Error()))
+			apperr.Abort(c, apperr.Bind(err))
 			return
 		}
 		res, created, err := svc.UpsertAttendance(c.Request.Context(), req)
 		if err != nil {
-			writeErr(c, err)
+			apperr.Abort(c, err)
 			return
 		}
 		if created {
@@ -48,7 +53,7 @@ func handleHeadAttendance(svc *Service) gin.HandlerFunc {
 		}
 		ok, err := svc.Exists(c.Request.Context(), user, on)
 		if err != nil {
-			writeErr(c, err)
+			apperr.Abort(c, err)
 			return
 		}
 		if ok {
@@ -61,11 +66,15 @@ func handleHeadAttendance(svc *Service) gin.HandlerFunc {
 
 func handleListAttendances(svc *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		page, err := pagination.FromQuery(c)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
 		q := ListQuery{
-			Limit:  atoiDefault(c.Query("limit"), DefaultPageLimit),
-			Offset: atoiDefault(c.Query("offset"), 0),
-			Sort:   strDefault(c.Query("sort"), DefaultSort),
-			TZ:     strDefault(c.Query("tz"), DefaultTZ),
+			Page: page,
+			Sort: strDefault(c.Query("sort"), DefaultSort),
+			TZ:   strDefault(c.Query("tz"), DefaultTZ),
 		}
 		if v := c.Query("user_id"); v != "" {
 			q.StudentNumber = &[]string{v}[0]
@@ -80,20 +89,12 @@ func handleListAttendances(svc *Service) gin.HandlerFunc {
 			q.To = &[]string{v}[0]
 		}
 
-		rows, total, err := svc.List(c.Request.Context(), q)
+		res, err := svc.List(c.Request.Context(), q)
 		if err != nil {
-			writeErr(c, err)
+			apperr.Abort(c, err)
 			return
 		}
-		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
-		c.JSON(http.StatusOK, gin.H{
-			"items": rows,
-			"page": gin.H{
-				"limit":  q.Limit,
-				"offset": q.Offset,
-				"total":  total,
-			},
-		})
+		c.JSON(http.StatusOK, res)
 	}
 }
 
@@ -105,12 +106,12 @@ func handleStats(svc *Service) gin.HandlerFunc {
 			Limit: atoiDefault(c.Query("limit"), 10),
 		}
 		if req.From == "" || req.To == "" {
-			writeErr(c, ErrInvalid("from/to are required (YYYY-MM-DD)"))
+			apperr.Abort(c, apperr.Invalid("from/to are required (YYYY-MM-DD)"))
 			return
 		}
 		rows, err := svc.Stats(c.Request.Context(), req)
 		if err != nil {
-			writeErr(c, err)
+			apperr.Abort(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
@@ -120,16 +121,6 @@ func handleStats(svc *Service) gin.HandlerFunc {
 	}
 }
 
-func writeErr(c *gin.Context, err error) {
-	status := toHTTPStatus(err)
-	switch e := err.(type) {
-	case *APIError:
-		c.JSON(status, gin.H{"error": e})
-	default:
-		c.JSON(500, gin.H{"error": APIError{Code: CodeInternal, Message: e.Error()}})
-	}
-}
-
 func atoiDefault(s string, d int) int {
 	if s == "" {
 		return d
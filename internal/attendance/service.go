@@ -3,49 +3,15 @@ package attendance
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"fmt"
+	"strconv"
 	"time"
-)
-
-// ===== Error model (assets/disposals/lends と同型) =====
-type Code string
 
-const (
-	CodeInvalidArgument Code = "INVALID_ARGUMENT"
-	CodeNotFound        Code = "NOT_FOUND"
-	CodeConflict        Code = "CONFLICT"
-	CodeInternal        Code = "INTERNAL"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
 )
 
-type APIError struct {
-	Code    Code   `json:"code"`
-	Message string `json:"message"`
-}
-
-func (e *APIError) Error() string      { return fmt.Sprintf("%s: %s", e.Code, e.Message) }
-func ErrInvalid(msg string) *APIError  { return &APIError{Code: CodeInvalidArgument, Message: msg} }
-func ErrNotFound(msg string) *APIError { return &APIError{Code: CodeNotFound, Message: msg} }
-func ErrConflict(msg string) *APIError { return &APIError{Code: CodeConflict, Message: msg} }
-func ErrInternal(msg string) *APIError { return &APIError{Code: CodeInternal, Message: msg} }
-
-func toHTTPStatus(err error) int {
-	var api *APIError
-	if errors.As(err, &api) {
-		switch api.Code {
-		case CodeInvalidArgument:
-			return 400
-		case CodeNotFound:
-			return 404
-		case CodeConflict:
-			return 409
-		default:
-			return 500
-		}
-	}
-	return 500
-}
-
 // ===== Service =====
 
 type Service struct {
@@ -60,71 +26,92 @@ func NewService(db *sql.DB) *Service {
 // POST /attendances
 func (s *Service) UpsertAttendance(ctx context.Context, in CreateAttendanceRequest) (AttendanceResponse, bool, error) {
 	if in.StudentNumber == "" {
-		return AttendanceResponse{}, false, ErrInvalid("user_id is required")
+		return AttendanceResponse{}, false, apperr.Invalid("user_id is required")
 	}
 	var on *time.Time
 	if in.AttendedOn != nil && *in.AttendedOn != "" {
 		parsed, err := parseOn(*in.AttendedOn)
 		if err != nil {
-			return AttendanceResponse{}, false, ErrInvalid("attended_on must be YYYY-MM-DD or 'today'")
+			return AttendanceResponse{}, false, apperr.Invalid("attended_on must be YYYY-MM-DD or 'today'")
 		}
 		on = &parsed
 	}
 
-	row, created, err := s.store.Upsert(ctx, in.StudentNumber, on, in.Note)
+	var (
+		out     AttendanceResponse
+		created bool
+	)
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		prev, err := st.LockByStudentOn(ctx, in.StudentNumber, on)
+		if err != nil {
+			return err
+		}
+		row, c, err := st.Upsert(ctx, in.StudentNumber, on, in.Note)
+		if err != nil {
+			return err
+		}
+		out, created = row.toDTO(), c
+
+		e := audit.Entry{
+			Action:     audit.ActionUpsert,
+			EntityType: audit.EntityAttendance,
+			EntityID:   strconv.FormatUint(out.AttendanceID, 10),
+			After:      out,
+		}
+		if prev != nil {
+			e.Before = prev.toDTO()
+		}
+		return audit.Record(ctx, tx, e)
+	})
 	if err != nil {
 		return AttendanceResponse{}, false, err
 	}
-	return row.toDTO(), created, nil
+	return out, created, nil
 }
 
 // HEAD /attendances?user_id=&on=
 func (s *Service) Exists(ctx context.Context, userID string, onStr string) (bool, error) {
 	if userID == "" {
-		return false, ErrInvalid("user_id is required")
+		return false, apperr.Invalid("user_id is required")
 	}
 	on, err := parseOn(onStr)
 	if err != nil {
-		return false, ErrInvalid("on must be YYYY-MM-DD or 'today'")
+		return false, apperr.Invalid("on must be YYYY-MM-DD or 'today'")
 	}
 	return s.store.Exists(ctx, userID, on)
 }
 
 // GET /attendances
-func (s *Service) List(ctx context.Context, q ListQuery) ([]AttendanceResponse, int64, error) {
-	if q.Sort == "" {
+func (s *Service) List(ctx context.Context, q ListQuery) (pagination.Page[AttendanceResponse], error) {
+	switch q.Sort {
+	case SortClockedAtDesc, SortClockedAtAsc, SortAttendedOnDesc, SortAttendedOnAsc:
+	default:
 		q.Sort = DefaultSort
 	}
-	if q.Limit <= 0 {
-		q.Limit = DefaultPageLimit
-	}
-	if q.Limit > MaxPageLimit {
-		q.Limit = MaxPageLimit
-	}
 
-	rows, total, err := s.store.List(ctx, q)
+	rows, err := s.store.List(ctx, q)
 	if err != nil {
-		return nil, 0, err
-	}
-	out := make([]AttendanceResponse, 0, len(rows))
-	for i := 0; i < len(rows); i++ {
-		out = append(out, rows[i].toDTO())
+		return pagination.Page[AttendanceResponse]{}, err
 	}
-	return out, total, nil
+	page := pagination.Build(q.Page, rows, func(last Attendance) string {
+		return pagination.Encode(q.Sort, cursorKeys(q.Sort, last)...)
+	})
+	return pagination.Map(page, Attendance.toDTO), nil
 }
 
 // GET /attendances/stats
 func (s *Service) Stats(ctx context.Context, req StatsRequest) ([]StatsRow, error) {
 	from, err := time.ParseInLocation(DateLayout, req.From, tzLoc())
 	if err != nil {
-		return nil, ErrInvalid("from must be YYYY-MM-DD")
+		return nil, apperr.Invalid("from must be YYYY-MM-DD")
 	}
 	to, err := time.ParseInLocation(DateLayout, req.To, tzLoc())
 	if err != nil {
-		return nil, ErrInvalid("to must be YYYY-MM-DD")
+		return nil, apperr.Invalid("to must be YYYY-MM-DD")
 	}
 	if to.Before(from) {
-		return nil, ErrInvalid("to must be >= from")
+		return nil, apperr.Invalid("to must be >= from")
 	}
 	return s.store.Stats(ctx, from, to, req.Limit)
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"fmt"
 	"strings"
 	"time"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/pagination"
 )
 
 type DBTX interface {
@@ -54,13 +56,38 @@ func (s *Store) Upsert(ctx context.Context, student string, attendedOn *time.Tim
 	var r attendanceRow
 	if err := row.Scan(&r.AttendanceID, &r.StudentNumber, &r.AttendedOn, &r.ClockedAt, &r.Note); err != nil {
 		if err == sql.ErrNoRows {
-			return Attendance{}, created, ErrInternal("inserted but not found")
+			return Attendance{}, created, apperr.Internal("inserted but not found")
 		}
 		return Attendance{}, created, err
 	}
 	return r.toModel(), created, nil
 }
 
+// LockByStudentOn: 更新前の行をロックして取得（監査ログの before 用）。無ければ nil
+func (s *Store) LockByStudentOn(ctx context.Context, student string, attendedOn *time.Time) (*Attendance, error) {
+	attOn := any(nil)
+	if attendedOn != nil {
+		attOn = attendedOn.Format(DateLayout)
+	}
+	row := s.db.QueryRowContext(ctx, `
+	SELECT attendance_id, student_number, DATE_FORMAT(attended_on, '%Y-%m-%d') as attended_on, clocked_at, note
+	FROM attendances
+	WHERE student_number = ?
+	AND attended_on = COALESCE(?, CURRENT_DATE)
+	FOR UPDATE`,
+		student, attOn,
+	)
+	var r attendanceRow
+	if err := row.Scan(&r.AttendanceID, &r.StudentNumber, &r.AttendedOn, &r.ClockedAt, &r.Note); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m := r.toModel()
+	return &m, nil
+}
+
 // Exists: 指定ユーザが指定日(=on)に存在するか
 func (s *Store) Exists(ctx context.Context, student string, on time.Time) (bool, error) {
 	var one int
@@ -77,8 +104,32 @@ func (s *Store) Exists(ctx context.Context, student string, on time.Time) (bool,
 	return true, nil
 }
 
-// List: 条件に応じて動的WHERE + ORDER + LIMIT/OFFSET
-func (s *Store) List(ctx context.Context, q ListQuery) ([]Attendance, int64, error) {
+// listOrder: 並び順ごとの ORDER BY 列（末尾は必ず attendance_id で一意にする）
+func listOrder(sort string) []pagination.Column {
+	switch sort {
+	case SortClockedAtAsc:
+		return []pagination.Column{{Expr: "clocked_at"}, {Expr: "attendance_id"}}
+	case SortAttendedOnDesc:
+		return []pagination.Column{{Expr: "attended_on", Desc: true}, {Expr: "clocked_at", Desc: true}, {Expr: "attendance_id", Desc: true}}
+	case SortAttendedOnAsc:
+		return []pagination.Column{{Expr: "attended_on"}, {Expr: "clocked_at"}, {Expr: "attendance_id"}}
+	default:
+		return []pagination.Column{{Expr: "clocked_at", Desc: true}, {Expr: "attendance_id", Desc: true}}
+	}
+}
+
+// cursorKeys: 行から listOrder と同じ順のキーを取り出す
+func cursorKeys(sort string, a Attendance) []any {
+	switch sort {
+	case SortAttendedOnDesc, SortAttendedOnAsc:
+		return []any{a.AttendedOn, a.ClockedAt, a.AttendanceID}
+	default:
+		return []any{a.ClockedAt, a.AttendanceID}
+	}
+}
+
+// List: 条件に応じて動的WHERE + ORDER。cursor の続きから q.Page.Fetch() 件
+func (s *Store) List(ctx context.Context, q ListQuery) ([]Attendance, error) {
 	var (
 		buf    bytes.Buffer
 		args   []any
@@ -107,37 +158,43 @@ func (s *Store) List(ctx context.Context, q ListQuery) ([]Attendance, int64, err
 			args = append(args, mustDate(*q.To))
 		}
 	}
-	if len(wheres) > 0 {
-		buf.WriteString(" WHERE " + strings.Join(wheres, " AND "))
-	}
 
-	// ORDER
-	switch q.Sort {
-	case SortClockedAtAsc:
-		buf.WriteString(" ORDER BY clocked_at ASC, attendance_id ASC")
-	case SortAttendedOnDesc:
-		buf.WriteString(" ORDER BY attended_on DESC, clocked_at DESC, attendance_id DESC")
-	case SortAttendedOnAsc:
-		buf.WriteString(" ORDER BY attended_on ASC, clocked_at ASC, attendance_id ASC")
-	default:
-		buf.WriteString(" ORDER BY clocked_at DESC, attendance_id DESC")
+	// cursor（attended_on は "YYYY-MM-DD" のまま DATE 列と比較できる）
+	cols := listOrder(q.Sort)
+	var (
+		lastOn string
+		lastAt time.Time
+		lastID uint64
+	)
+	dest := []any{&lastAt, &lastID}
+	if len(cols) == 3 {
+		dest = []any{&lastOn, &lastAt, &lastID}
 	}
-
-	// LIMIT/OFFSET
-	limit := q.Limit
-	if limit <= 0 {
-		limit = DefaultPageLimit
+	ok, err := q.Page.After(q.Sort, dest...)
+	if err != nil {
+		return nil, err
 	}
-	if limit > MaxPageLimit {
-		limit = MaxPageLimit
+	if ok {
+		vals := []any{lastAt, lastID}
+		if len(cols) == 3 {
+			vals = []any{lastOn, lastAt, lastID}
+		}
+		cond, seekArgs := pagination.Seek(cols, vals)
+		wheres = append(wheres, cond)
+		args = append(args, seekArgs...)
 	}
-	offset := q.Offset
-	buf.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset))
+	if len(wheres) > 0 {
+		buf.WriteString(" WHERE " + strings.Join(wheres, " AND "))
+	}
+
+	// ORDER / LIMIT
+	buf.WriteString(" ORDER BY " + pagination.OrderBy(cols) + " LIMIT ?")
+	args = append(args, q.Page.Fetch())
 
 	// 実行
 	rows, err := s.db.QueryContext(ctx, buf.String(), args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -145,25 +202,11 @@ func (s *Store) List(ctx context.Context, q ListQuery) ([]Attendance, int64, err
 	for rows.Next() {
 		var r attendanceRow
 		if err := rows.Scan(&r.AttendanceID, &r.StudentNumber, &r.AttendedOn, &r.ClockedAt, &r.Note); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		out = append(out, r.toModel())
 	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
-
-	// COUNT（ORDER BY より前までを再構築）
-	var cntBuf bytes.Buffer
-	cntBuf.WriteString("SELECT COUNT(*) FROM attendances")
-	if len(wheres) > 0 {
-		cntBuf.WriteString(" WHERE " + strings.Join(wheres, " AND "))
-	}
-	var total int64
-	if err := s.db.QueryRowContext(ctx, cntBuf.String(), args...).Scan(&total); err != nil {
-		return nil, 0, err
-	}
-	return out, total, nil
+	return out, rows.Err()
 }
 
 // Stats: 期間の出席数をユーザ別合計（TOP N）
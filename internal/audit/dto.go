@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// ---- Responses ----
+
+type AuditResponse struct {
+	AuditID    uint64          `json:"audit_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorID    *string         `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  *string         `json:"request_id,omitempty"`
+}
+
+// ---- List payload ----
+
+type Page struct {
+	pagination.Request
+	Order string // "asc" or "desc"
+}
+
+type AuditFilter struct {
+	EntityType *string
+	EntityID   *string
+	ActorID    *string
+	Action     *string
+	RequestID  *string
+	From       *time.Time
+	To         *time.Time
+}
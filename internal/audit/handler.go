@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+	r.GET("/audit", auth.Allow(auth.StaffOnly...), h.ListAudit)
+}
+
+// GET /audit?entity_type=asset&entity_id=12&actor_id=&action=&request_id=&from=&to=
+func (h *Handler) ListAudit(c *gin.Context) {
+	f := AuditFilter{}
+	if v := c.Query("entity_type"); v != "" {
+		f.EntityType = &v
+	}
+	if v := c.Query("entity_id"); v != "" {
+		f.EntityID = &v
+	}
+	if v := c.Query("actor_id"); v != "" {
+		f.ActorID = &v
+	}
+	if v := c.Query("action"); v != "" {
+		f.Action = &v
+	}
+	if v := c.Query("request_id"); v != "" {
+		f.RequestID = &v
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("from must be RFC3339"))
+			return
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("to must be RFC3339"))
+			return
+		}
+		f.To = &t
+	}
+	req, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	p := Page{Request: req, Order: c.DefaultQuery("order", "desc")}
+	res, err := h.svc.List(c.Request.Context(), f, p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 操作の種類
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionUpsert   = "upsert"
+	ActionLend     = "lend"
+	ActionReturn   = "return"
+	ActionDispose  = "dispose"
+	ActionDelete   = "delete"
+	ActionAdjust   = "adjust" // 棚卸しの差異反映
+	ActionTransfer = "transfer"
+	ActionRenew    = "renew" // 返却期限の延長
+	ActionLose     = "lose"  // 紛失の届出
+)
+
+// 対象エンティティの種類（entity_id の意味も併記）
+const (
+	EntityAssetMaster        = "asset_master"        // management_number
+	EntityAsset              = "asset"               // asset_id
+	EntityLend               = "lend"                // lend_ulid
+	EntityReturn             = "return"              // return_ulid
+	EntityDisposal           = "disposal"            // disposal_ulid
+	EntityAttendance         = "attendance"          // attendance_id
+	EntityAssetStatus        = "asset_status"        // status_id
+	EntityGenre              = "genre"               // genre_id
+	EntityManagementCategory = "management_category" // management_category_id
+	EntityStocktake          = "stocktake"           // stocktake_id
+	EntityLocation           = "location"            // location_id
+	EntityTransfer           = "transfer"            // transfer_ulid
+	EntityAttachment         = "attachment"          // attachment_ulid
+	EntityReservation        = "reservation"         // reservation_ulid
+	EntityLoss               = "loss"                // loss_ulid
+)
+
+// Entry: Record に渡す1件分。Before/After は JSON 化できる任意の値（nil 可）
+type Entry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+}
+
+// DBモデル（audit_logs と1:1）
+type Log struct {
+	AuditID    uint64
+	OccurredAt time.Time
+	ActorID    sql.NullString
+	Action     string
+	EntityType string
+	EntityID   string
+	BeforeJSON []byte
+	AfterJSON  []byte
+	RequestID  sql.NullString
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+	"IRIS-backend/internal/platform/reqid"
+)
+
+// ===== 記録 =====
+
+// Record: 変更と同じトランザクション上で監査ログを1件追記する。
+// 操作者・リクエストIDはコンテキスト（auth / reqid ミドルウェア）から取る。
+// 失敗した場合は呼び出し元でロールバックさせること（変更だけ残るのを防ぐ）。
+func Record(ctx context.Context, tx db.DBTX, e Entry) error {
+	before, err := marshal(e.Before)
+	if err != nil {
+		return fmt.Errorf("audit: before の JSON 化に失敗: %w", err)
+	}
+	after, err := marshal(e.After)
+	if err != nil {
+		return fmt.Errorf("audit: after の JSON 化に失敗: %w", err)
+	}
+	var rid *string
+	if v := reqid.FromContext(ctx); v != "" {
+		rid = &v
+	}
+	return insertLog(ctx, tx, time.Now().UTC(), auth.ActorID(ctx), rid, e, before, after)
+}
+
+func marshal(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// ===== Service =====
+
+type Service struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewService(db *sql.DB) *Service { return &Service{db: db, store: NewStore(db)} }
+
+// GET /audit
+func (s *Service) List(ctx context.Context, f AuditFilter, p Page) (pagination.Page[AuditResponse], error) {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return pagination.Page[AuditResponse]{}, apperr.Invalid("from must be before to")
+	}
+	desc := strings.ToLower(p.Order) != "asc"
+	sort := "audit_id:asc"
+	if desc {
+		sort = "audit_id:desc"
+	}
+	var after *uint64
+	var id uint64
+	if ok, err := p.After(sort, &id); err != nil {
+		return pagination.Page[AuditResponse]{}, err
+	} else if ok {
+		after = &id
+	}
+	rows, err := s.store.List(ctx, f, desc, after, p.Fetch())
+	if err != nil {
+		return pagination.Page[AuditResponse]{}, err
+	}
+	items := make([]AuditResponse, 0, len(rows))
+	for _, l := range rows {
+		items = append(items, AuditResponse{
+			AuditID:    l.AuditID,
+			OccurredAt: l.OccurredAt,
+			ActorID:    nullToPtr(l.ActorID),
+			Action:     l.Action,
+			EntityType: l.EntityType,
+			EntityID:   l.EntityID,
+			Before:     rawOrNil(l.BeforeJSON),
+			After:      rawOrNil(l.AfterJSON),
+			RequestID:  nullToPtr(l.RequestID),
+		})
+	}
+	return pagination.Build(p.Request, items, func(last AuditResponse) string {
+		return pagination.Encode(sort, last.AuditID)
+	}), nil
+}
+
+// ---- helpers ----
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
+
+func rawOrNil(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.RawMessage(b)
+}
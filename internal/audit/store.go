@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Store struct{ db *sql.DB }
+
+func NewStore(db *sql.DB) *Store { return &Store{db: db} }
+
+// insertLog: 呼び出し元のトランザクション上で1行追記する
+func insertLog(ctx context.Context, tx db.DBTX, at time.Time, actor, requestID *string, e Entry, before, after []byte) error {
+	const q = `
+	INSERT INTO audit_logs
+	(occurred_at, actor_id, action, entity_type, entity_id, before_json, after_json, request_id)
+	VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, q,
+		at, actor, e.Action, e.EntityType, e.EntityID, jsonOrNil(before), jsonOrNil(after), requestID,
+	)
+	return err
+}
+
+// List: audit_id 順。afterID があればその続きから limit 件
+func (s *Store) List(ctx context.Context, f AuditFilter, desc bool, afterID *uint64, limit int) ([]Log, error) {
+	where := "WHERE 1=1"
+	args := []any{}
+	if f.EntityType != nil {
+		where += " AND entity_type = ?"
+		args = append(args, *f.EntityType)
+	}
+	if f.EntityID != nil {
+		where += " AND entity_id = ?"
+		args = append(args, *f.EntityID)
+	}
+	if f.ActorID != nil {
+		where += " AND actor_id = ?"
+		args = append(args, *f.ActorID)
+	}
+	if f.Action != nil {
+		where += " AND action = ?"
+		args = append(args, *f.Action)
+	}
+	if f.RequestID != nil {
+		where += " AND request_id = ?"
+		args = append(args, *f.RequestID)
+	}
+	if f.From != nil {
+		where += " AND occurred_at >= ?"
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		where += " AND occurred_at < ?"
+		args = append(args, *f.To)
+	}
+
+	cols := []pagination.Column{{Expr: "audit_id", Desc: desc}}
+	if afterID != nil {
+		cond, seekArgs := pagination.Seek(cols, []any{*afterID})
+		where += " AND " + cond
+		args = append(args, seekArgs...)
+	}
+
+	q := fmt.Sprintf(`
+	SELECT audit_id, occurred_at, actor_id, action, entity_type, entity_id, before_json, after_json, request_id
+	FROM audit_logs
+	%s
+	ORDER BY %s
+	LIMIT ?`, where, pagination.OrderBy(cols))
+	rows, err := s.db.QueryContext(ctx, q, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Log
+	for rows.Next() {
+		var l Log
+		if err := rows.Scan(
+			&l.AuditID, &l.OccurredAt, &l.ActorID, &l.Action, &l.EntityType, &l.EntityID,
+			&l.BeforeJSON, &l.AfterJSON, &l.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func jsonOrNil(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,124 @@
+// Package apperr: API 全体で共通のエラーモデル。
+// サービス層は *Error を返し、ハンドラは Abort で積むだけにする。
+// レスポンスへの書き出しは Middleware が一箇所で行う。
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code: クライアントが分岐に使う安定したエラーコード（文字列は変更しないこと）
+type Code string
+
+const (
+	CodeInvalidArgument      Code = "INVALID_ARGUMENT"
+	CodeUnauthenticated      Code = "UNAUTHENTICATED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT" // 在庫不足・一意制約違反など
+	CodePreconditionFailed   Code = "PRECONDITION_FAILED"
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+	CodeUnprocessable        Code = "UNPROCESSABLE_ENTITY"
+	CodeInternal             Code = "INTERNAL"
+)
+
+// FieldError: 入力項目ごとの検証エラー（field は JSON のキー名）
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code      Code           `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	Fields    []FieldError   `json:"fields,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%s: %s", e.Code, e.Message) }
+
+func New(code Code, msg string) *Error { return &Error{Code: code, Message: msg} }
+
+func Invalid(msg string) *Error         { return New(CodeInvalidArgument, msg) }
+func Unauthenticated(msg string) *Error { return New(CodeUnauthenticated, msg) }
+func Forbidden(msg string) *Error       { return New(CodeForbidden, msg) }
+func NotFound(msg string) *Error        { return New(CodeNotFound, msg) }
+func Conflict(msg string) *Error        { return New(CodeConflict, msg) }
+func PreconditionFailed(msg string) *Error {
+	return New(CodePreconditionFailed, msg)
+}
+func PreconditionRequired(msg string) *Error {
+	return New(CodePreconditionRequired, msg)
+}
+func Unprocessable(msg string) *Error { return New(CodeUnprocessable, msg) }
+func Internal(msg string) *Error      { return New(CodeInternal, msg) }
+
+// WithDetail: 補足情報（残数・現在のバージョン等）を付けたコピーを返す
+func (e *Error) WithDetail(key string, v any) *Error {
+	cp := *e
+	cp.Details = make(map[string]any, len(e.Details)+1)
+	for k, dv := range e.Details {
+		cp.Details[k] = dv
+	}
+	cp.Details[key] = v
+	return &cp
+}
+
+// WithField: 項目エラーを追加したコピーを返す
+func (e *Error) WithField(field, msg string) *Error {
+	cp := *e
+	cp.Fields = append(append([]FieldError{}, e.Fields...), FieldError{Field: field, Message: msg})
+	return &cp
+}
+
+// Is: errors.Is(err, apperr.NotFound("")) のようにコードだけで比較できるようにする
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if errors.As(target, &t) {
+		return e.Code == t.Code
+	}
+	return false
+}
+
+// HasCode: err が指定コードの *Error か
+func HasCode(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// HTTPStatus: コード → HTTP ステータス（対応表はここだけに置く）
+func HTTPStatus(code Code) int {
+	switch code {
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodePreconditionFailed:
+		return http.StatusPreconditionFailed
+	case CodePreconditionRequired:
+		return http.StatusPreconditionRequired
+	case CodeUnprocessable:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// From: 任意の error を *Error に寄せる。
+// *Error 以外（DBエラー等）は内部情報を出さないよう INTERNAL に丸める。
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal("internal error")
+}
@@ -0,0 +1,128 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"IRIS-backend/internal/platform/reqid"
+)
+
+// Body: エラーレスポンスの形（全エンドポイント共通）
+//
+//	{"error":{"code":"NOT_FOUND","message":"...","details":{...},"fields":[...],"request_id":"..."}}
+type Body struct {
+	Error *Error `json:"error"`
+}
+
+// Abort: ハンドラ/ミドルウェアからエラーを積んで後続を止める。書き出しは Middleware が行う
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Middleware: c.Errors に積まれた最後のエラーを共通形式で書き出す。
+// ルータの先頭（認証より前）に1回だけ登録すること。
+func Middleware() gin.HandlerFunc {
+	useJSONFieldNames()
+	return func(c *gin.Context) {
+		c.Next()
+		Flush(c)
+	}
+}
+
+// Flush: 積まれたエラーがあり未応答なら、その場で書き出す。
+// レスポンス本文を横取りしたいミドルウェア（冪等キー等）が c.Next() の直後に呼ぶ。
+func Flush(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := c.Errors.Last().Err
+	e := From(err)
+	rid := reqid.FromContext(c.Request.Context())
+	if e.Code == CodeInternal {
+		log.Printf("[%s] %s %s: %v", rid, c.Request.Method, c.FullPath(), err)
+	}
+	out := *e
+	out.RequestID = rid
+	c.JSON(HTTPStatus(e.Code), Body{Error: &out})
+}
+
+// Bind: ShouldBindJSON / ShouldBindQuery のエラーを INVALID_ARGUMENT に変換する。
+// validator のエラーは項目ごとの fields に展開する。
+func Bind(err error) *Error {
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		e := Invalid("validation failed")
+		for _, fe := range ve {
+			e.Fields = append(e.Fields, FieldError{Field: fieldPath(fe), Message: ruleMessage(fe)})
+		}
+		return e
+	}
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		return Invalid("invalid json").WithField(ute.Field, "must be "+ute.Type.String())
+	}
+	if errors.Is(err, io.EOF) {
+		return Invalid("request body is empty")
+	}
+	return Invalid("invalid json")
+}
+
+// fieldPath: "CreateLendRequest.quantity" → "quantity"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be >= " + fe.Param()
+	case "max", "lte":
+		return "must be <= " + fe.Param()
+	case "gt":
+		return "must be > " + fe.Param()
+	case "oneof":
+		return "must be one of [" + fe.Param() + "]"
+	case "len":
+		return "length must be " + fe.Param()
+	default:
+		return "failed on '" + fe.Tag() + "'"
+	}
+}
+
+var registerOnce sync.Once
+
+// useJSONFieldNames: validator が返す項目名を Go の構造体名ではなく json タグ名にする
+func useJSONFieldNames() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+// 認証済みの呼び出し元
+type Principal struct {
+	UserID  uint64 `json:"user_id"`
+	LoginID string `json:"login_id"`
+	Name    string `json:"display_name"`
+	Role    Role   `json:"role"`
+}
+
+// トークン → Principal の解決（users.Service が実装）
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (Principal, error)
+}
+
+type ctxKey struct{}
+
+const ginKey = "auth.principal"
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext: Service 層からは c.Request.Context() 経由でこれを使う
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// ActorID: 操作者の login_id（未認証なら nil）
+func ActorID(ctx context.Context) *string {
+	p, ok := FromContext(ctx)
+	if !ok || p.LoginID == "" {
+		return nil
+	}
+	v := p.LoginID
+	return &v
+}
+
+// Middleware: Authorization: Bearer <token> を検証し Principal をコンテキストへ積む
+func Middleware(a Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			apperr.Abort(c, apperr.Unauthenticated("missing bearer token"))
+			return
+		}
+		p, err := a.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			// 401 はトークンが無効なときだけ。DB 障害などはそのまま 500 にする
+			if apperr.HasCode(err, apperr.CodeUnauthenticated) {
+				err = apperr.Unauthenticated("invalid or expired token")
+			}
+			apperr.Abort(c, err)
+			return
+		}
+		c.Set(ginKey, p)
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// TokenFromRequest: ログアウト等でトークン文字列そのものが必要な場合に使う
+func TokenFromRequest(c *gin.Context) string {
+	return bearerToken(c.GetHeader("Authorization"))
+}
+
+func bearerToken(h string) string {
+	const prefix = "bearer "
+	if len(h) <= len(prefix) || strings.ToLower(h[:len(prefix)]) != prefix {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
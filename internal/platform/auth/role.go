@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"  // 全操作（廃棄・マスタ更新・ユーザ管理）
+	RoleStaff  Role = "staff"  // 備品登録・返却処理などの窓口業務
+	RoleMember Role = "member" // 一般利用者（自分名義の貸出のみ）
+	RoleKiosk  Role = "kiosk"  // 出席打刻端末
+)
+
+// ルート登録時に使うロール集合（auth.Allow(auth.StaffOnly...) のように展開して渡す）
+var (
+	AdminOnly = []Role{RoleAdmin}
+	StaffOnly = []Role{RoleAdmin, RoleStaff}
+	Members   = []Role{RoleAdmin, RoleStaff, RoleMember}
+	Everyone  = []Role{RoleAdmin, RoleStaff, RoleMember, RoleKiosk}
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleStaff, RoleMember, RoleKiosk:
+		return true
+	}
+	return false
+}
+
+// Is: 呼び出し元が指定ロールのいずれかか
+func (p Principal) Is(roles ...Role) bool {
+	for _, r := range roles {
+		if p.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow: 許可ロール以外は 403。Middleware の後段でルートごとに付ける
+func Allow(roles ...Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := FromContext(c.Request.Context())
+		if !ok {
+			apperr.Abort(c, apperr.Unauthenticated("not logged in"))
+			return
+		}
+		if !p.Is(roles...) {
+			apperr.Abort(c, apperr.Forbidden("role '"+string(p.Role)+"' is not allowed to perform this operation"))
+			return
+		}
+		c.Next()
+	}
+}
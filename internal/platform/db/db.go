@@ -29,10 +29,74 @@ type Certs struct {
 	Key  string `yaml:"key"`
 }
 
+type AuthConfig struct {
+	SessionTTLHours int `yaml:"session_ttl_hours"` // 0 なら既定値（12時間）
+}
+
+// NumberingConfig: 管理番号の形式（トークンは internal/asset_mgmt/numbering を参照）
+type NumberingConfig struct {
+	Template             string `yaml:"template"`                // 空なら "{genre}-{yyyymmdd}-{seq:5}"
+	FiscalYearStartMonth int    `yaml:"fiscal_year_start_month"` // {fy} の年度開始月。0 なら 4
+}
+
+// StorageConfig: 添付ファイルの置き場所（internal/platform/storage）
+type StorageConfig struct {
+	Driver      string `yaml:"driver"`        // 空か "local"
+	Dir         string `yaml:"dir"`           // local の保存先。空なら "data/storage"
+	MaxUploadMB int    `yaml:"max_upload_mb"` // 1ファイルの上限。0 なら 20
+}
+
+// LendConfig: 貸出の運用ルール（期間の上限はジャンルごとに asset_genres.max_loan_days）
+type LendConfig struct {
+	MaxRenewals int `yaml:"max_renewals"` // 1件の貸出で返却期限を延長できる回数。0 なら無制限
+}
+
+// ReservationConfig: 予約（順番待ち）の期限（internal/asset_mgmt/reservations）
+type ReservationConfig struct {
+	PickupHours     int `yaml:"pickup_hours"`     // 取り置きの受け取り期限（時間）。0 なら 48
+	MaxWaitDays     int `yaml:"max_wait_days"`    // 順番待ちのまま失効するまでの日数。0 なら 30
+	IntervalMinutes int `yaml:"interval_minutes"` // 期限切れの定期処理の間隔。0 なら定期実行しない（予約・貸出・返却のたびに処理する）
+}
+
+// NotifyConfig: 通知の送り先（internal/platform/notify）。どちらも未設定ならログに出すだけ
+type NotifyConfig struct {
+	SMTP    SMTPConfig    `yaml:"smtp"`
+	Webhook WebhookConfig `yaml:"webhook"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"` // 空なら SMTP は使わない
+	Port     int    `yaml:"port"` // 0 なら 587
+	Username string `yaml:"user"` // 空なら認証なし
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type WebhookConfig struct {
+	URL        string `yaml:"url"`         // 空なら Webhook は使わない
+	Secret     string `yaml:"secret"`      // 設定すると X-IRIS-Signature に HMAC-SHA256 を付ける
+	TimeoutSec int    `yaml:"timeout_sec"` // 0 なら 10
+}
+
+// ReminderConfig: 返却期限のリマインダ（internal/asset_mgmt/reminders）
+type ReminderConfig struct {
+	IntervalMinutes int `yaml:"interval_minutes"` // 定期実行の間隔。0 なら定期実行しない（手動実行のみ）
+	DaysBefore      int `yaml:"days_before"`      // 期限の何日前に予告するか。0 なら予告しない
+	RepeatDays      int `yaml:"repeat_days"`      // 延滞の再通知間隔（日）。0 なら延滞通知は 1 回だけ
+	MaxAttempts     int `yaml:"max_attempts"`     // 送信失敗の再試行の上限（通知1回分あたり）。0 なら上限なし
+}
+
 type Config struct {
-	Version string         `yaml:"version"`
-	DB      DatabaseConfig `yaml:"database"`
-	Certificate Certs      `yaml:"certificate"`
+	Version      string            `yaml:"version"`
+	DB           DatabaseConfig    `yaml:"database"`
+	Certificate  Certs             `yaml:"certificate"`
+	Auth         AuthConfig        `yaml:"auth"`
+	Numbering    NumberingConfig   `yaml:"numbering"`
+	Storage      StorageConfig     `yaml:"storage"`
+	Lends        LendConfig        `yaml:"lends"`
+	Reservations ReservationConfig `yaml:"reservations"`
+	Notify       NotifyConfig      `yaml:"notify"`
+	Reminders    ReminderConfig    `yaml:"reminders"`
 }
 
 func LoadConfig(path string) (*Config, error) {
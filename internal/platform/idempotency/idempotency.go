@@ -0,0 +1,253 @@
+// Package idempotency: Idempotency-Key ヘッダによる POST の再送対策。
+//
+// 同じ操作者・同じキーで再送された場合は、初回のレスポンスをそのまま返す（在庫を二重に動かさない）。
+// キーが同じで本文が異なる場合は 422、初回がまだ処理中なら 409 を返す。
+// 5xx で終わった場合（ハンドラがコミットまで到達していない）はキーを消し、クライアントが同じキーで再試行できるようにする。
+// それ以外で結果の保存に失敗した場合は、コミット済みの可能性があるのでキーを「処理中」のまま残す
+// （再送は 409 になり、在庫を二重に動かさない。期限切れ後は同じキーを使える）。
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotency-Replayed"
+
+	maxKeyLen = 128
+	ttl       = 24 * time.Hour
+
+	completeAttempts = 3
+	completeBackoff  = 200 * time.Millisecond
+)
+
+// 処理済みの記録（status_code が NULL = 処理中）
+type record struct {
+	RequestHash  string
+	StatusCode   sql.NullInt32
+	ContentType  sql.NullString
+	Location     sql.NullString
+	ResponseBody []byte
+}
+
+// Middleware: ルート単位で付ける。ヘッダが無いリクエストはそのまま通す
+func Middleware(db *sql.DB) gin.HandlerFunc {
+	st := &store{db: db}
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLen {
+			apperr.Abort(c, apperr.Invalid("Idempotency-Key must be at most 128 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apperr.Abort(c, apperr.Invalid("failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		actor := ""
+		if v := auth.ActorID(ctx); v != nil {
+			actor = *v
+		}
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+		now := time.Now().UTC()
+
+		ok, err := st.reserve(ctx, actor, key, c.Request.Method, c.Request.URL.Path, hash, now)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		if !ok {
+			replayOrReject(c, st, actor, key, hash)
+			return
+		}
+
+		// DB 後始末はクライアント切断に引きずられないよう独立したコンテキストで行う
+		bg, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		handled := false
+		defer func() {
+			// panic でハンドラが戻らなかった場合は、コミット前に落ちたものとして「処理中」のまま残さない
+			if !handled {
+				release(bg, st, actor, key)
+			}
+		}()
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		apperr.Flush(c) // エラー応答もここで確定させて保存対象にする
+		handled = true
+
+		status := rec.Status()
+		if status >= http.StatusInternalServerError {
+			release(bg, st, actor, key)
+			return
+		}
+		// ここから先はコミット済みの可能性がある。保存できなくてもキーは消さない
+		for i := 0; i < completeAttempts; i++ {
+			if i > 0 {
+				time.Sleep(time.Duration(i) * completeBackoff)
+			}
+			if err = st.complete(bg, actor, key, status, rec.Header().Get("Content-Type"), rec.Header().Get("Location"), rec.buf.Bytes()); err == nil {
+				return
+			}
+		}
+		log.Printf("idempotency: complete %q: %v (key left in progress)", key, err)
+	}
+}
+
+func release(ctx context.Context, st *store, actor, key string) {
+	if err := st.release(ctx, actor, key); err != nil {
+		log.Printf("idempotency: release %q: %v", key, err)
+	}
+}
+
+func replayOrReject(c *gin.Context, st *store, actor, key, hash string) {
+	r, err := st.get(c.Request.Context(), actor, key)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if r == nil {
+		// 直前に 5xx で解放された
+		apperr.Abort(c, apperr.Conflict("request with this Idempotency-Key was just released; retry"))
+		return
+	}
+	if r.RequestHash != hash {
+		apperr.Abort(c, apperr.Unprocessable("Idempotency-Key was already used with a different request"))
+		return
+	}
+	if !r.StatusCode.Valid {
+		apperr.Abort(c, apperr.Conflict("request with this Idempotency-Key is still in progress"))
+		return
+	}
+
+	if r.Location.Valid {
+		c.Header("Location", r.Location.String)
+	}
+	c.Header(ReplayedHeader, "true")
+	ct := "application/json; charset=utf-8"
+	if r.ContentType.Valid {
+		ct = r.ContentType.String
+	}
+	c.Data(int(r.StatusCode.Int32), ct, r.ResponseBody)
+	c.Abort()
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ===== レスポンスの横取り =====
+
+type recorder struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.buf.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.buf.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// ===== store =====
+
+type store struct{ db *sql.DB }
+
+// reserve: キーを「処理中」で確保する。既に存在すれば false
+func (s *store) reserve(ctx context.Context, actor, key, method, path, hash string, now time.Time) (bool, error) {
+	const q = `
+	INSERT INTO idempotency_keys
+	(actor_id, idem_key, method, path, request_hash, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for i := 0; i < 2; i++ {
+		_, err := s.db.ExecContext(ctx, q, actor, key, method, path, hash, now, now.Add(ttl))
+		if err == nil {
+			return true, nil
+		}
+		var me *mysql.MySQLError
+		if !errors.As(err, &me) || me.Number != 1062 {
+			return false, err
+		}
+		// 期限切れの行なら消して取り直す
+		res, err := s.db.ExecContext(ctx,
+			`DELETE FROM idempotency_keys WHERE actor_id = ? AND idem_key = ? AND expires_at <= ?`, actor, key, now)
+		if err != nil {
+			return false, err
+		}
+		if aff, _ := res.RowsAffected(); aff == 0 {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *store) get(ctx context.Context, actor, key string) (*record, error) {
+	const q = `
+	SELECT request_hash, status_code, content_type, location, response_body
+	FROM idempotency_keys WHERE actor_id = ? AND idem_key = ?`
+	var r record
+	err := s.db.QueryRowContext(ctx, q, actor, key).Scan(
+		&r.RequestHash, &r.StatusCode, &r.ContentType, &r.Location, &r.ResponseBody,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *store) complete(ctx context.Context, actor, key string, status int, contentType, location string, body []byte) error {
+	const q = `
+	UPDATE idempotency_keys
+	SET status_code = ?, content_type = ?, location = ?, response_body = ?
+	WHERE actor_id = ? AND idem_key = ?`
+	_, err := s.db.ExecContext(ctx, q, status, nullIfEmpty(contentType), nullIfEmpty(location), body, actor, key)
+	return err
+}
+
+func (s *store) release(ctx context.Context, actor, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE actor_id = ? AND idem_key = ?`, actor, key)
+	return err
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
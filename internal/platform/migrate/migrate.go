@@ -0,0 +1,292 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// マイグレーションSQLはバイナリに埋め込む（migrations/NNNN_name.up.sql / .down.sql）
+//
+//go:embed migrations/*.sql
+var files embed.FS
+
+const (
+	versionTable = "schema_migrations"
+	lockName     = "iris_schema_migrate"
+	lockTimeout  = 10 // 秒
+)
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB) (*Migrator, error) {
+	ms, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: ms}, nil
+}
+
+// load: 埋め込みファイルを version 昇順の Migration に組み立てる（up/down は必ずペア）
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileNameRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("不正なマイグレーションファイル名: %s", e.Name())
+		}
+		v, _ := strconv.ParseInt(m[1], 10, 64)
+		buf, err := fs.ReadFile(fsys, "migrations/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mg, ok := byVersion[v]
+		if !ok {
+			mg = &Migration{Version: v, Name: m[2]}
+			byVersion[v] = mg
+		} else if mg.Name != m[2] {
+			return nil, fmt.Errorf("version %d の名前が一致しません: %s / %s", v, mg.Name, m[2])
+		}
+		if m[3] == "up" {
+			mg.Up = string(buf)
+		} else {
+			mg.Down = string(buf)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if strings.TrimSpace(mg.Up) == "" || strings.TrimSpace(mg.Down) == "" {
+			return nil, fmt.Errorf("version %d (%s) の up/down が揃っていません", mg.Version, mg.Name)
+		}
+		out = append(out, *mg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up: 未適用のマイグレーションをすべて順に適用する
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if err := execScript(ctx, conn, mg.Up); err != nil {
+				return fmt.Errorf("%04d_%s up 失敗: %w", mg.Version, mg.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO `+versionTable+` (version, name, applied_at) VALUES (?, ?, UTC_TIMESTAMP())`,
+				mg.Version, mg.Name,
+			); err != nil {
+				return err
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down: 適用済みのものを新しい順に steps 件だけ戻す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mg := m.migrations[i]
+			if _, ok := applied[mg.Version]; !ok {
+				continue
+			}
+			if err := execScript(ctx, conn, mg.Down); err != nil {
+				return fmt.Errorf("%04d_%s down 失敗: %w", mg.Version, mg.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, `DELETE FROM `+versionTable+` WHERE version = ?`, mg.Version); err != nil {
+				return err
+			}
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status: 埋め込み済みマイグレーションごとの適用状況
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if at, ok := applied[mg.Version]; ok {
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// withLock: 複数プロセスから同時に流されないよう GET_LOCK で直列化する
+// ※ MySQL の DDL は暗黙コミットされるため、1マイグレーション内の途中失敗は手動で戻す必要がある
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName, lockTimeout).Scan(&got); err != nil {
+		return err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return fmt.Errorf("マイグレーションロックを取得できません（他のプロセスが実行中）")
+	}
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, lockName)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS `+versionTable+` (
+	  version    BIGINT       NOT NULL,
+	  name       VARCHAR(255) NOT NULL,
+	  applied_at DATETIME     NOT NULL,
+	  PRIMARY KEY (version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM `+versionTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		out[v] = at
+	}
+	return out, rows.Err()
+}
+
+// execScript: DSN に multiStatements を付けていないので1文ずつ流す
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%w\n--- statement ---\n%s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// splitStatements: 行末の ";" で文を区切る。"--" 以降（文字列の外）は行末コメントとして捨ててから見るので、
+// "...; -- コメント" の行もそこで文が終わる
+func splitStatements(script string) []string {
+	var (
+		out []string
+		cur strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		line = stripLineComment(line)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSuffix(strings.TrimSpace(cur.String()), ";")
+			out = append(out, stmt)
+			cur.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(cur.String()); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+// stripLineComment: 引用符（' " `）の外にある "-- "（MySQL と同じく直後が空白か行末のもの）から後ろを落とす
+func stripLineComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(line) && line[i+1] == '-':
+			if i+2 == len(line) || line[i+2] == ' ' || line[i+2] == '\t' || line[i+2] == '\r' {
+				return strings.TrimRight(line[:i], " \t")
+			}
+		}
+	}
+	return line
+}
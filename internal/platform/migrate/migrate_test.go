@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "single statement",
+			script: "CREATE TABLE a (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name:   "multi-line statements",
+			script: "CREATE TABLE a (\n  id INT\n);\n\nDROP TABLE b;\n",
+			want:   []string{"CREATE TABLE a (\n  id INT\n)", "DROP TABLE b"},
+		},
+		{
+			name:   "comment lines are dropped",
+			script: "-- header\nALTER TABLE a\n  -- inside\n  ADD COLUMN x INT;\n",
+			want:   []string{"ALTER TABLE a\n  ADD COLUMN x INT"},
+		},
+		{
+			name:   "trailing comment after semicolon ends the statement",
+			script: "ALTER TABLE a ADD COLUMN x INT; -- note\nCREATE TABLE b (id INT);\n",
+			want:   []string{"ALTER TABLE a ADD COLUMN x INT", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "trailing comment mid statement",
+			script: "ALTER TABLE a\n  ADD COLUMN x INT NULL, -- nullable\n  ADD KEY k (x);\n",
+			want:   []string{"ALTER TABLE a\n  ADD COLUMN x INT NULL,\n  ADD KEY k (x)"},
+		},
+		{
+			name:   "dashes inside quotes are kept",
+			script: "INSERT INTO t (v) VALUES ('a -- b'); -- c\n",
+			want:   []string{"INSERT INTO t (v) VALUES ('a -- b')"},
+		},
+		{
+			name:   "double dash without space is not a comment",
+			script: "SELECT 1--1;\n",
+			want:   []string{"SELECT 1--1"},
+		},
+		{
+			name:   "last statement without semicolon",
+			script: "DROP TABLE a;\nDROP TABLE b",
+			want:   []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name:   "empty",
+			script: "-- nothing\n\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 埋め込みのマイグレーションで、1つの文に ";" の行末が紛れ込んでいないこと（2文がつながっていないこと）
+func TestEmbeddedMigrationsSplit(t *testing.T) {
+	names, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for _, name := range names {
+		b, err := fs.ReadFile(files, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range splitStatements(string(b)) {
+			for _, line := range strings.Split(stmt, "\n") {
+				if strings.HasSuffix(strings.TrimSpace(line), ";") {
+					t.Errorf("%s: statement contains an inner terminator:\n%s", name, stmt)
+				}
+			}
+		}
+	}
+}
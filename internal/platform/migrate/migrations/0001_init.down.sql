@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS attendances;
+DROP TABLE IF EXISTS disposals;
+DROP TABLE IF EXISTS returns;
+DROP TABLE IF EXISTS lends;
+DROP TABLE IF EXISTS assets;
+DROP TABLE IF EXISTS assets_master;
+DROP TABLE IF EXISTS asset_statuses;
+DROP TABLE IF EXISTS asset_genres;
+DROP TABLE IF EXISTS management_categories;
@@ -0,0 +1,129 @@
+-- 初期スキーマ（assets / lends / disposals / attendance の各 Store が前提としているテーブル群）
+
+CREATE TABLE management_categories (
+  management_category_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  category_code          VARCHAR(16)  NOT NULL,
+  category_name          VARCHAR(100) NOT NULL,
+  created_at             DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (management_category_id),
+  UNIQUE KEY uq_management_categories_code (category_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE asset_genres (
+  genre_id   INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  genre_code VARCHAR(16)  NOT NULL,
+  genre_name VARCHAR(100) NOT NULL,
+  created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (genre_id),
+  UNIQUE KEY uq_asset_genres_code (genre_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE asset_statuses (
+  status_id   INT UNSIGNED NOT NULL,
+  status_name VARCHAR(50)  NOT NULL,
+  PRIMARY KEY (status_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- lends / disposals が参照している固定ID（1=利用可能, 4=貸出中, 5=廃棄済）
+INSERT INTO asset_statuses (status_id, status_name) VALUES
+  (1, '利用可能'),
+  (2, '使用中'),
+  (3, '修理中'),
+  (4, '貸出中'),
+  (5, '廃棄済');
+
+CREATE TABLE assets_master (
+  asset_master_id        BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  management_number      VARCHAR(64)     NOT NULL,
+  name                   VARCHAR(255)    NOT NULL,
+  management_category_id INT UNSIGNED    NOT NULL,
+  genre_id               INT UNSIGNED    NOT NULL,
+  manufacturer           VARCHAR(255)    NOT NULL,
+  model                  VARCHAR(255)    NULL,
+  created_at             DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (asset_master_id),
+  UNIQUE KEY uq_assets_master_mng (management_number),
+  KEY idx_assets_master_genre (genre_id),
+  KEY idx_assets_master_created (created_at),
+  CONSTRAINT fk_assets_master_category FOREIGN KEY (management_category_id) REFERENCES management_categories (management_category_id),
+  CONSTRAINT fk_assets_master_genre FOREIGN KEY (genre_id) REFERENCES asset_genres (genre_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE assets (
+  asset_id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  asset_master_id  BIGINT UNSIGNED NOT NULL,
+  serial           VARCHAR(255)    NULL,
+  quantity         INT UNSIGNED    NOT NULL DEFAULT 1,
+  purchased_at     DATETIME        NOT NULL,
+  status_id        INT UNSIGNED    NOT NULL,
+  owner            VARCHAR(100)    NOT NULL,
+  default_location VARCHAR(100)    NOT NULL,
+  location         VARCHAR(100)    NULL,
+  last_checked_at  DATETIME        NULL,
+  last_checked_by  VARCHAR(64)     NULL,
+  notes            TEXT            NULL,
+  PRIMARY KEY (asset_id),
+  KEY idx_assets_master (asset_master_id),
+  KEY idx_assets_purchased (purchased_at, asset_id),
+  CONSTRAINT fk_assets_master FOREIGN KEY (asset_master_id) REFERENCES assets_master (asset_master_id),
+  CONSTRAINT fk_assets_status FOREIGN KEY (status_id) REFERENCES asset_statuses (status_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE lends (
+  lend_id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  lend_ulid         CHAR(26)        NOT NULL,
+  asset_master_id   BIGINT UNSIGNED NOT NULL,
+  management_number VARCHAR(64)     NOT NULL,
+  quantity          INT UNSIGNED    NOT NULL,
+  borrower_id       VARCHAR(64)     NOT NULL,
+  due_on            DATE            NULL,
+  lent_by_id        VARCHAR(64)     NULL,
+  lent_at           DATETIME        NOT NULL,
+  note              TEXT            NULL,
+  returned          TINYINT(1)      NOT NULL DEFAULT 0,
+  PRIMARY KEY (lend_id),
+  UNIQUE KEY uq_lends_ulid (lend_ulid),
+  KEY idx_lends_lent_at (lent_at),
+  KEY idx_lends_borrower (borrower_id),
+  KEY idx_lends_master (asset_master_id),
+  CONSTRAINT fk_lends_master FOREIGN KEY (asset_master_id) REFERENCES assets_master (asset_master_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE returns (
+  return_id       BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  return_ulid     CHAR(26)        NOT NULL,
+  lend_id         BIGINT UNSIGNED NOT NULL,
+  quantity        INT UNSIGNED    NOT NULL,
+  processed_by_id VARCHAR(64)     NULL,
+  returned_at     DATETIME        NOT NULL,
+  note            TEXT            NULL,
+  PRIMARY KEY (return_id),
+  UNIQUE KEY uq_returns_ulid (return_ulid),
+  KEY idx_returns_lend (lend_id, returned_at),
+  CONSTRAINT fk_returns_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE disposals (
+  disposal_id       BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  disposal_ulid     CHAR(26)        NOT NULL,
+  management_number VARCHAR(64)     NOT NULL,
+  quantity          INT UNSIGNED    NOT NULL,
+  disposed_at       DATETIME        NOT NULL,
+  reason            TEXT            NULL,
+  processed_by_id   VARCHAR(64)     NULL,
+  PRIMARY KEY (disposal_id),
+  UNIQUE KEY uq_disposals_ulid (disposal_ulid),
+  KEY idx_disposals_mng (management_number),
+  KEY idx_disposals_disposed_at (disposed_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE attendances (
+  attendance_id  BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  student_number VARCHAR(32)     NOT NULL,
+  attended_on    DATE            NOT NULL,
+  clocked_at     DATETIME        NOT NULL,
+  note           VARCHAR(255)    NULL,
+  PRIMARY KEY (attendance_id),
+  UNIQUE KEY uq_attendances_student_on (student_number, attended_on),
+  KEY idx_attendances_clocked (clocked_at, attendance_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,27 @@
+-- 利用者アカウントとログインセッション
+
+CREATE TABLE users (
+  user_id       BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  login_id      VARCHAR(64)     NOT NULL,
+  display_name  VARCHAR(100)    NOT NULL,
+  password_hash VARCHAR(100)    NOT NULL,
+  is_active     TINYINT(1)      NOT NULL DEFAULT 1,
+  created_at    DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at    DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id),
+  UNIQUE KEY uq_users_login (login_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- トークン本体は保存せず SHA-256 のみ保持する
+CREATE TABLE user_sessions (
+  session_id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  token_hash CHAR(64)        NOT NULL,
+  user_id    BIGINT UNSIGNED NOT NULL,
+  created_at DATETIME        NOT NULL,
+  expires_at DATETIME        NOT NULL,
+  revoked_at DATETIME        NULL,
+  PRIMARY KEY (session_id),
+  UNIQUE KEY uq_user_sessions_token (token_hash),
+  KEY idx_user_sessions_user (user_id),
+  CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES users (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
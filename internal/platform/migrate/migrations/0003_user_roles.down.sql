@@ -0,0 +1,3 @@
+ALTER TABLE users
+  DROP CHECK chk_users_role,
+  DROP COLUMN role;
@@ -0,0 +1,4 @@
+-- ロール（admin / staff / member / kiosk）
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member' AFTER display_name,
+  ADD CONSTRAINT chk_users_role CHECK (role IN ('admin', 'staff', 'member', 'kiosk'));
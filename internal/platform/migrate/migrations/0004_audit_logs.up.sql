@@ -0,0 +1,17 @@
+-- 変更履歴（追記のみ。UPDATE / DELETE する API は用意しない）
+CREATE TABLE audit_logs (
+  audit_id    BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  occurred_at DATETIME(6)     NOT NULL,
+  actor_id    VARCHAR(64)     NULL,
+  action      VARCHAR(32)     NOT NULL,
+  entity_type VARCHAR(32)     NOT NULL,
+  entity_id   VARCHAR(64)     NOT NULL,
+  before_json JSON            NULL,
+  after_json  JSON            NULL,
+  request_id  VARCHAR(64)     NULL,
+  PRIMARY KEY (audit_id),
+  KEY idx_audit_entity (entity_type, entity_id, audit_id),
+  KEY idx_audit_actor (actor_id, audit_id),
+  KEY idx_audit_occurred (occurred_at),
+  KEY idx_audit_request (request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+-- Idempotency-Key の処理結果（同じキーの再送には保存したレスポンスを返す）
+-- status_code が NULL の行は「処理中」
+
+CREATE TABLE idempotency_keys (
+  actor_id      VARCHAR(64)       NOT NULL,
+  idem_key      VARCHAR(128)      NOT NULL,
+  method        VARCHAR(8)        NOT NULL,
+  path          VARCHAR(255)      NOT NULL,
+  request_hash  CHAR(64)          NOT NULL,
+  status_code   SMALLINT UNSIGNED NULL,
+  content_type  VARCHAR(100)      NULL,
+  location      VARCHAR(255)      NULL,
+  response_body MEDIUMBLOB        NULL,
+  created_at    DATETIME          NOT NULL,
+  expires_at    DATETIME          NOT NULL,
+  PRIMARY KEY (actor_id, idem_key),
+  KEY idx_idempotency_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
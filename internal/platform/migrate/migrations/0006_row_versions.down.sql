@@ -0,0 +1,3 @@
+ALTER TABLE assets DROP COLUMN version;
+
+ALTER TABLE assets_master DROP COLUMN version;
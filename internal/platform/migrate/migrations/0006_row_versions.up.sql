@@ -0,0 +1,5 @@
+-- 楽観ロック用の行バージョン（ETag / If-Match）。UPDATE のたびに +1 する
+
+ALTER TABLE assets_master ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
+
+ALTER TABLE assets ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
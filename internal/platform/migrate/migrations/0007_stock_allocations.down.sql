@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS disposal_allocations;
+
+DROP TABLE IF EXISTS lend_allocations;
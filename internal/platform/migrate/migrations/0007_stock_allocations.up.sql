@@ -0,0 +1,32 @@
+-- 貸出・廃棄がどの在庫行（assets）から何個動かしたか
+-- 返却は lend_allocations.returned_quantity を見て、貸し出した行へ正確に戻す
+
+CREATE TABLE lend_allocations (
+  lend_id           BIGINT UNSIGNED NOT NULL,
+  asset_id          BIGINT UNSIGNED NOT NULL,
+  quantity          INT UNSIGNED    NOT NULL,
+  returned_quantity INT UNSIGNED    NOT NULL DEFAULT 0,
+  PRIMARY KEY (lend_id, asset_id),
+  KEY idx_lend_allocations_asset (asset_id),
+  CONSTRAINT fk_lend_allocations_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id),
+  CONSTRAINT fk_lend_allocations_asset FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE disposal_allocations (
+  disposal_id BIGINT UNSIGNED NOT NULL,
+  asset_id    BIGINT UNSIGNED NOT NULL,
+  quantity    INT UNSIGNED    NOT NULL,
+  PRIMARY KEY (disposal_id, asset_id),
+  KEY idx_disposal_allocations_asset (asset_id),
+  CONSTRAINT fk_disposal_allocations_disposal FOREIGN KEY (disposal_id) REFERENCES disposals (disposal_id),
+  CONSTRAINT fk_disposal_allocations_asset FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 既存の貸出は、従来の LockAssetRow と同じく master 配下の先頭行から出したものとみなす
+INSERT INTO lend_allocations (lend_id, asset_id, quantity, returned_quantity)
+SELECT l.lend_id, a.asset_id, l.quantity, LEAST(l.quantity, COALESCE(r.sum_qty, 0))
+FROM lends l
+JOIN (SELECT asset_master_id, MIN(asset_id) AS asset_id FROM assets GROUP BY asset_master_id) a
+  ON a.asset_master_id = l.asset_master_id
+LEFT JOIN (SELECT lend_id, SUM(quantity) AS sum_qty FROM returns GROUP BY lend_id) r
+  ON r.lend_id = l.lend_id;
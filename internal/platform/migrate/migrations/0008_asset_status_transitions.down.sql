@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS asset_status_transitions;
+
+DELETE FROM asset_statuses WHERE status_id = 6;
+
+ALTER TABLE asset_statuses
+  DROP INDEX uq_asset_statuses_code,
+  DROP COLUMN status_code,
+  DROP COLUMN is_system;
@@ -0,0 +1,37 @@
+-- ステータスをコード付きのマスタにし、許可する遷移（from → to）を表で持つ。
+-- is_system = 1 の行はアプリが固定IDで参照しているので削除できない。
+
+ALTER TABLE asset_statuses
+  ADD COLUMN status_code VARCHAR(32) NULL AFTER status_id,
+  ADD COLUMN is_system TINYINT(1) NOT NULL DEFAULT 0;
+
+UPDATE asset_statuses SET status_code = 'available', is_system = 1 WHERE status_id = 1;
+UPDATE asset_statuses SET status_code = 'in_use',    is_system = 1 WHERE status_id = 2;
+UPDATE asset_statuses SET status_code = 'repair',    is_system = 1 WHERE status_id = 3;
+UPDATE asset_statuses SET status_code = 'lent',      is_system = 1 WHERE status_id = 4;
+UPDATE asset_statuses SET status_code = 'disposed',  is_system = 1 WHERE status_id = 5;
+UPDATE asset_statuses SET status_code = CONCAT('status_', status_id) WHERE status_code IS NULL;
+
+INSERT INTO asset_statuses (status_id, status_code, status_name, is_system) VALUES
+  (6, 'lost', '紛失', 1);
+
+ALTER TABLE asset_statuses
+  MODIFY COLUMN status_code VARCHAR(32) NOT NULL,
+  ADD UNIQUE KEY uq_asset_statuses_code (status_code);
+
+CREATE TABLE asset_status_transitions (
+  from_status_id INT UNSIGNED NOT NULL,
+  to_status_id   INT UNSIGNED NOT NULL,
+  PRIMARY KEY (from_status_id, to_status_id),
+  KEY idx_asset_status_transitions_to (to_status_id),
+  CONSTRAINT fk_asset_status_transitions_from FOREIGN KEY (from_status_id) REFERENCES asset_statuses (status_id),
+  CONSTRAINT fk_asset_status_transitions_to FOREIGN KEY (to_status_id) REFERENCES asset_statuses (status_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 既定の遷移: 利用可能 ⇄ 使用中 / 修理中 / 貸出中、貸出中 → 紛失 → 利用可能、廃棄済以外 → 廃棄済
+INSERT INTO asset_status_transitions (from_status_id, to_status_id) VALUES
+  (1, 2), (1, 3), (1, 4), (1, 5),
+  (2, 1), (2, 3), (2, 5),
+  (3, 1), (3, 5),
+  (4, 1), (4, 5), (4, 6),
+  (6, 1), (6, 5);
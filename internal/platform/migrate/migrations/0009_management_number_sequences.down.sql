@@ -0,0 +1 @@
+DROP TABLE IF EXISTS management_number_sequences;
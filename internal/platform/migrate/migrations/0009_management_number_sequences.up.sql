@@ -0,0 +1,15 @@
+-- 管理番号の連番（scope は numbering テンプレートの {seq}/{check} 以外を展開した文字列）
+
+CREATE TABLE management_number_sequences (
+  scope      VARCHAR(191)    NOT NULL,
+  last_value BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (scope)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 既存の番号（旧形式 GENRE-YYYYMMDD-NNNNN）は既定テンプレートの連番として引き継ぎ、重複を避ける
+INSERT INTO management_number_sequences (scope, last_value)
+SELECT CONCAT(SUBSTRING_INDEX(management_number, '-', 2), '-{seq}'),
+       MAX(CAST(SUBSTRING_INDEX(management_number, '-', -1) AS UNSIGNED))
+FROM assets_master
+WHERE management_number REGEXP '^[A-Z0-9]+-[0-9]{8}-[0-9]+$'
+GROUP BY SUBSTRING_INDEX(management_number, '-', 2);
@@ -0,0 +1 @@
+ALTER TABLE assets_master DROP INDEX ft_assets_master_search;
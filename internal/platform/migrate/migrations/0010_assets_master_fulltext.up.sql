@@ -0,0 +1,4 @@
+-- マスタのキーワード検索用（日本語・型番の部分一致に効くよう ngram パーサ）
+
+ALTER TABLE assets_master
+  ADD FULLTEXT INDEX ft_assets_master_search (name, manufacturer, model, management_number) WITH PARSER ngram;
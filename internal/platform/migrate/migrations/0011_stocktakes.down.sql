@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stocktake_counts;
+DROP TABLE IF EXISTS stocktakes;
@@ -0,0 +1,30 @@
+-- 棚卸し。セッションを場所かジャンルで絞って開き、在庫行ごとに数えた数を積む。
+-- 差異は締めるときに assets.quantity（貸出中の分は既に差し引かれている）と突き合わせる。
+
+CREATE TABLE stocktakes (
+  stocktake_id  BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  location      VARCHAR(100)    NULL,
+  genre_id      INT UNSIGNED    NULL,
+  note          TEXT            NULL,
+  status        VARCHAR(16)     NOT NULL DEFAULT 'open', -- open | closed
+  opened_by_id  VARCHAR(64)     NULL,
+  opened_at     DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  closed_by_id  VARCHAR(64)     NULL,
+  closed_at     DATETIME        NULL,
+  applied       TINYINT(1)      NOT NULL DEFAULT 0,
+  PRIMARY KEY (stocktake_id),
+  KEY idx_stocktakes_status (status, opened_at),
+  CONSTRAINT fk_stocktakes_genre FOREIGN KEY (genre_id) REFERENCES asset_genres (genre_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE stocktake_counts (
+  stocktake_id     BIGINT UNSIGNED NOT NULL,
+  asset_id         BIGINT UNSIGNED NOT NULL,
+  counted_quantity INT UNSIGNED    NOT NULL,
+  counted_by_id    VARCHAR(64)     NULL,
+  counted_at       DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (stocktake_id, asset_id),
+  KEY idx_stocktake_counts_asset (asset_id),
+  CONSTRAINT fk_stocktake_counts_stocktake FOREIGN KEY (stocktake_id) REFERENCES stocktakes (stocktake_id) ON DELETE CASCADE,
+  CONSTRAINT fk_stocktake_counts_asset FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,33 @@
+-- 場所の名前を文字列に戻す。元に戻しきれない（lossy）:
+--   * 建物・部屋・棚の階層とコードは失われる
+--   * up で捨てた、貸出中に location へ書かれていた借用者IDは戻らない（その行の location は NULL のまま）
+
+ALTER TABLE stocktakes
+  DROP FOREIGN KEY fk_stocktakes_location,
+  ADD COLUMN location VARCHAR(100) NULL AFTER stocktake_id;
+
+UPDATE stocktakes s JOIN locations l ON l.location_id = s.location_id
+SET s.location = l.location_name;
+
+ALTER TABLE stocktakes DROP COLUMN location_id;
+
+ALTER TABLE assets
+  DROP FOREIGN KEY fk_assets_default_location,
+  DROP FOREIGN KEY fk_assets_location,
+  ADD COLUMN default_location VARCHAR(100) NULL AFTER owner,
+  ADD COLUMN location         VARCHAR(100) NULL AFTER default_location;
+
+UPDATE assets a JOIN locations l ON l.location_id = a.default_location_id
+SET a.default_location = l.location_name;
+
+UPDATE assets a JOIN locations l ON l.location_id = a.location_id
+SET a.location = l.location_name;
+
+ALTER TABLE assets
+  MODIFY COLUMN default_location VARCHAR(100) NOT NULL,
+  DROP INDEX idx_assets_default_location,
+  DROP INDEX idx_assets_location,
+  DROP COLUMN default_location_id,
+  DROP COLUMN location_id;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,70 @@
+-- 保管場所を 建物 → 部屋 → 棚 の階層を持つマスタにし、在庫行・棚卸しは location_id で参照する。
+-- 既存の文字列は最上位の「部屋」として取り込む。
+-- 貸出時に location へ借用者IDが書かれていた行（その行を割り当てた貸出の borrower_id と一致するもの）だけは場所ではないので捨てる。
+-- 他の行の location が、たまたまどこかの貸出の borrower_id と同じ文字列でも、それは場所として残す。
+
+CREATE TABLE locations (
+  location_id   INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  parent_id     INT UNSIGNED NULL,
+  location_code VARCHAR(32)  NULL,
+  location_name VARCHAR(100) NOT NULL,
+  kind          VARCHAR(16)  NOT NULL, -- building | room | shelf
+  created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (location_id),
+  KEY idx_locations_parent (parent_id),
+  CONSTRAINT fk_locations_parent FOREIGN KEY (parent_id) REFERENCES locations (location_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO locations (location_name, kind)
+SELECT v, 'room' FROM (
+  SELECT default_location AS v FROM assets
+  UNION
+  SELECT a.location FROM assets a
+  WHERE a.location IS NOT NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM lend_allocations la JOIN lends ld ON ld.lend_id = la.lend_id
+      WHERE la.asset_id = a.asset_id AND ld.borrower_id = a.location
+    )
+  UNION
+  SELECT location FROM stocktakes WHERE location IS NOT NULL
+) s
+ORDER BY v;
+
+UPDATE locations SET location_code = CONCAT('L', LPAD(location_id, 5, '0'));
+
+ALTER TABLE locations
+  MODIFY COLUMN location_code VARCHAR(32) NOT NULL,
+  ADD UNIQUE KEY uq_locations_code (location_code);
+
+ALTER TABLE assets
+  ADD COLUMN default_location_id INT UNSIGNED NULL AFTER owner,
+  ADD COLUMN location_id         INT UNSIGNED NULL AFTER default_location_id;
+
+UPDATE assets a JOIN locations l ON l.location_name = a.default_location
+SET a.default_location_id = l.location_id;
+
+UPDATE assets a JOIN locations l ON l.location_name = a.location
+SET a.location_id = l.location_id
+WHERE NOT EXISTS (
+  SELECT 1 FROM lend_allocations la JOIN lends ld ON ld.lend_id = la.lend_id
+  WHERE la.asset_id = a.asset_id AND ld.borrower_id = a.location
+);
+
+ALTER TABLE assets
+  MODIFY COLUMN default_location_id INT UNSIGNED NOT NULL,
+  DROP COLUMN default_location,
+  DROP COLUMN location,
+  ADD KEY idx_assets_default_location (default_location_id),
+  ADD KEY idx_assets_location (location_id),
+  ADD CONSTRAINT fk_assets_default_location FOREIGN KEY (default_location_id) REFERENCES locations (location_id),
+  ADD CONSTRAINT fk_assets_location FOREIGN KEY (location_id) REFERENCES locations (location_id);
+
+ALTER TABLE stocktakes
+  ADD COLUMN location_id INT UNSIGNED NULL AFTER stocktake_id;
+
+UPDATE stocktakes s JOIN locations l ON l.location_name = s.location
+SET s.location_id = l.location_id;
+
+ALTER TABLE stocktakes
+  DROP COLUMN location,
+  ADD CONSTRAINT fk_stocktakes_location FOREIGN KEY (location_id) REFERENCES locations (location_id);
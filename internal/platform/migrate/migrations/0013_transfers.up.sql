@@ -0,0 +1,26 @@
+-- 在庫行の移動（場所・所有者の変更）の履歴。一部だけ移すときは在庫行を分割し、
+-- 移した分を持つ新しい行を to_asset_id に残す（全量なら asset_id と同じ）。
+
+CREATE TABLE transfers (
+  transfer_id       BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  transfer_ulid     CHAR(26)        NOT NULL,
+  asset_id          BIGINT UNSIGNED NOT NULL,
+  to_asset_id       BIGINT UNSIGNED NOT NULL,
+  quantity          INT UNSIGNED    NOT NULL,
+  from_location_id  INT UNSIGNED    NOT NULL,
+  to_location_id    INT UNSIGNED    NOT NULL,
+  from_owner        VARCHAR(100)    NOT NULL,
+  to_owner          VARCHAR(100)    NOT NULL,
+  reason            TEXT            NULL,
+  transferred_by_id VARCHAR(64)     NULL,
+  transferred_at    DATETIME        NOT NULL,
+  PRIMARY KEY (transfer_id),
+  UNIQUE KEY uq_transfers_ulid (transfer_ulid),
+  KEY idx_transfers_asset (asset_id),
+  KEY idx_transfers_to_asset (to_asset_id),
+  KEY idx_transfers_transferred_at (transferred_at, transfer_id),
+  CONSTRAINT fk_transfers_asset FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  CONSTRAINT fk_transfers_to_asset FOREIGN KEY (to_asset_id) REFERENCES assets (asset_id),
+  CONSTRAINT fk_transfers_from_location FOREIGN KEY (from_location_id) REFERENCES locations (location_id),
+  CONSTRAINT fk_transfers_to_location FOREIGN KEY (to_location_id) REFERENCES locations (location_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- 添付ファイル（請求書・取説・貸出／返却時の写真・廃棄の証跡など）。実体はストレージに置き、ここはメタデータだけ。
+-- owner_type / owner_id は監査ログの entity_type / entity_id と同じ組（asset_master は management_number、asset は asset_id、
+-- lend は lend_ulid、disposal は disposal_ulid）。
+
+CREATE TABLE attachments (
+  attachment_id   BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  attachment_ulid CHAR(26)        NOT NULL,
+  owner_type      VARCHAR(32)     NOT NULL,
+  owner_id        VARCHAR(64)     NOT NULL,
+  kind            VARCHAR(32)     NULL, -- invoice | manual | checkout | return | evidence など（自由）
+  file_name       VARCHAR(255)    NOT NULL,
+  content_type    VARCHAR(100)    NOT NULL,
+  size_bytes      BIGINT UNSIGNED NOT NULL,
+  sha256          CHAR(64)        NOT NULL,
+  storage_key     VARCHAR(255)    NOT NULL,
+  thumbnail_key   VARCHAR(255)    NULL,
+  note            TEXT            NULL,
+  uploaded_by_id  VARCHAR(64)     NULL,
+  uploaded_at     DATETIME        NOT NULL,
+  PRIMARY KEY (attachment_id),
+  UNIQUE KEY uq_attachments_ulid (attachment_ulid),
+  KEY idx_attachments_owner (owner_type, owner_id, attachment_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS asset_master_attributes;
+DROP TABLE IF EXISTS genre_attributes;
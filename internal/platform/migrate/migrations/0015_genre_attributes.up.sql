@@ -0,0 +1,27 @@
+-- ジャンルごとの追加属性（CPU/RAM/OS、長さ/コネクタ、危険物分類など）の定義と、資産マスタの値。
+-- 値は検索用に型ごとの列にも入れる（value_text は表示・一致検索用の正規形）。
+
+CREATE TABLE genre_attributes (
+  genre_id    INT UNSIGNED NOT NULL,
+  attr_key    VARCHAR(32)  NOT NULL,
+  label       VARCHAR(100) NOT NULL,
+  value_type  VARCHAR(8)   NOT NULL, -- string | number | enum | date | bool
+  required    TINYINT(1)   NOT NULL DEFAULT 0,
+  enum_values JSON         NULL,     -- value_type = enum のときの選択肢
+  sort_order  INT UNSIGNED NOT NULL DEFAULT 0,
+  PRIMARY KEY (genre_id, attr_key),
+  CONSTRAINT fk_genre_attributes_genre FOREIGN KEY (genre_id) REFERENCES asset_genres (genre_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE asset_master_attributes (
+  asset_master_id BIGINT UNSIGNED NOT NULL,
+  attr_key        VARCHAR(32)     NOT NULL,
+  value_text      VARCHAR(255)    NOT NULL,
+  value_number    DECIMAL(20,6)   NULL, -- number（bool は 0/1）
+  value_date      DATE            NULL,
+  PRIMARY KEY (asset_master_id, attr_key),
+  KEY idx_ama_text (attr_key, value_text),
+  KEY idx_ama_number (attr_key, value_number),
+  KEY idx_ama_date (attr_key, value_date),
+  CONSTRAINT fk_ama_master FOREIGN KEY (asset_master_id) REFERENCES assets_master (asset_master_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS lend_reminders;
+
+ALTER TABLE lends
+  DROP KEY idx_lends_due;
+
+ALTER TABLE users
+  DROP COLUMN email;
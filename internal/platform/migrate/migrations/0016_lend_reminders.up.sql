@@ -0,0 +1,19 @@
+-- 返却期限のリマインダ。送り先のメールアドレスを利用者に持たせ、送った記録を残して二重送信を防ぐ
+
+ALTER TABLE users
+  ADD COLUMN email VARCHAR(254) NULL AFTER display_name;
+
+ALTER TABLE lends
+  ADD KEY idx_lends_due (returned, due_on);
+
+CREATE TABLE lend_reminders (
+  reminder_id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  lend_id     BIGINT UNSIGNED NOT NULL,
+  kind        VARCHAR(16)     NOT NULL, -- due_soon | overdue
+  recipient   VARCHAR(254)    NULL,     -- メールを送れなかった（アドレス未登録）ときは NULL
+  sent_at     DATETIME        NOT NULL,
+  error       VARCHAR(500)    NULL,     -- 送信に失敗したときの理由（次の実行で再送する）
+  PRIMARY KEY (reminder_id),
+  KEY idx_lend_reminders_lend (lend_id, kind, sent_at),
+  CONSTRAINT fk_lend_reminders_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
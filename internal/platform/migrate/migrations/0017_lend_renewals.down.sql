@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lend_renewals;
+
+ALTER TABLE asset_genres
+  DROP COLUMN max_loan_days;
@@ -0,0 +1,20 @@
+-- 返却期限の延長。延長ごとに旧・新の期限と承認者・理由を残す。貸出期間の上限はジャンルごと
+
+-- max_loan_days が NULL なら上限なし
+ALTER TABLE asset_genres
+  ADD COLUMN max_loan_days INT UNSIGNED NULL AFTER genre_name;
+
+CREATE TABLE lend_renewals (
+  renewal_id     BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  renewal_ulid   CHAR(26)        NOT NULL,
+  lend_id        BIGINT UNSIGNED NOT NULL,
+  old_due_on     DATE            NOT NULL,
+  new_due_on     DATE            NOT NULL,
+  approved_by_id VARCHAR(64)     NULL,
+  reason         VARCHAR(500)    NOT NULL,
+  renewed_at     DATETIME        NOT NULL,
+  PRIMARY KEY (renewal_id),
+  UNIQUE KEY uq_lend_renewals_ulid (renewal_ulid),
+  KEY idx_lend_renewals_lend (lend_id, renewed_at),
+  CONSTRAINT fk_lend_renewals_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
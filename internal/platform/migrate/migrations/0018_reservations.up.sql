@@ -0,0 +1,26 @@
+-- 在庫切れの資産マスタへの予約（順番待ち）。同じマスタの中では reservation_id 順（先着順）に引き当てる
+-- status: waiting（順番待ち）→ ready（取り置き中。pickup_until まで）→ fulfilled（貸出済み）
+--         途中で cancelled / expired（受け取り期限切れ・待ち期限切れ）になる
+
+CREATE TABLE reservations (
+  reservation_id   BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reservation_ulid CHAR(26)        NOT NULL,
+  asset_master_id  BIGINT UNSIGNED NOT NULL,
+  borrower_id      VARCHAR(64)     NOT NULL,
+  quantity         INT UNSIGNED    NOT NULL,
+  status           VARCHAR(16)     NOT NULL DEFAULT 'waiting',
+  note             TEXT            NULL,
+  created_by_id    VARCHAR(64)     NULL,
+  created_at       DATETIME        NOT NULL,
+  ready_at         DATETIME        NULL,
+  pickup_until     DATETIME        NULL,
+  closed_at        DATETIME        NULL,
+  lend_id          BIGINT UNSIGNED NULL, -- fulfilled のときの貸出
+  PRIMARY KEY (reservation_id),
+  UNIQUE KEY uq_reservations_ulid (reservation_ulid),
+  KEY idx_reservations_queue (asset_master_id, status, reservation_id),
+  KEY idx_reservations_borrower (borrower_id, status),
+  KEY idx_reservations_pickup (status, pickup_until),
+  CONSTRAINT fk_reservations_master FOREIGN KEY (asset_master_id) REFERENCES assets_master (asset_master_id),
+  CONSTRAINT fk_reservations_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE lends
+  DROP KEY idx_lends_checkout,
+  DROP COLUMN checkout_ulid;
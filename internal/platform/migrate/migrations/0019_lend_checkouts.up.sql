@@ -0,0 +1,5 @@
+-- まとめて貸出（POST /lends/batch）。同じ手続きで作った貸出を checkout_ulid でまとめる
+
+ALTER TABLE lends
+  ADD COLUMN checkout_ulid CHAR(26) NULL AFTER lend_ulid, -- 単品の貸出は NULL
+  ADD KEY idx_lends_checkout (checkout_ulid);
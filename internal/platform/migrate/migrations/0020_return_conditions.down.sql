@@ -0,0 +1,10 @@
+DELETE FROM asset_status_transitions WHERE from_status_id = 4 AND to_status_id = 3;
+
+DROP TABLE IF EXISTS lend_losses;
+
+ALTER TABLE lend_allocations
+  DROP COLUMN lost_quantity;
+
+ALTER TABLE returns
+  DROP COLUMN damage_description,
+  DROP COLUMN condition_grade;
@@ -0,0 +1,31 @@
+-- 返却時の状態（good / worn / damaged）と破損内容。破損で戻った分は「修理中」の在庫行へ回す。
+-- 紛失の届出は未返却数を閉じるだけで在庫には戻さない（lend_allocations.lost_quantity と lend_losses）
+
+ALTER TABLE returns
+  ADD COLUMN condition_grade    VARCHAR(16)   NOT NULL DEFAULT 'good' AFTER quantity,
+  ADD COLUMN damage_description VARCHAR(1000) NULL AFTER condition_grade;
+
+ALTER TABLE lend_allocations
+  ADD COLUMN lost_quantity INT UNSIGNED NOT NULL DEFAULT 0 AFTER returned_quantity;
+
+CREATE TABLE lend_losses (
+  loss_id        BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  loss_ulid      CHAR(26)        NOT NULL,
+  lend_id        BIGINT UNSIGNED NOT NULL,
+  quantity       INT UNSIGNED    NOT NULL,
+  description    VARCHAR(1000)   NOT NULL,
+  status         VARCHAR(16)     NOT NULL DEFAULT 'open', -- open / closed
+  reported_by_id VARCHAR(64)     NULL,
+  reported_at    DATETIME        NOT NULL,
+  closed_by_id   VARCHAR(64)     NULL,
+  closed_at      DATETIME        NULL,
+  resolution     VARCHAR(500)    NULL, -- 弁償済み・見つからず償却など
+  PRIMARY KEY (loss_id),
+  UNIQUE KEY uq_lend_losses_ulid (loss_ulid),
+  KEY idx_lend_losses_lend (lend_id, loss_id),
+  KEY idx_lend_losses_status (status, loss_id),
+  CONSTRAINT fk_lend_losses_lend FOREIGN KEY (lend_id) REFERENCES lends (lend_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 貸出中の行が破損で戻ったときは、そのまま修理中にする
+INSERT IGNORE INTO asset_status_transitions (from_status_id, to_status_id) VALUES (4, 3);
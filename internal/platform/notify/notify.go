@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// Message: 送る内容。To はメールの宛先（Webhook では本文に載せるだけ）、Data は Webhook にそのまま載せる
+type Message struct {
+	Event   string   // 例: "lend.due_soon" / "lend.overdue"
+	To      []string // 空なら SMTP では送らない
+	Subject string
+	Body    string
+	Data    any
+}
+
+// Notifier: 通知の送り先
+type Notifier interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// ErrSkipped: 送り先がこのメッセージを送らなかった（SMTP で宛先がないなど）。Multi は成功にも失敗にも数えない
+var ErrSkipped = errors.New("notify: skipped")
+
+// New: 設定された送り先をまとめて返す。どれも未設定ならログに出すだけ
+func New(cfg db.NotifyConfig) Notifier {
+	var out Multi
+	if cfg.SMTP.Host != "" {
+		out = append(out, NewSMTP(cfg.SMTP))
+	}
+	if cfg.Webhook.URL != "" {
+		out = append(out, NewWebhook(cfg.Webhook))
+	}
+	if len(out) == 0 {
+		return Log{}
+	}
+	return out
+}
+
+// Multi: すべての送り先に送る。どれか1つに届けば成功（残りの失敗はログに出すだけ。
+// 失敗扱いにすると、届いた側にも次の実行で同じ通知が送られてしまう）。どこにも届かなければ失敗をまとめて返し、
+// どの送り先も送らなかった（宛先がないなど）なら ErrSkipped
+type Multi []Notifier
+
+func (m Multi) Send(ctx context.Context, msg Message) error {
+	var (
+		errs      []error
+		delivered bool
+	)
+	for _, n := range m {
+		switch err := n.Send(ctx, msg); {
+		case err == nil:
+			delivered = true
+		case errors.Is(err, ErrSkipped):
+		default:
+			errs = append(errs, err)
+		}
+	}
+	if delivered && len(errs) > 0 {
+		log.Printf("[WARN] notify %s: partially failed: %v", msg.Event, errors.Join(errs...))
+		return nil
+	}
+	if !delivered && len(errs) == 0 {
+		return ErrSkipped
+	}
+	return errors.Join(errs...)
+}
+
+// Log: サーバーログに出すだけ（開発用・送り先未設定のとき）
+type Log struct{}
+
+func (Log) Send(_ context.Context, m Message) error {
+	log.Printf("[INFO] notify %s to=%s subject=%q", m.Event, strings.Join(m.To, ","), m.Subject)
+	return nil
+}
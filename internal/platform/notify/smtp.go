@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// SMTP: メールで送る。本文は UTF-8 の text/plain（base64）
+type SMTP struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTP(cfg db.SMTPConfig) *SMTP {
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	s := &SMTP{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		host: cfg.Host,
+		from: cfg.From,
+	}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return s
+}
+
+// Send: 宛先がなければ ErrSkipped。net/smtp は ctx を見ないので、キャンセル済みなら送らないだけ
+func (s *SMTP) Send(ctx context.Context, m Message) error {
+	if len(m.To) == 0 {
+		return ErrSkipped
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, to := range m.To {
+		if strings.ContainsAny(to, "\r\n") {
+			return fmt.Errorf("smtp: invalid recipient %q", to)
+		}
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.from, m.To, s.build(m)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTP) build(m Message) []byte {
+	var b bytes.Buffer
+	header := func(k, v string) {
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", s.from)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r", "", "\n", " ").Replace(m.Subject)))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "base64")
+	b.WriteString("\r\n")
+
+	enc := base64.StdEncoding.EncodeToString([]byte(m.Body))
+	for len(enc) > 76 {
+		b.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	b.WriteString(enc + "\r\n")
+	return b.Bytes()
+}
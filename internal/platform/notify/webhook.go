@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// Webhook: JSON を POST する。2xx 以外は失敗
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhook(cfg db.WebhookConfig) *Webhook {
+	timeout := cfg.TimeoutSec
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &Webhook{
+		url:    cfg.URL,
+		secret: []byte(cfg.Secret),
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+type webhookPayload struct {
+	Event   string   `json:"event"`
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	Data    any      `json:"data,omitempty"`
+	SentAt  string   `json:"sent_at"`
+}
+
+func (w *Webhook) Send(ctx context.Context, m Message) error {
+	body, err := json.Marshal(webhookPayload{
+		Event:   m.Event,
+		To:      m.To,
+		Subject: m.Subject,
+		Body:    m.Body,
+		Data:    m.Data,
+		SentAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-IRIS-Event", m.Event)
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set("X-IRIS-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package pagination: 一覧 API 共通のカーソル方式ページング。
+//
+// 各一覧は「既存の並び順のキー + 主キー」でキーセット（WHERE (k, id) < (?, ?)）を作り、
+// OFFSET と COUNT(*) を使わない。深いページでも最初のページと同じコストで引ける。
+//
+//	GET /assets?limit=50                → {"items":[...],"next_cursor":"eyJzIj...","has_more":true}
+//	GET /assets?limit=50&cursor=eyJzIj... → 続き
+//
+// cursor は不透明な文字列として扱わせる（中身は並び順の識別子と最後の行のキー）。
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Page: 一覧レスポンスの共通形
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Request: ?limit=&cursor= を解釈したもの
+type Request struct {
+	Limit  int
+	cursor *cursor
+}
+
+type cursor struct {
+	Sort   string            `json:"s"`           // 並び順の識別子（別の並び順で発行された cursor を弾く）
+	Keys   []json.RawMessage `json:"k,omitempty"` // 前ページ最後の行のソートキー
+	Offset int               `json:"o,omitempty"` // キーセットにできない並び（関連度順）だけで使う
+}
+
+// FromQuery: limit（既定 50、最大 200）と cursor を読む
+func FromQuery(c *gin.Context) (Request, error) {
+	r := Request{Limit: DefaultLimit}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return Request{}, apperr.Invalid("limit must be a positive number")
+		}
+		r.Limit = min(n, MaxLimit)
+	}
+	if v := c.Query("cursor"); v != "" {
+		cur, err := decode(v)
+		if err != nil {
+			return Request{}, errBadCursor
+		}
+		r.cursor = cur
+	}
+	return r, nil
+}
+
+var errBadCursor = apperr.Invalid("invalid cursor").WithField("cursor", "is malformed or was issued for a different sort order")
+
+// Fetch: 続きがあるか判定するため、1件多く取る件数（SQL の LIMIT に使う）
+func (r Request) Fetch() int { return r.limit() + 1 }
+
+func (r Request) limit() int {
+	if r.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(r.Limit, MaxLimit)
+}
+
+// After: cursor があれば前ページ最後の行のキーを dest に読み出して true を返す。
+// sort は Encode に渡したものと同じ識別子（並び順が変わっていれば INVALID_ARGUMENT）。
+func (r Request) After(sort string, dest ...any) (bool, error) {
+	if r.cursor == nil {
+		return false, nil
+	}
+	if r.cursor.Sort != sort || len(r.cursor.Keys) != len(dest) {
+		return false, errBadCursor
+	}
+	for i, raw := range r.cursor.Keys {
+		if err := json.Unmarshal(raw, dest[i]); err != nil {
+			return false, errBadCursor
+		}
+	}
+	return true, nil
+}
+
+// Offset: キーセットにできない並びでの読み飛ばし件数
+func (r Request) Offset(sort string) (int, error) {
+	if r.cursor == nil {
+		return 0, nil
+	}
+	if r.cursor.Sort != sort || len(r.cursor.Keys) != 0 || r.cursor.Offset < 0 {
+		return 0, errBadCursor
+	}
+	return r.cursor.Offset, nil
+}
+
+// Build: Fetch() 件で取った rows から1ページ分を切り出す。
+// 続きがあれば、ページ最後の要素から next で次の cursor を作る。
+func Build[T any](r Request, rows []T, next func(last T) string) Page[T] {
+	p := Page[T]{Items: rows}
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+	if n := r.limit(); len(rows) > n {
+		p.Items = rows[:n]
+		p.HasMore = true
+		p.NextCursor = next(p.Items[n-1])
+	}
+	return p
+}
+
+// Encode: キーセット用の cursor（keys は ORDER BY と同じ順）
+func Encode(sort string, keys ...any) string {
+	raws := make([]json.RawMessage, len(keys))
+	for i, k := range keys {
+		b, err := json.Marshal(k)
+		if err != nil {
+			// キーは時刻・数値・文字列だけなので起こらない
+			panic("pagination: " + err.Error())
+		}
+		raws[i] = b
+	}
+	return encode(cursor{Sort: sort, Keys: raws})
+}
+
+// EncodeOffset: 読み飛ばし件数の cursor
+func EncodeOffset(sort string, offset int) string {
+	return encode(cursor{Sort: sort, Offset: offset})
+}
+
+func encode(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ===== SQL =====
+
+// Column: ORDER BY の1列（Expr はコード内の固定文字列だけを渡すこと）
+type Column struct {
+	Expr string
+	Desc bool
+}
+
+// OrderBy: "a DESC, b DESC"
+func OrderBy(cols []Column) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = c.Expr + dir(c.Desc)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Seek: cols の並びで vals より後ろの行だけを通す条件。
+// 昇順・降順が混ざっていても使えるよう (a > ?) OR (a = ? AND b > ?) ... の形に展開する。
+func Seek(cols []Column, vals []any) (string, []any) {
+	var (
+		ors  []string
+		args []any
+	)
+	for i, c := range cols {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, cols[j].Expr+" = ?")
+			args = append(args, vals[j])
+		}
+		op := " > ?"
+		if c.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, c.Expr+op)
+		args = append(args, vals[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func dir(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+// Map: ページの要素を変換する（cursor・has_more はそのまま）
+func Map[T, U any](p Page[T], fn func(T) U) Page[U] {
+	out := Page[U]{Items: make([]U, 0, len(p.Items)), NextCursor: p.NextCursor, HasMore: p.HasMore}
+	for _, v := range p.Items {
+		out.Items = append(out.Items, fn(v))
+	}
+	return out
+}
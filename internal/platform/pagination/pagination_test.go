@@ -0,0 +1,200 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func request(t *testing.T, query string) (Request, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/items?"+query, nil)
+	return FromQuery(c)
+}
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "defaults", query: "", wantLimit: DefaultLimit},
+		{name: "limit", query: "limit=10", wantLimit: 10},
+		{name: "limit is capped", query: "limit=1000", wantLimit: MaxLimit},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "negative limit", query: "limit=-1", wantErr: true},
+		{name: "non-numeric limit", query: "limit=abc", wantErr: true},
+		{name: "cursor is not base64", query: "cursor=%2A%2A", wantErr: true},
+		{name: "cursor is not json", query: "cursor=bm90LWpzb24", wantErr: true},
+		{name: "valid cursor", query: "cursor=" + Encode("id:desc", 1), wantLimit: DefaultLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := request(t, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && r.Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, want %d", r.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2025, time.January, 2, 3, 4, 5, 0, time.UTC)
+	r, err := request(t, "cursor="+Encode("lent_at:desc", at, uint64(42), "PC-0001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		gotAt  time.Time
+		gotID  uint64
+		gotMng string
+	)
+	ok, err := r.After("lent_at:desc", &gotAt, &gotID, &gotMng)
+	if err != nil || !ok {
+		t.Fatalf("After() = %v, %v", ok, err)
+	}
+	if !gotAt.Equal(at) || gotID != 42 || gotMng != "PC-0001" {
+		t.Errorf("After() keys = %v, %d, %q", gotAt, gotID, gotMng)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	cur := "cursor=" + Encode("id:desc", uint64(7))
+	tests := []struct {
+		name    string
+		query   string
+		sort    string
+		dest    func() []any
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "no cursor", query: "", sort: "id:desc", dest: func() []any { return []any{new(uint64)} }},
+		{name: "match", query: cur, sort: "id:desc", dest: func() []any { return []any{new(uint64)} }, wantOK: true},
+		{name: "other sort", query: cur, sort: "name:asc", dest: func() []any { return []any{new(uint64)} }, wantErr: true},
+		{name: "key count", query: cur, sort: "id:desc", dest: func() []any { return []any{new(string), new(uint64)} }, wantErr: true},
+		{name: "key type", query: cur, sort: "id:desc", dest: func() []any { return []any{new(string)} }, wantErr: true},
+		{name: "offset cursor", query: "cursor=" + EncodeOffset("id:desc", 50), sort: "id:desc", dest: func() []any { return []any{new(uint64)} }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := request(t, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := r.After(tt.sort, tt.dest()...)
+			if (err != nil) != tt.wantErr || ok != tt.wantOK {
+				t.Errorf("After() = %v, %v; want %v, wantErr %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{name: "no cursor", query: "", want: 0},
+		{name: "offset", query: "cursor=" + EncodeOffset("relevance", 100), want: 100},
+		{name: "other sort", query: "cursor=" + EncodeOffset("name:asc", 100), wantErr: true},
+		{name: "keyset cursor", query: "cursor=" + Encode("relevance", 1), wantErr: true},
+		{name: "negative", query: "cursor=" + EncodeOffset("relevance", -1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := request(t, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.Offset("relevance")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Offset() = %d, %v; want %d, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	next := func(last int) string { return strconv.Itoa(last) }
+	tests := []struct {
+		name       string
+		rows       []int
+		wantItems  []int
+		wantMore   bool
+		wantCursor string
+	}{
+		{name: "empty", rows: nil, wantItems: []int{}},
+		{name: "exactly limit", rows: []int{1, 2, 3}, wantItems: []int{1, 2, 3}},
+		{name: "one extra row", rows: []int{1, 2, 3, 4}, wantItems: []int{1, 2, 3}, wantMore: true, wantCursor: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Build(Request{Limit: 3}, tt.rows, next)
+			if !reflect.DeepEqual(p.Items, tt.wantItems) || p.HasMore != tt.wantMore || p.NextCursor != tt.wantCursor {
+				t.Errorf("Build() = %+v", p)
+			}
+		})
+	}
+}
+
+func TestSeek(t *testing.T) {
+	tests := []struct {
+		name     string
+		cols     []Column
+		vals     []any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "single column desc",
+			cols:     []Column{{Expr: "id", Desc: true}},
+			vals:     []any{10},
+			wantSQL:  "((id < ?))",
+			wantArgs: []any{10},
+		},
+		{
+			name:     "two columns asc",
+			cols:     []Column{{Expr: "a.name"}, {Expr: "a.id"}},
+			vals:     []any{"x", 5},
+			wantSQL:  "((a.name > ?) OR (a.name = ? AND a.id > ?))",
+			wantArgs: []any{"x", "x", 5},
+		},
+		{
+			name:     "mixed directions",
+			cols:     []Column{{Expr: "due_on"}, {Expr: "lent_at", Desc: true}, {Expr: "lend_id", Desc: true}},
+			vals:     []any{"2025-01-01", "t", 9},
+			wantSQL:  "((due_on > ?) OR (due_on = ? AND lent_at < ?) OR (due_on = ? AND lent_at = ? AND lend_id < ?))",
+			wantArgs: []any{"2025-01-01", "2025-01-01", "t", "2025-01-01", "t", 9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := Seek(tt.cols, tt.vals)
+			if sql != tt.wantSQL {
+				t.Errorf("Seek() sql = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Seek() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	got := OrderBy([]Column{{Expr: "name"}, {Expr: "id", Desc: true}})
+	if want := "name ASC, id DESC"; got != want {
+		t.Errorf("OrderBy() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package reqid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/id"
+)
+
+const Header = "X-Request-ID"
+
+// 受け取った値はログ・監査に残るので、英数と一部記号のみ許可
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type ctxKey struct{}
+
+var gen = id.NewULIDGen()
+
+// Middleware: X-Request-ID を引き継ぐ（無ければ ULID を採番）し、レスポンスにも付ける
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rid := c.GetHeader(Header)
+		if !validID.MatchString(rid) {
+			rid = gen.New()
+		}
+		c.Header(Header, rid)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, rid))
+		c.Next()
+	}
+}
+
+func FromContext(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(string)
+	return v
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"IRIS-backend/internal/platform/db"
+)
+
+// ErrNotFound: key に対応する実体がない
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object: 読み出し中の実体（http.ServeContent に渡せるよう Seek できる）
+type Object interface {
+	io.ReadSeekCloser
+}
+
+// Storage: ファイルの実体の置き場所。key は "/" 区切りの相対パス（".." や先頭の "/" は不可）
+type Storage interface {
+	// Put: r を最後まで読んで key に保存する（同じ key があれば置き換える）
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open: 見つからなければ ErrNotFound
+	Open(ctx context.Context, key string) (Object, error)
+	// Delete: 見つからなくてもエラーにしない
+	Delete(ctx context.Context, key string) error
+}
+
+// New: 設定の driver に応じた実装を返す
+func New(cfg db.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "data/storage"
+		}
+		return NewLocal(dir)
+	}
+	return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+}
+
+// MaxUploadBytes: 設定の上限（MB）をバイトにする。0 なら 20MB
+func MaxUploadBytes(cfg db.StorageConfig) int64 {
+	if cfg.MaxUploadMB <= 0 {
+		return 20 << 20
+	}
+	return int64(cfg.MaxUploadMB) << 20
+}
+
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	return nil
+}
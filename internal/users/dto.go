@@ -0,0 +1,49 @@
+package users
+
+import "time"
+
+// ---- Requests ----
+
+type LoginRequest struct {
+	LoginID  string `json:"login_id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type CreateUserRequest struct {
+	LoginID     string  `json:"login_id" binding:"required"`
+	DisplayName string  `json:"display_name" binding:"required"`
+	Email       *string `json:"email,omitempty"` // 通知（返却期限のリマインダ等）の送り先
+	Password    string  `json:"password" binding:"required"`
+	Role        *string `json:"role,omitempty"` // 省略時 member
+}
+
+type UpdateUserRequest struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Email       *string `json:"email,omitempty"` // "" で削除
+	Role        *string `json:"role,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ---- Responses ----
+
+type UserResponse struct {
+	UserID      uint64    `json:"user_id"`
+	LoginID     string    `json:"login_id"`
+	DisplayName string    `json:"display_name"`
+	Email       *string   `json:"email,omitempty"`
+	Role        string    `json:"role"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type LoginResponse struct {
+	Token     string       `json:"token"`
+	TokenType string       `json:"token_type"` // 常に "Bearer"
+	ExpiresAt time.Time    `json:"expires_at"`
+	User      UserResponse `json:"user"`
+}
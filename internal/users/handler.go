@@ -0,0 +1,141 @@
+package users
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+type Handler struct{ svc *Service }
+
+// RegisterPublicRoutes: 認証ミドルウェアの外側に登録するルート
+func RegisterPublicRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+	r.POST("/auth/login", h.Login)
+}
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	// 自分自身
+	r.POST("/auth/logout", h.Logout)
+	r.GET("/auth/me", h.Me)
+	r.PUT("/auth/password", h.ChangePassword)
+
+	// ユーザ管理
+	r.POST("/users", auth.Allow(auth.AdminOnly...), h.CreateUser)
+	r.GET("/users", auth.Allow(auth.AdminOnly...), h.ListUsers)
+	r.GET("/users/:user_id", auth.Allow(auth.AdminOnly...), h.GetUser)
+	r.PUT("/users/:user_id", auth.Allow(auth.AdminOnly...), h.UpdateUser)
+}
+
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.Login(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) Logout(c *gin.Context) {
+	if err := h.svc.Logout(c.Request.Context(), auth.TokenFromRequest(c)); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Me(c *gin.Context) {
+	res, err := h.svc.Me(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	if err := h.svc.ChangePassword(c.Request.Context(), req); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.CreateUser(c.Request.Context(), req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Header("Location", "/users/"+strconv.FormatUint(res.UserID, 10))
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *Handler) GetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("user_id must be a number"))
+		return
+	}
+	res, err := h.svc.GetUser(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) UpdateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.Invalid("user_id must be a number"))
+		return
+	}
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	res, err := h.svc.UpdateUser(c.Request.Context(), id, req)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	p, err := pagination.FromQuery(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	res, err := h.svc.ListUsers(c.Request.Context(), p)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
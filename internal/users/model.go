@@ -0,0 +1,38 @@
+package users
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DBモデル（users テーブルと1:1）
+type User struct {
+	UserID       uint64
+	LoginID      string
+	DisplayName  string
+	Email        sql.NullString
+	Role         string
+	PasswordHash string
+	IsActive     bool
+	CreatedAt    time.Time
+}
+
+func (u User) toDTO() UserResponse {
+	return UserResponse{
+		UserID:      u.UserID,
+		LoginID:     u.LoginID,
+		DisplayName: u.DisplayName,
+		Email:       nullToPtr(u.Email),
+		Role:        u.Role,
+		IsActive:    u.IsActive,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+func nullToPtr(ns sql.NullString) *string {
+	if ns.Valid {
+		v := ns.String
+		return &v
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
+
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/pagination"
+)
+
+// ===== Clock =====
+
+type Clock interface{ Now() time.Time }
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now().UTC() }
+
+// ===== Service =====
+
+const (
+	defaultSessionTTL = 12 * time.Hour
+	minPasswordLen    = 8
+	tokenBytes        = 32
+)
+
+// 存在しないユーザでも bcrypt 比較を1回走らせ、応答時間で存在有無が漏れないようにする
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+
+type Service struct {
+	db         *sql.DB
+	store      *Store
+	clock      Clock
+	sessionTTL time.Duration
+}
+
+func NewService(db *sql.DB, cfg db.AuthConfig) *Service {
+	ttl := defaultSessionTTL
+	if cfg.SessionTTLHours > 0 {
+		ttl = time.Duration(cfg.SessionTTLHours) * time.Hour
+	}
+	return &Service{db: db, store: NewStore(db), clock: realClock{}, sessionTTL: ttl}
+}
+
+// POST /auth/login
+func (s *Service) Login(ctx context.Context, in LoginRequest) (LoginResponse, error) {
+	loginID := strings.TrimSpace(in.LoginID)
+	if loginID == "" || in.Password == "" {
+		return LoginResponse{}, apperr.Invalid("login_id and password are required")
+	}
+
+	u, err := s.store.GetUserByLoginID(ctx, loginID)
+	if err != nil && err != sql.ErrNoRows {
+		return LoginResponse{}, err
+	}
+	if u == nil || !u.IsActive {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(in.Password))
+		return LoginResponse{}, apperr.Unauthenticated("invalid login_id or password")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(in.Password)) != nil {
+		return LoginResponse{}, apperr.Unauthenticated("invalid login_id or password")
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	now := s.clock.Now()
+	exp := now.Add(s.sessionTTL)
+	if err := s.store.InsertSession(ctx, hash, u.UserID, now, exp); err != nil {
+		return LoginResponse{}, err
+	}
+	return LoginResponse{Token: token, TokenType: "Bearer", ExpiresAt: exp, User: u.toDTO()}, nil
+}
+
+// POST /auth/logout
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return apperr.Unauthenticated("missing bearer token")
+	}
+	return s.store.RevokeSession(ctx, hashToken(token), s.clock.Now())
+}
+
+// Authenticate: auth.Authenticator の実装（ミドルウェアから呼ばれる）
+func (s *Service) Authenticate(ctx context.Context, token string) (auth.Principal, error) {
+	p, err := s.store.GetPrincipalByTokenHash(ctx, hashToken(token), s.clock.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return auth.Principal{}, apperr.Unauthenticated("invalid or expired token")
+		}
+		return auth.Principal{}, err
+	}
+	return p, nil
+}
+
+// GET /auth/me
+func (s *Service) Me(ctx context.Context) (UserResponse, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return UserResponse{}, apperr.Unauthenticated("not logged in")
+	}
+	return s.GetUser(ctx, p.UserID)
+}
+
+// PUT /auth/password
+func (s *Service) ChangePassword(ctx context.Context, in ChangePasswordRequest) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return apperr.Unauthenticated("not logged in")
+	}
+	u, err := s.store.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("user not found")
+		}
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(in.CurrentPassword)) != nil {
+		return apperr.Unauthenticated("current_password does not match")
+	}
+	hash, err := hashPassword(in.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdatePasswordHash(ctx, u.UserID, hash); err != nil {
+		return err
+	}
+	// 既存セッションはすべて無効化（再ログインさせる）
+	return s.store.RevokeAllSessions(ctx, u.UserID, s.clock.Now())
+}
+
+// POST /users
+func (s *Service) CreateUser(ctx context.Context, in CreateUserRequest) (UserResponse, error) {
+	loginID := strings.TrimSpace(in.LoginID)
+	name := strings.TrimSpace(in.DisplayName)
+	if loginID == "" || name == "" {
+		return UserResponse{}, apperr.Invalid("login_id and display_name are required")
+	}
+	email, err := normalizeEmail(in.Email)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	role := auth.RoleMember
+	if in.Role != nil {
+		role = auth.Role(*in.Role)
+		if !role.Valid() {
+			return UserResponse{}, apperr.Invalid("role must be one of admin, staff, member, kiosk")
+		}
+	}
+	hash, err := hashPassword(in.Password)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	var emailArg *string
+	if email != "" {
+		emailArg = &email
+	}
+	id, err := s.store.InsertUser(ctx, loginID, name, emailArg, string(role), hash)
+	if err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 {
+			return UserResponse{}, apperr.Conflict("login_id already exists")
+		}
+		return UserResponse{}, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+// normalizeEmail: 空なら ""（未設定）。表示名付き（"名前 <addr>"）は受け付けない
+func normalizeEmail(in *string) (string, error) {
+	if in == nil {
+		return "", nil
+	}
+	v := strings.TrimSpace(*in)
+	if v == "" {
+		return "", nil
+	}
+	addr, err := mail.ParseAddress(v)
+	if err != nil || addr.Address != v || len(v) > 254 {
+		return "", apperr.Invalid("validation failed").WithField("email", "must be a valid email address")
+	}
+	return v, nil
+}
+
+// PUT /users/:user_id
+func (s *Service) UpdateUser(ctx context.Context, id uint64, in UpdateUserRequest) (UserResponse, error) {
+	if in.Role != nil && !auth.Role(*in.Role).Valid() {
+		return UserResponse{}, apperr.Invalid("role must be one of admin, staff, member, kiosk")
+	}
+	if in.DisplayName != nil && strings.TrimSpace(*in.DisplayName) == "" {
+		return UserResponse{}, apperr.Invalid("display_name must not be empty")
+	}
+	if in.Email != nil {
+		email, err := normalizeEmail(in.Email)
+		if err != nil {
+			return UserResponse{}, err
+		}
+		in.Email = &email
+	}
+	// 自分自身の降格・無効化で管理者不在になるのを防ぐ
+	if p, ok := auth.FromContext(ctx); ok && p.UserID == id {
+		if (in.Role != nil && auth.Role(*in.Role) != p.Role) || (in.IsActive != nil && !*in.IsActive) {
+			return UserResponse{}, apperr.Forbidden("cannot change your own role or deactivate yourself")
+		}
+	}
+	err := db.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx db.DBTX) error {
+		st := NewStore(tx)
+		// 有効な管理者を先にロックしてから対象を読む（他の管理者による降格・無効化でも管理者不在にしない）
+		admins, err := st.LockActiveAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		u, err := st.LockUserByID(ctx, id)
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("user not found")
+		}
+		if err != nil {
+			return err
+		}
+		demoted := in.Role != nil && auth.Role(*in.Role) != auth.RoleAdmin
+		deactivated := in.IsActive != nil && !*in.IsActive
+		if auth.Role(u.Role) == auth.RoleAdmin && u.IsActive && (demoted || deactivated) && len(admins) <= 1 {
+			return apperr.Conflict("cannot demote or deactivate the last active admin")
+		}
+		if err := st.UpdateUserByID(ctx, id, in); err != nil {
+			return err
+		}
+		// 無効化・ロール変更は既存セッションに即時反映させる
+		if in.Role != nil || deactivated {
+			return st.RevokeAllSessions(ctx, id, s.clock.Now())
+		}
+		return nil
+	})
+	if err != nil {
+		return UserResponse{}, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+func (s *Service) GetUser(ctx context.Context, id uint64) (UserResponse, error) {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return UserResponse{}, apperr.NotFound("user not found")
+		}
+		return UserResponse{}, err
+	}
+	return u.toDTO(), nil
+}
+
+const sortUsers = "user_id"
+
+// GET /users
+func (s *Service) ListUsers(ctx context.Context, p pagination.Request) (pagination.Page[UserResponse], error) {
+	var afterID uint64
+	if _, err := p.After(sortUsers, &afterID); err != nil {
+		return pagination.Page[UserResponse]{}, err
+	}
+	rows, err := s.store.ListUsers(ctx, afterID, p.Fetch())
+	if err != nil {
+		return pagination.Page[UserResponse]{}, err
+	}
+	items := make([]UserResponse, 0, len(rows))
+	for _, u := range rows {
+		items = append(items, u.toDTO())
+	}
+	return pagination.Build(p, items, func(last UserResponse) string {
+		return pagination.Encode(sortUsers, last.UserID)
+	}), nil
+}
+
+// ===== helpers =====
+
+func hashPassword(pw string) (string, error) {
+	if len(pw) < minPasswordLen {
+		return "", apperr.Invalid(fmt.Sprintf("password must be at least %d characters", minPasswordLen))
+	}
+	if len(pw) > 72 { // bcrypt の上限
+		return "", apperr.Invalid("password must be at most 72 bytes")
+	}
+	b, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// newToken: クライアントへ返す不透明トークンと、DB保存用のハッシュを作る
+func newToken() (token, hash string, err error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/platform/auth"
+	"IRIS-backend/internal/platform/db"
+)
+
+type Store struct{ db db.DBTX }
+
+func NewStore(q db.DBTX) *Store { return &Store{db: q} }
+
+// ===== users =====
+
+func (s *Store) InsertUser(ctx context.Context, loginID, displayName string, email *string, role, passwordHash string) (uint64, error) {
+	const q = `
+	INSERT INTO users (login_id, display_name, email, role, password_hash, is_active, created_at)
+	VALUES (?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP)`
+	res, err := s.db.ExecContext(ctx, q, loginID, displayName, email, role, passwordHash)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uint64) (*User, error) {
+	const q = `
+	SELECT user_id, login_id, display_name, email, role, password_hash, is_active, created_at
+	FROM users WHERE user_id = ?`
+	var u User
+	if err := s.db.QueryRowContext(ctx, q, id).Scan(
+		&u.UserID, &u.LoginID, &u.DisplayName, &u.Email, &u.Role, &u.PasswordHash, &u.IsActive, &u.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// LockUserByID: 更新前の値をロックして読む
+func (s *Store) LockUserByID(ctx context.Context, id uint64) (*User, error) {
+	const q = `
+	SELECT user_id, login_id, display_name, email, role, password_hash, is_active, created_at
+	FROM users WHERE user_id = ? FOR UPDATE`
+	var u User
+	if err := s.db.QueryRowContext(ctx, q, id).Scan(
+		&u.UserID, &u.LoginID, &u.DisplayName, &u.Email, &u.Role, &u.PasswordHash, &u.IsActive, &u.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// LockActiveAdmins: 有効な管理者の user_id を user_id 順にロックして返す
+// （管理者の降格・無効化を同時に行っても、最後の1人を外せないようにする）
+func (s *Store) LockActiveAdmins(ctx context.Context) ([]uint64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id FROM users WHERE role = ? AND is_active = 1 ORDER BY user_id FOR UPDATE`, string(auth.RoleAdmin))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetUserByLoginID(ctx context.Context, loginID string) (*User, error) {
+	const q = `
+	SELECT user_id, login_id, display_name, email, role, password_hash, is_active, created_at
+	FROM users WHERE login_id = ?`
+	var u User
+	if err := s.db.QueryRowContext(ctx, q, loginID).Scan(
+		&u.UserID, &u.LoginID, &u.DisplayName, &u.Email, &u.Role, &u.PasswordHash, &u.IsActive, &u.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *Store) UpdateUserByID(ctx context.Context, id uint64, in UpdateUserRequest) error {
+	sets := []string{}
+	args := []any{}
+	if in.DisplayName != nil {
+		sets = append(sets, "display_name = ?")
+		args = append(args, *in.DisplayName)
+	}
+	if in.Email != nil {
+		sets = append(sets, "email = NULLIF(?, '')")
+		args = append(args, *in.Email)
+	}
+	if in.Role != nil {
+		sets = append(sets, "role = ?")
+		args = append(args, *in.Role)
+	}
+	if in.IsActive != nil {
+		sets = append(sets, "is_active = ?")
+		args = append(args, *in.IsActive)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE users SET %s WHERE user_id = ?`, strings.Join(sets, ", "))
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		// 値が同じでも 0 になるので存在確認で切り分ける
+		if _, err := s.GetUserByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdatePasswordHash(ctx context.Context, id uint64, hash string) error {
+	const q = `UPDATE users SET password_hash = ? WHERE user_id = ?`
+	res, err := s.db.ExecContext(ctx, q, hash, id)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListUsers: user_id 昇順。afterID より後ろを limit 件
+func (s *Store) ListUsers(ctx context.Context, afterID uint64, limit int) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT user_id, login_id, display_name, email, role, password_hash, is_active, created_at
+	FROM users WHERE user_id > ? ORDER BY user_id ASC LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []User{}
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.UserID, &u.LoginID, &u.DisplayName, &u.Email, &u.Role, &u.PasswordHash, &u.IsActive, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// ===== sessions =====
+
+func (s *Store) InsertSession(ctx context.Context, tokenHash string, userID uint64, now, expiresAt time.Time) error {
+	const q = `
+	INSERT INTO user_sessions (token_hash, user_id, created_at, expires_at)
+	VALUES (?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, q, tokenHash, userID, now, expiresAt)
+	return err
+}
+
+// 有効なセッション（未失効・期限内・ユーザ有効）から Principal を引く
+func (s *Store) GetPrincipalByTokenHash(ctx context.Context, tokenHash string, now time.Time) (auth.Principal, error) {
+	const q = `
+	SELECT u.user_id, u.login_id, u.display_name, u.role
+	FROM user_sessions s
+	JOIN users u ON u.user_id = s.user_id
+	WHERE s.token_hash = ?
+	AND s.revoked_at IS NULL
+	AND s.expires_at > ?
+	AND u.is_active = 1`
+	var p auth.Principal
+	if err := s.db.QueryRowContext(ctx, q, tokenHash, now).Scan(&p.UserID, &p.LoginID, &p.Name, &p.Role); err != nil {
+		return auth.Principal{}, err
+	}
+	return p, nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, tokenHash string, now time.Time) error {
+	const q = `UPDATE user_sessions SET revoked_at = ? WHERE token_hash = ? AND revoked_at IS NULL`
+	_, err := s.db.ExecContext(ctx, q, now, tokenHash)
+	return err
+}
+
+func (s *Store) RevokeAllSessions(ctx context.Context, userID uint64, now time.Time) error {
+	const q = `UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	_, err := s.db.ExecContext(ctx, q, now, userID)
+	return err
+}
@@ -19,11 +19,29 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 
 	"IRIS-backend/internal/asset_mgmt/assets"
+	"IRIS-backend/internal/asset_mgmt/attachments"
+	"IRIS-backend/internal/asset_mgmt/categories"
 	"IRIS-backend/internal/asset_mgmt/disposals"
+	"IRIS-backend/internal/asset_mgmt/genres"
+	"IRIS-backend/internal/asset_mgmt/imports"
 	"IRIS-backend/internal/asset_mgmt/lends"
+	"IRIS-backend/internal/asset_mgmt/locations"
+	"IRIS-backend/internal/asset_mgmt/numbering"
 	"IRIS-backend/internal/asset_mgmt/printLabels"
+	"IRIS-backend/internal/asset_mgmt/reminders"
+	"IRIS-backend/internal/asset_mgmt/reservations"
+	"IRIS-backend/internal/asset_mgmt/statuses"
+	"IRIS-backend/internal/asset_mgmt/stocktakes"
+	"IRIS-backend/internal/asset_mgmt/transfers"
 	"IRIS-backend/internal/attendance"
+	"IRIS-backend/internal/audit"
+	"IRIS-backend/internal/platform/apperr"
+	"IRIS-backend/internal/platform/auth"
 	"IRIS-backend/internal/platform/db"
+	"IRIS-backend/internal/platform/notify"
+	"IRIS-backend/internal/platform/reqid"
+	"IRIS-backend/internal/platform/storage"
+	"IRIS-backend/internal/users"
 )
 
 // フロントのビルド出力を埋め込む（backend/public 配下）
@@ -46,16 +64,26 @@ func main() {
 
 	log.Printf("[INFO] connected to DB: %s", cfg.DB.DBName)
 
+	numberingScheme, err := numbering.New(cfg.Numbering)
+	if err != nil {
+		panic(err)
+	}
+
+	fileStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(gin.Logger(), gin.Recovery(), reqid.Middleware(), apperr.Middleware())
 	_ = r.SetTrustedProxies(nil)
 
 	// CORS（開発中のみ必要。埋め込み配信に切り替えたら基本不要）
 	// r.Use(cors.New(cors.Config{
 	// 	AllowOrigins:     []string{"http://localhost:3000"},
-	// 	AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
-	// 	ExposeHeaders:    []string{"Content-Length"},
+	// 	AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", "If-Match", "If-None-Match"},
+	// 	ExposeHeaders:    []string{"Content-Length", "ETag", "Location"},
 	// 	AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 	// 	AllowCredentials: true,
 	// }))
@@ -65,11 +93,32 @@ func main() {
 
 	// /api/v2
 	api := r.Group("/api/v2")
-	assets.RegisterRoutes(api, assets.NewService(conn))
-	lends.RegisterRoutes(api, lends.NewService(conn))
-	disposals.RegisterRoutes(api, disposals.NewService(conn))
-	attendance.RegisterRoutes(api, attendance.NewService(conn))
-	printLabels.RegisterRoutes(api, printLabels.NewService())
+	usersSvc := users.NewService(conn, cfg.Auth)
+	users.RegisterPublicRoutes(api, usersSvc)
+
+	// ここから下は Bearer トークン必須
+	authed := api.Group("", auth.Middleware(usersSvc))
+	users.RegisterRoutes(authed, usersSvc)
+	assetsSvc := assets.NewService(conn, numberingScheme)
+	assets.RegisterRoutes(authed, assetsSvc)
+	imports.RegisterRoutes(authed, imports.NewService(conn, assetsSvc))
+	statuses.RegisterRoutes(authed, statuses.NewService(conn))
+	genres.RegisterRoutes(authed, genres.NewService(conn))
+	categories.RegisterRoutes(authed, categories.NewService(conn))
+	locations.RegisterRoutes(authed, locations.NewService(conn))
+	notifier := notify.New(cfg.Notify)
+	reservationsSvc := reservations.NewService(conn, notifier, cfg.Reservations)
+	reservations.RegisterRoutes(authed, reservationsSvc)
+	lends.RegisterRoutes(authed, lends.NewService(conn, cfg.Lends, reservationsSvc))
+	remindersSvc := reminders.NewService(conn, notifier, cfg.Reminders)
+	reminders.RegisterRoutes(authed, remindersSvc)
+	disposals.RegisterRoutes(authed, disposals.NewService(conn, reservationsSvc))
+	transfers.RegisterRoutes(authed, transfers.NewService(conn))
+	attachments.RegisterRoutes(authed, attachments.NewService(conn, fileStore, storage.MaxUploadBytes(cfg.Storage)))
+	stocktakes.RegisterRoutes(authed, stocktakes.NewService(conn))
+	attendance.RegisterRoutes(authed, attendance.NewService(conn))
+	printLabels.RegisterRoutes(authed, printLabels.NewService())
+	audit.RegisterRoutes(authed, audit.NewService(conn))
 
 	sub, err := fs.Sub(embedded, "public")
 	if err != nil {
@@ -135,6 +184,12 @@ func main() {
 	certFile := fmt.Sprintf("config/tls/deploy/%s", cfg.Certificate.Cert)
 	keyFile := fmt.Sprintf("config/tls/deploy/%s", cfg.Certificate.Key)
 
+	// 返却期限のリマインダと予約の期限切れ（定期実行）。シャットダウン時に止める
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	remindersSvc.Start(bgCtx)
+	reservationsSvc.Start(bgCtx)
+
 	go func() {
 		log.Println("[INFO] listening on https://0.0.0.0:8443")
 		if err := srv.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
@@ -147,6 +202,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	log.Println("[INFO] shutting down...")
+	stopBackground()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {